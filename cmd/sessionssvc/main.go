@@ -1,21 +1,34 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
+	"expvar"
 	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 
+	kitexpvar "github.com/go-kit/kit/metrics/expvar"
 	"github.com/oklog/run"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	"github.com/Soroka-EDMS/svc/sessions/pkgs/config"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/constants"
 	"github.com/Soroka-EDMS/svc/sessions/pkgs/db"
 	"github.com/Soroka-EDMS/svc/sessions/pkgs/endpoints"
 	"github.com/Soroka-EDMS/svc/sessions/pkgs/handlers"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/notify"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/permissions"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/policy"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/ratelimit"
 	"github.com/Soroka-EDMS/svc/sessions/pkgs/service"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/users"
 )
 
 func main() {
@@ -23,11 +36,27 @@ func main() {
 
 	var (
 		httpAddr   = flag.String("address", ":443", "")
+		grpcAddr   = flag.String("grpc.address", ":8443", "gRPC listener address, TLS is shared with HTTPS listener")
+		debugAddr  = flag.String("debug.address", "", "debug listener address exposing metrics on /debug/vars (disabled if empty)")
 		consulAddr = flag.String("consul.address", "localhost:8500", "Consul agent address")
 		conn       = flag.String("consul.sessionsdb", "sessionsdb", "database connection string")
 		certKey    = flag.String("consul.tls.pubkey", "tls/pubKey", "tls certificate")
 		privateKey = flag.String("consul.tls.privkey", "tls/privKey", "tls private key")
+		clientCA   = flag.String("consul.tls.clientCA", "tls/clientCA", "CA bundle to verify client certificates of calling services with. Per service rate limits apply to verified services only, none if missing")
 		signingKey = flag.String("consul.service.signingKey", "service/signingKey", "Secret key to sign JWT")
+		serviceKey = flag.String("consul.service.serviceKey", "service/serviceKey", "PEM encoded EC private key (P-256) to sign tokens of Sessions service for Users service")
+		mfaKey     = flag.String("consul.service.mfaKey", "service/mfaKey", "Secret key to encrypt MFA secrets with")
+		rateLimits = flag.String("consul.service.rateLimits", "service/rateLimits", "JSON rate limits of endpoints. Defaults are used if missing")
+		sessLimits = flag.String("consul.service.sessionLimits", "service/sessionLimits", "JSON caps of simultaneous sessions per user. Sessions are not limited if missing")
+		notifySink = flag.String("consul.service.notifications", "service/notifications", "JSON settings of webhook or SMTP relay to notify users with. SMTP relay also delivers email login codes. Users are not notified if missing")
+		perms      = flag.String("consul.service.permissions", "service/permissions", "JSON map of permission names to bits of role mask, admin included. Only admin permission is known if missing")
+		policies   = flag.String("consul.service.policies", "service/policies", "JSON policies of /session/decide, reloaded on change. Every action is refused if missing")
+		usersCA    = flag.String("consul.users.ca", "users/tls/ca", "CA bundle to verify Users service certificate. System roots are used if missing")
+		usersCert  = flag.String("consul.users.cert", "users/tls/cert", "client certificate for mutual TLS with Users service (optional)")
+		usersKey   = flag.String("consul.users.key", "users/tls/key", "client private key for mutual TLS with Users service (optional)")
+		usersName  = flag.String("users.servername", "", "server name to verify Users service certificate against")
+		usersSvc   = flag.String("users.service", constants.UsersServiceName, "Users service name in Consul catalog")
+		usersTag   = flag.String("users.tag", "", "tag to filter Users service instances in Consul catalog (optional)")
 	)
 
 	//Parse CLI parameters
@@ -39,30 +68,105 @@ func main() {
 	//Log CLI parameters
 	logger.Log(
 		"address", *httpAddr,
+		"grpc.address", *grpcAddr,
+		"debug.address", *debugAddr,
 		"consul.address", *consulAddr,
 		"consul.sessionsdb", *conn,
 		"consul.tls.pubkey", *certKey,
 		"consul.tls.privkey", *privateKey,
+		"consul.tls.clientCA", *clientCA,
 		"consul.service.secret", *signingKey,
+		"consul.service.serviceKey", *serviceKey,
+		"consul.service.mfaKey", *mfaKey,
+		"consul.service.rateLimits", *rateLimits,
+		"consul.service.sessionLimits", *sessLimits,
+		"consul.service.notifications", *notifySink,
+		"consul.service.permissions", *perms,
+		"consul.service.policies", *policies,
+		"consul.users.ca", *usersCA,
+		"consul.users.cert", *usersCert,
+		"consul.users.key", *usersKey,
+		"users.servername", *usersName,
+		"users.service", *usersSvc,
+		"users.tag", *usersTag,
 	)
 
-	//Obtain consul k/v storage
-	consulStorage, err := GetConsulClient(*consulAddr)
+	//Obtain consul client and its k/v storage
+	consulClient, err := GetConsulClient(*consulAddr)
 	config.LogAndTerminateOnError(err, "create consul client")
+	consulStorage := consulClient.KV()
 
 	//Obtain tls pair (raw)
 	certKeyData, err := ConsulGetKey(consulStorage, *certKey)
 	config.LogAndTerminateOnError(err, "obtain certificate key")
 	privateKeyData, err := ConsulGetKey(consulStorage, *privateKey)
 	config.LogAndTerminateOnError(err, "obtain private key")
+	clientCAData, err := ConsulGetOptionalKey(consulStorage, *clientCA)
+	config.LogAndTerminateOnError(err, "obtain client CA bundle")
 
 	signSecret, err := ConsulGetKey(consulStorage, *signingKey)
 	config.LogAndTerminateOnError(err, "obtain secret")
 
+	serviceKeyData, err := ConsulGetKey(consulStorage, *serviceKey)
+	config.LogAndTerminateOnError(err, "obtain service key")
+	serviceTokens, err := service.NewServiceTokenSource(serviceKeyData)
+	config.LogAndTerminateOnError(err, "parse service key")
+
+	mfaSecret, err := ConsulGetKey(consulStorage, *mfaKey)
+	config.LogAndTerminateOnError(err, "obtain MFA encryption key")
+
+	limitsCfg := ratelimit.DefaultConfig()
+	rawLimits, err := ConsulGetOptionalKey(consulStorage, *rateLimits)
+	config.LogAndTerminateOnError(err, "obtain rate limits")
+	if len(rawLimits) > 0 {
+		limitsCfg, err = ratelimit.ParseConfig(rawLimits)
+		config.LogAndTerminateOnError(err, "parse rate limits")
+	}
+
+	var sessionLimits service.SessionLimits
+	rawSessionLimits, err := ConsulGetOptionalKey(consulStorage, *sessLimits)
+	config.LogAndTerminateOnError(err, "obtain session limits")
+	if len(rawSessionLimits) > 0 {
+		sessionLimits, err = service.ParseSessionLimits(rawSessionLimits)
+		config.LogAndTerminateOnError(err, "parse session limits")
+	}
+
+	var notifyCfg notify.Config
+	rawNotify, err := ConsulGetOptionalKey(consulStorage, *notifySink)
+	config.LogAndTerminateOnError(err, "obtain notification settings")
+	if len(rawNotify) > 0 {
+		notifyCfg, err = notify.ParseConfig(rawNotify)
+		config.LogAndTerminateOnError(err, "parse notification settings")
+	}
+
+	registry := permissions.DefaultRegistry()
+	rawPermissions, err := ConsulGetOptionalKey(consulStorage, *perms)
+	config.LogAndTerminateOnError(err, "obtain permissions")
+	if len(rawPermissions) > 0 {
+		registry, err = permissions.ParseRegistry(rawPermissions)
+		config.LogAndTerminateOnError(err, "parse permissions")
+	}
+
+	policyEngine := policy.NewEngine()
+	rawPolicies, err := ConsulGetOptionalKey(consulStorage, *policies)
+	config.LogAndTerminateOnError(err, "obtain policies")
+	config.LogAndTerminateOnError(policyEngine.Load(rawPolicies), "parse policies")
+
 	//Get kpair from raw data
-	logger.Log("pub", string(certKeyData), "priv", string(privateKeyData))
 	cert, err := tls.X509KeyPair(certKeyData, privateKeyData)
 	config.LogAndTerminateOnError(err, "create cert from raw key pair data")
+	tlsConfig, err := handlers.MakeServerTLSConfig(cert, clientCAData)
+	config.LogAndTerminateOnError(err, "create TLS settings")
+
+	//Obtain TLS material for Users service client
+	var usersTLS models.UsersTLSConfig
+	usersTLS.ServerName = *usersName
+	usersTLS.CA, err = ConsulGetOptionalKey(consulStorage, *usersCA)
+	config.LogAndTerminateOnError(err, "obtain Users CA bundle")
+	usersTLS.Cert, err = ConsulGetOptionalKey(consulStorage, *usersCert)
+	config.LogAndTerminateOnError(err, "obtain Users client certificate")
+	usersTLS.Key, err = ConsulGetOptionalKey(consulStorage, *usersKey)
+	config.LogAndTerminateOnError(err, "obtain Users client private key")
 
 	//Create sessions database
 	rawConnectionStr, err := ConsulGetKey(consulStorage, *conn)
@@ -71,18 +175,46 @@ func main() {
 	dbs, err := db.Connection(logger, string(rawConnectionStr))
 
 	//Build service layers
-	var handler http.Handler
+	var (
+		handler    http.Handler
+		grpcServer *grpc.Server
+	)
 	{
 		logger.Log("Loading", "Creating Session service...")
-		svc := service.Build(logger, dbs, signSecret, certKeyData)
-		endp := endpoints.MakeServerEndpoints(svc)
+		httpClient, err := users.MakeHTTPClient(usersTLS)
+		config.LogAndTerminateOnError(err, "create Users HTTP client")
+		var usersTags []string
+		if len(*usersTag) > 0 {
+			usersTags = append(usersTags, *usersTag)
+		}
+		usersInstancer := users.NewInstancer(consulClient, *usersSvc, usersTags, logger)
+		defer usersInstancer.Stop()
+
+		usersClient, err := users.NewClient(usersInstancer, httpClient, serviceTokens, users.DefaultConfig(), logger)
+		config.LogAndTerminateOnError(err, "create Users client")
+
+		usersCache := users.NewCachingClient(usersClient, users.DefaultCacheConfig(), users.CacheMetrics{
+			Hits:    kitexpvar.NewCounter("users_cache_hits"),
+			Misses:  kitexpvar.NewCounter("users_cache_misses"),
+			HitRate: kitexpvar.NewGauge("users_cache_hit_rate"),
+		})
+
+		svc, err := service.Build(logger, dbs, signSecret, mfaSecret, usersCache,
+			service.WithSessionLimits(sessionLimits),
+			service.WithNotifier(notify.New(notifyCfg)),
+			service.WithMailer(notify.NewMailer(notifyCfg.SMTP)),
+			service.WithPermissions(registry),
+			service.WithPolicies(policyEngine),
+		)
+		config.LogAndTerminateOnError(err, "build session service")
+		endp := endpoints.MakeServerEndpoints(svc).WithRateLimits(ratelimit.NewLimiter(limitsCfg))
 		handler = handlers.MakeHTTPHandler(endp, logger)
+		grpcServer = handlers.MakeGRPCServer(endp, logger, grpc.Creds(credentials.NewTLS(tlsConfig)))
 	}
 
 	logger.Log("Loading", "Starting Session service...")
 	var g run.Group
 	{
-		tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}}
 		httpsListener, err := tls.Listen("tcp", *httpAddr, tlsConfig)
 		config.LogAndTerminateOnError(err, "create https listener")
 
@@ -94,6 +226,47 @@ func main() {
 			httpsListener.Close()
 		})
 	}
+	{
+		grpcListener, err := net.Listen("tcp", *grpcAddr)
+		config.LogAndTerminateOnError(err, "create grpc listener")
+
+		g.Add(func() error {
+			logger.Log("transport", "grpc", "addr", *grpcAddr)
+			return grpcServer.Serve(grpcListener)
+		}, func(err error) {
+			logger.Log("transport", "grpc", "err", err)
+			grpcServer.GracefulStop()
+		})
+	}
+	{
+		ctx, cancel := context.WithCancel(context.Background())
+
+		g.Add(func() error {
+			//Policies are reloaded on change. An invalid document is rejected, so that current policies stay in force
+			return WatchConsulKey(ctx, consulStorage, *policies, func(value []byte) {
+				if err := policyEngine.Load(value); err != nil {
+					logger.Log("policies", *policies, "action", "reload", "err", err)
+					return
+				}
+				logger.Log("policies", *policies, "action", "reload")
+			})
+		}, func(error) {
+			cancel()
+		})
+	}
+	if len(*debugAddr) > 0 {
+		debugListener, err := net.Listen("tcp", *debugAddr)
+		config.LogAndTerminateOnError(err, "create debug listener")
+
+		g.Add(func() error {
+			logger.Log("transport", "debug/http", "addr", *debugAddr)
+			mux := http.NewServeMux()
+			mux.Handle("/debug/vars", expvar.Handler())
+			return http.Serve(debugListener, mux)
+		}, func(err error) {
+			debugListener.Close()
+		})
+	}
 	{
 		var (
 			cancelInterrupt = make(chan struct{})
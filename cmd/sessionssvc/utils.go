@@ -1,13 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/consul/api"
 )
 
 //GetConsulClient connects to consul and returns a consul client
-func GetConsulClient(addr string) (*api.KV, error) {
+func GetConsulClient(addr string) (*api.Client, error) {
 	config := api.DefaultConfig()
 	if len(addr) > 0 {
 		config.Address = addr
@@ -16,7 +18,7 @@ func GetConsulClient(addr string) (*api.KV, error) {
 	if err != nil {
 		return nil, err
 	}
-	return consulClient.KV(), nil
+	return consulClient, nil
 }
 
 //ConsulGetKey get a raw key from consul and returns it
@@ -31,3 +33,53 @@ func ConsulGetKey(consul *api.KV, key string) ([]byte, error) {
 
 	return kvpair.Value, nil
 }
+
+//ConsulGetOptionalKey gets a raw key from consul. Missing key is not an error, nil is returned instead
+func ConsulGetOptionalKey(consul *api.KV, key string) ([]byte, error) {
+	kvpair, _, err := consul.Get(key, nil)
+	if err != nil {
+		return nil, err
+	}
+	if kvpair == nil {
+		return nil, nil
+	}
+
+	return kvpair.Value, nil
+}
+
+//WatchConsulKey calls onChange with the value of a key every time it changes, until ctx is done. Missing key is
+//reported as nil. Consul blocking queries are used, so the key is not polled
+func WatchConsulKey(ctx context.Context, consul *api.KV, key string, onChange func([]byte)) error {
+	var index uint64
+	for {
+		kvpair, meta, err := consul.Get(key, (&api.QueryOptions{WaitIndex: index}).WithContext(ctx))
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			//Consul is unreachable: keep current value and retry later
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+
+		//Index goes back when Consul state is restored, the watch starts over then
+		if meta.LastIndex < index {
+			index = 0
+			continue
+		}
+		if meta.LastIndex == index {
+			continue
+		}
+		index = meta.LastIndex
+
+		var value []byte
+		if kvpair != nil {
+			value = kvpair.Value
+		}
+		onChange(value)
+	}
+}
@@ -14,14 +14,27 @@ curl -X PUT --data-binary @/serv.crt http://$CONSUL_HOST_ADDR/v1/kv/$PUBLIC_KEY
 cat /serv.key
 curl -X PUT --data-binary @/serv.key http://$CONSUL_HOST_ADDR/v1/kv/$PRIVATE_KEY
 
+#Add CA bundle that signed Users service certificate (self-signed for local composition)
+curl -X PUT --data-binary @/serv.crt http://$CONSUL_HOST_ADDR/v1/kv/$USERS_CA
+
 #Add
 SECRET="secret"
 echo $SECRET
 curl -X PUT --data-binary "$SECRET" http://$CONSUL_HOST_ADDR/v1/kv/$SESSIONS_SECRET
 
+#Add key to sign tokens for Users service. Users service verifies them with the public key
+openssl ecparam -name prime256v1 -genkey -noout -out /service.key
+curl -X PUT --data-binary @/service.key http://$CONSUL_HOST_ADDR/v1/kv/$SESSIONS_SERVICE_KEY
+
+#Add key to encrypt MFA secrets
+MFA_KEY="mfa-secret"
+curl -X PUT --data-binary "$MFA_KEY" http://$CONSUL_HOST_ADDR/v1/kv/$SESSIONS_MFA_KEY
+
 chmod +x /bin/sessionssvc
 
 /bin/sessionssvc -consul.address $CONSUL_HOST_ADDR \
  - consul.tls.pubkey $PUBLIC_KEY \
  - consul.tls.privkey $PRIVATE_KEY \
- - consul.service.signingKey $SESSIONS_SECRET
\ No newline at end of file
+ - consul.tls.clientCA $CLIENT_CA \
+ - consul.service.signingKey $SESSIONS_SECRET \
+ - consul.service.serviceKey $SESSIONS_SERVICE_KEY
\ No newline at end of file
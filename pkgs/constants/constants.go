@@ -1,27 +1,113 @@
 package constants
 
 const (
-	LoginEndpoint         string = "/session/login"
-	LogoutEndpoint        string = "/session/logout"
-	CheckTokenEndpoint    string = "/session/check_token"
-	URIOnGetProfile       string = "https://users_users.service_1:443/user?email=%v"
-	URIOnAuthentification string = "https://users_users.service_1:443/users/check_auth"
-	TokenIssuer           string = "https://edms.com/sessions"
-	PublicKeyIsMissing    string = "Public key is missing"
-	RequestToUsersFailed  string = "Request to Users service failed"
-	MissingBody           string = "Missing content in request body"
-	MissingRefreshToken   string = "Missing refresh token"
-	ExpiredRefreshToken   string = "Refresh token expired"
-	ExpiredAccessToken    string = "Access token expired"
-	NoPermissions         string = "Client does not have required permissions"
-	MalformedBody         string = "Malformed content in request body"
-	Encoding              string = "An error occured while enconding response"
-	NonAuthorized         string = "Required authorization"
-	ClientUnknown         string = "Client is unknown"
-	FailedToCreateJWT     string = "Failed to create JWT"
-	InvalidClaimInToken   string = "Invalid claim in token"
-	InvalidTokenType      string = "Invalid token type"
-	NotImplemented        string = "Not implemented"
-	ClaimsPerAccessToken  int    = 7
-	ClaimsPerRefreshToken int    = 6
+	APIVersionPrefix               string = "/v1"
+	OpenAPIEndpoint                string = "/v1/openapi.json"
+	APITitle                       string = "Soroka EDMS Sessions"
+	APIVersion                     string = "1.0.0"
+	ProblemTypeBase                string = "https://edms.com/problems/"
+	ProblemContentType             string = "application/problem+json"
+	ProblemDomain                  string = "edms.com"
+	LoginEndpoint                  string = "/session/login"
+	LogoutEndpoint                 string = "/session/logout"
+	CheckTokenEndpoint             string = "/session/check_token"
+	CheckTokensEndpoint            string = "/session/check_tokens"
+	ForwardAuthEndpoint            string = "/session/auth"
+	AuthorizeEndpoint              string = "/session/authorize"
+	DecideEndpoint                 string = "/session/decide"
+	AuthUserHeader                 string = "X-Auth-User"
+	AuthMaskHeader                 string = "X-Auth-Mask"
+	AuthSessionHeader              string = "X-Auth-Session"
+	LoginMFAEndpoint               string = "/session/login/mfa"
+	EnrollTOTPEndpoint             string = "/session/mfa/totp/enroll"
+	ConfirmTOTPEndpoint            string = "/session/mfa/totp/confirm"
+	RecoveryCodesEndpoint          string = "/session/mfa/recovery_codes"
+	ResetMFAEndpoint               string = "/session/admin/mfa/reset"
+	UnlockLoginEndpoint            string = "/session/admin/login/unlock"
+	ClientTypeHeader               string = "X-Client-Type"
+	NativeClientType               string = "native"
+	TrustedDeviceCookie            string = "trusted_device"
+	WebAuthnRegisterBeginEndpoint  string = "/session/webauthn/register/begin"
+	WebAuthnRegisterFinishEndpoint string = "/session/webauthn/register/finish"
+	WebAuthnLoginBeginEndpoint     string = "/session/webauthn/login/begin"
+	WebAuthnLoginFinishEndpoint    string = "/session/webauthn/login/finish"
+	RefreshEndpoint                string = "/session/refresh"
+	EmailLoginEndpoint             string = "/session/login/email"
+	EmailLoginRedeemEndpoint       string = "/session/login/email/redeem"
+	MagicLinkURL                   string = "https://edms.com/login/email"
+	WebAuthnRPID                   string = "edms.com"
+	WebAuthnRPName                 string = "Soroka EDMS"
+	WebAuthnOrigin                 string = "https://edms.com"
+	UsersServiceName               string = "users"
+	UsersProfilePath               string = "/user"
+	UsersCheckAuthPath             string = "/users/check_auth"
+	TokenIssuer                    string = "https://edms.com/sessions"
+	UsersAudience                  string = "https://edms.com/users"
+	ServiceTokenSubject            string = "sessions"
+	ServiceTokenType               string = "service"
+	ChallengeTokenType             string = "mfa_challenge"
+	NewDeviceEvent                 string = "new_device"
+	MFAChallengeType               string = "MFA"
+	DeviceTokenType                string = "device"
+	TOTPIssuer                     string = "Soroka EDMS"
+	PublicKeyIsMissing             string = "Public key is missing"
+	InvalidCABundle                string = "CA bundle does not contain valid certificates"
+	InvalidClientCert              string = "Client certificate or its private key is invalid"
+	RequestToUsersFailed           string = "Request to Users service failed"
+	UsersUnavailable               string = "Users service is unavailable"
+	SigningSecretMissing           string = "Signing secret is missing"
+	MissingBody                    string = "Missing content in request body"
+	MissingRefreshToken            string = "Missing refresh token"
+	MissingAccessToken             string = "Missing access token"
+	ExpiredRefreshToken            string = "Refresh token expired"
+	ExpiredAccessToken             string = "Access token expired"
+	NoPermissions                  string = "Client does not have required permissions"
+	MalformedBody                  string = "Malformed content in request body"
+	AmbiguousToken                 string = "Token in Authorization header differs from the one in request body"
+	UnsupportedContentType         string = "Unsupported content type of request body"
+	Encoding                       string = "An error occured while enconding response"
+	NonAuthorized                  string = "Required authorization"
+	InvalidCredentials             string = "Invalid user name or password"
+	InternalError                  string = "Internal server error"
+	NotFound                       string = "Resource not found"
+	MethodNotAllowed               string = "Method is not allowed for the resource"
+	ClientUnknown                  string = "Client is unknown"
+	FailedToCreateJWT              string = "Failed to create JWT"
+	InvalidClaimInToken            string = "Invalid claim in token"
+	InvalidTokenType               string = "Invalid token type"
+	NotImplemented                 string = "Not implemented"
+	InvalidMFACode                 string = "Invalid or already used MFA code"
+	MFAAlreadyEnabled              string = "MFA is already enabled"
+	MFANotEnrolled                 string = "MFA enrollment was not started"
+	EncryptionKeyMissing           string = "Encryption key is missing"
+	DecryptionFailed               string = "Failed to decrypt secret"
+	MalformedAuthenticatorData     string = "Malformed authenticator data"
+	WebAuthnVerification           string = "WebAuthn verification failed"
+	WebAuthnChallenge              string = "WebAuthn challenge is invalid or expired"
+	CredentialNotFound             string = "Credential is not registered"
+	CredentialAlreadyExists        string = "Credential is already registered"
+	SignCountRegression            string = "Authenticator signature counter did not increase"
+	TooManyAttempts                string = "Too many failed login attempts, try again later"
+	TooManySessions                string = "Maximum number of simultaneous sessions reached"
+	InvalidEmailCode               string = "Email login code is invalid or expired"
+	MailUnavailable                string = "Email delivery is not configured"
+	NotificationRejected           string = "Notification sink rejected the event"
+	RateLimited                    string = "Rate limit exceeded"
+	AccountLocked                  string = "Login is temporarily locked after too many failed attempts"
+	BatchTooLarge                  string = "Too many tokens in a batch"
+	UnknownPermission              string = "Permission is not registered"
+	InvalidAuthorizationMode       string = "Authorization mode must be either all or any"
+	InvalidPermissionBit           string = "Permission bit must be in range from 0 to 62"
+	DuplicatePermissionBit         string = "Permission bit is registered twice"
+	MissingAdminPermission         string = "Permissions must register admin"
+	InvalidServiceKey              string = "Service key must be a PEM encoded EC private key"
+	ClaimsPerAccessToken           int    = 7
+	ClaimsPerRefreshToken          int    = 7
+	ClaimsPerServiceToken          int    = 7
+	ClaimsPerChallenge             int    = 8
+	ClaimsPerDeviceToken           int    = 8
+	RecoveryCodesCount             int    = 10
+	TrustedDeviceDays              int    = 30
+	MaxTokensPerBatch              int    = 100
+	AdminPermission                int64  = 1 << 14
 )
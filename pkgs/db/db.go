@@ -10,7 +10,12 @@ import (
 func Connection(logger log.Logger, conn string) (models.ISessionDatabase, error) {
 	if conn == "stub" {
 		var db SessionsDbStub
-		db.Tokens = make(map[string]string)
+		db.Tokens = make(map[string][]string)
+		db.MFA = make(map[string]models.MFARecord)
+		db.Credentials = make(map[string]models.WebAuthnCredential)
+		db.Challenges = make(map[string]models.WebAuthnChallenge)
+		db.Devices = make(map[string]map[string]int64)
+		db.EmailLogins = make(map[string]models.EmailLogin)
 		db.Logger = logger
 		return &db, nil
 	} else {
@@ -6,55 +6,228 @@ import (
 	"github.com/go-kit/kit/log"
 
 	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
 )
 
 type SessionsDbStub struct {
-	Tokens map[string]string
-	Mtx    sync.RWMutex
-	Logger log.Logger
+	Tokens      map[string][]string
+	MFA         map[string]models.MFARecord
+	Credentials map[string]models.WebAuthnCredential
+	Challenges  map[string]models.WebAuthnChallenge
+	Devices     map[string]map[string]int64
+	EmailLogins map[string]models.EmailLogin
+	Mtx         sync.RWMutex
+	Logger      log.Logger
 }
 
 func (db *SessionsDbStub) Save(userID string, token string) (err error) {
 	db.Mtx.Lock()
 	defer db.Mtx.Unlock()
-	db.Tokens[userID] = token
+	db.Tokens[userID] = append(db.Tokens[userID], token)
 
 	return nil
 }
 
 func (db *SessionsDbStub) Get(userID string) (token string, err error) {
-	var ok bool
-
 	db.Mtx.RLock()
 	defer db.Mtx.RUnlock()
 
-	if token, ok = db.Tokens[userID]; !ok {
+	tokens := db.Tokens[userID]
+	if len(tokens) == 0 {
 		return "", errors.ErrClientUnknown
 	}
 
-	return token, nil
+	return tokens[len(tokens)-1], nil
+}
+
+func (db *SessionsDbStub) List(userID string) (tokens []string, err error) {
+	db.Mtx.RLock()
+	defer db.Mtx.RUnlock()
+
+	return append([]string(nil), db.Tokens[userID]...), nil
 }
 
 func (db *SessionsDbStub) Exist(userID string, token string) (flag bool) {
-	var (
-		ok bool
-		t  string
-	)
+	db.Mtx.RLock()
+	defer db.Mtx.RUnlock()
+
+	for _, t := range db.Tokens[userID] {
+		if t == token {
+			return true
+		}
+	}
 
+	return false
+}
+
+func (db *SessionsDbStub) Delete(userID string, token string) (deleted bool, err error) {
 	db.Mtx.Lock()
 	defer db.Mtx.Unlock()
 
-	if t, ok = db.Tokens[userID]; !ok || token != t {
-		return false
+	tokens := db.Tokens[userID]
+	for i, t := range tokens {
+		if t == token {
+			tokens = append(tokens[:i:i], tokens[i+1:]...)
+			deleted = true
+			break
+		}
 	}
 
-	return true
+	if len(tokens) == 0 {
+		delete(db.Tokens, userID)
+	} else {
+		db.Tokens[userID] = tokens
+	}
+	return
 }
 
-func (db *SessionsDbStub) Delete(userID string, token string) (err error) {
+func (db *SessionsDbStub) SaveMFA(userID string, record models.MFARecord) (err error) {
 	db.Mtx.Lock()
 	defer db.Mtx.Unlock()
+	db.MFA[userID] = record
+
+	return nil
+}
+
+func (db *SessionsDbStub) GetMFA(userID string) (record models.MFARecord, err error) {
+	var ok bool
+
+	db.Mtx.RLock()
+	defer db.Mtx.RUnlock()
+
+	if record, ok = db.MFA[userID]; !ok {
+		return models.MFARecord{}, errors.ErrMFANotEnrolled
+	}
 
-	delete(db.Tokens, userID)
+	return record, nil
+}
+
+func (db *SessionsDbStub) DeleteMFA(userID string) (err error) {
+	db.Mtx.Lock()
+	defer db.Mtx.Unlock()
+
+	delete(db.MFA, userID)
 	return
 }
+
+func (db *SessionsDbStub) SaveCredential(credential models.WebAuthnCredential) (err error) {
+	db.Mtx.Lock()
+	defer db.Mtx.Unlock()
+	db.Credentials[credential.ID] = credential
+
+	return nil
+}
+
+func (db *SessionsDbStub) GetCredential(credentialID string) (credential models.WebAuthnCredential, err error) {
+	var ok bool
+
+	db.Mtx.RLock()
+	defer db.Mtx.RUnlock()
+
+	if credential, ok = db.Credentials[credentialID]; !ok {
+		return models.WebAuthnCredential{}, errors.ErrCredentialNotFound
+	}
+
+	return credential, nil
+}
+
+func (db *SessionsDbStub) ListCredentials(userID string) (credentials []models.WebAuthnCredential, err error) {
+	db.Mtx.RLock()
+	defer db.Mtx.RUnlock()
+
+	for _, credential := range db.Credentials {
+		if credential.UserID == userID {
+			credentials = append(credentials, credential)
+		}
+	}
+
+	return credentials, nil
+}
+
+func (db *SessionsDbStub) SaveChallenge(challenge models.WebAuthnChallenge) (err error) {
+	db.Mtx.Lock()
+	defer db.Mtx.Unlock()
+	db.Challenges[challenge.Challenge] = challenge
+
+	return nil
+}
+
+//ConsumeChallenge returns challenge and removes it, so that every challenge is used at most once
+func (db *SessionsDbStub) ConsumeChallenge(challenge string) (record models.WebAuthnChallenge, err error) {
+	var ok bool
+
+	db.Mtx.Lock()
+	defer db.Mtx.Unlock()
+
+	if record, ok = db.Challenges[challenge]; !ok {
+		return models.WebAuthnChallenge{}, errors.ErrWebAuthnChallenge
+	}
+	delete(db.Challenges, challenge)
+
+	return record, nil
+}
+
+func (db *SessionsDbStub) SaveDevice(userID string, deviceID string, seenAt int64) (err error) {
+	db.Mtx.Lock()
+	defer db.Mtx.Unlock()
+
+	if db.Devices[userID] == nil {
+		db.Devices[userID] = make(map[string]int64)
+	}
+	db.Devices[userID][deviceID] = seenAt
+
+	return nil
+}
+
+func (db *SessionsDbStub) KnownDevices(userID string) (devices map[string]int64, err error) {
+	db.Mtx.RLock()
+	defer db.Mtx.RUnlock()
+
+	devices = make(map[string]int64, len(db.Devices[userID]))
+	for id, seenAt := range db.Devices[userID] {
+		devices[id] = seenAt
+	}
+
+	return devices, nil
+}
+
+func (db *SessionsDbStub) SaveEmailLogin(record models.EmailLogin) (err error) {
+	db.Mtx.Lock()
+	defer db.Mtx.Unlock()
+	db.EmailLogins[record.Email] = record
+
+	return nil
+}
+
+func (db *SessionsDbStub) GetEmailLogin(email string) (record models.EmailLogin, err error) {
+	var ok bool
+
+	db.Mtx.RLock()
+	defer db.Mtx.RUnlock()
+
+	if record, ok = db.EmailLogins[email]; !ok {
+		return models.EmailLogin{}, errors.ErrInvalidEmailCode
+	}
+
+	return record, nil
+}
+
+func (db *SessionsDbStub) DeleteEmailLogin(email string) (err error) {
+	db.Mtx.Lock()
+	defer db.Mtx.Unlock()
+	delete(db.EmailLogins, email)
+
+	return nil
+}
+
+func (db *SessionsDbStub) DeleteExpiredEmailLogins(now int64) (err error) {
+	db.Mtx.Lock()
+	defer db.Mtx.Unlock()
+	for email, record := range db.EmailLogins {
+		if record.ExpirationDate <= now {
+			delete(db.EmailLogins, email)
+		}
+	}
+
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/Soroka-EDMS/svc/sessions/pkgs/config"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
 )
 
 func TestSave_Exists(t *testing.T) {
@@ -78,8 +79,89 @@ func TestDelete(t *testing.T) {
 	err = db.Save(testData.sub, testData.token)
 	assert.NoError(t, err)
 
-	err = db.Delete(testData.sub, testData.token)
+	deleted, err := db.Delete(testData.sub, testData.token)
 	assert.NoError(t, err)
+	assert.True(t, deleted)
 
 	assert.False(t, db.Exist(testData.sub, testData.token))
+
+	//Second delete finds nothing
+	deleted, err = db.Delete(testData.sub, testData.token)
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+}
+
+func TestMFA_SaveGetDelete(t *testing.T) {
+	db, err := Connection(config.GetLogger().Logger, "stub")
+	assert.NoError(t, err)
+
+	_, err = db.GetMFA("user@example.com")
+	assert.Error(t, err)
+
+	record := models.MFARecord{Secret: []byte("encrypted"), Confirmed: true, LastStep: 42}
+	assert.NoError(t, db.SaveMFA("user@example.com", record))
+
+	saved, err := db.GetMFA("user@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, record, saved)
+
+	assert.NoError(t, db.DeleteMFA("user@example.com"))
+	_, err = db.GetMFA("user@example.com")
+	assert.Error(t, err)
+}
+
+func TestWebAuthn_ChallengeIsConsumedOnce(t *testing.T) {
+	db, err := Connection(config.GetLogger().Logger, "stub")
+	assert.NoError(t, err)
+
+	challenge := models.WebAuthnChallenge{Challenge: "c2VjcmV0", Ceremony: "webauthn.get", ExpirationDate: 42}
+	assert.NoError(t, db.SaveChallenge(challenge))
+
+	consumed, err := db.ConsumeChallenge(challenge.Challenge)
+	assert.NoError(t, err)
+	assert.Equal(t, challenge, consumed)
+
+	_, err = db.ConsumeChallenge(challenge.Challenge)
+	assert.Error(t, err)
+}
+
+func TestWebAuthn_Credentials(t *testing.T) {
+	db, err := Connection(config.GetLogger().Logger, "stub")
+	assert.NoError(t, err)
+
+	_, err = db.GetCredential("aWQ")
+	assert.Error(t, err)
+
+	credential := models.WebAuthnCredential{ID: "aWQ", UserID: "user@example.com", PublicKey: []byte("key")}
+	assert.NoError(t, db.SaveCredential(credential))
+	assert.NoError(t, db.SaveCredential(models.WebAuthnCredential{ID: "b3RoZXI", UserID: "other@example.com"}))
+
+	saved, err := db.GetCredential(credential.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, credential, saved)
+
+	credentials, err := db.ListCredentials("user@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.WebAuthnCredential{credential}, credentials)
+}
+
+func TestList_MultipleSessions(t *testing.T) {
+	db, err := Connection(config.GetLogger().Logger, "stub")
+	assert.NoError(t, err)
+
+	assert.NoError(t, db.Save("user@example.com", "first"))
+	assert.NoError(t, db.Save("user@example.com", "second"))
+
+	tokens, err := db.List("user@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, tokens)
+
+	token, err := db.Get("user@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "second", token)
+
+	_, err = db.Delete("user@example.com", "first")
+	assert.NoError(t, err)
+	assert.False(t, db.Exist("user@example.com", "first"))
+	assert.True(t, db.Exist("user@example.com", "second"))
 }
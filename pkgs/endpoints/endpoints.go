@@ -2,24 +2,95 @@ package endpoints
 
 import (
 	"context"
+	"strings"
 
 	"github.com/go-kit/kit/endpoint"
 
 	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/ratelimit"
 )
 
 //Endpoints collects individually constructed endpoints into a single type. Each endpoint is a func that wraps corresponding function from service interface
 type SessionsEndpoints struct {
-	LoginEndpoint      endpoint.Endpoint
-	LogoutEndpoint     endpoint.Endpoint
-	CheckTokenEndpoint endpoint.Endpoint
+	LoginEndpoint                  endpoint.Endpoint
+	LogoutEndpoint                 endpoint.Endpoint
+	CheckTokenEndpoint             endpoint.Endpoint
+	CheckTokensEndpoint            endpoint.Endpoint
+	ForwardAuthEndpoint            endpoint.Endpoint
+	AuthorizeEndpoint              endpoint.Endpoint
+	DecideEndpoint                 endpoint.Endpoint
+	LoginMFAEndpoint               endpoint.Endpoint
+	EnrollTOTPEndpoint             endpoint.Endpoint
+	ConfirmTOTPEndpoint            endpoint.Endpoint
+	RecoveryCodesEndpoint          endpoint.Endpoint
+	ResetMFAEndpoint               endpoint.Endpoint
+	UnlockLoginEndpoint            endpoint.Endpoint
+	WebAuthnRegisterBeginEndpoint  endpoint.Endpoint
+	WebAuthnRegisterFinishEndpoint endpoint.Endpoint
+	WebAuthnLoginBeginEndpoint     endpoint.Endpoint
+	WebAuthnLoginFinishEndpoint    endpoint.Endpoint
+	RefreshEndpoint                endpoint.Endpoint
+	EmailLoginEndpoint             endpoint.Endpoint
+	EmailLoginRedeemEndpoint       endpoint.Endpoint
 }
 
 func MakeServerEndpoints(s models.ISessionService) SessionsEndpoints {
 	return SessionsEndpoints{
-		LoginEndpoint:      BuildLoginEndpoint(s),
-		LogoutEndpoint:     BuildLogoutEndpoint(s),
-		CheckTokenEndpoint: BuildCheckTokenEndpoint(s),
+		LoginEndpoint:                  BuildLoginEndpoint(s),
+		LogoutEndpoint:                 BuildLogoutEndpoint(s),
+		CheckTokenEndpoint:             BuildCheckTokenEndpoint(s),
+		CheckTokensEndpoint:            BuildCheckTokensEndpoint(s),
+		ForwardAuthEndpoint:            BuildCheckTokenEndpoint(s),
+		AuthorizeEndpoint:              BuildAuthorizeEndpoint(s),
+		DecideEndpoint:                 BuildDecideEndpoint(s),
+		LoginMFAEndpoint:               BuildLoginMFAEndpoint(s),
+		EnrollTOTPEndpoint:             BuildEnrollTOTPEndpoint(s),
+		ConfirmTOTPEndpoint:            BuildConfirmTOTPEndpoint(s),
+		RecoveryCodesEndpoint:          BuildRecoveryCodesEndpoint(s),
+		ResetMFAEndpoint:               BuildResetMFAEndpoint(s),
+		UnlockLoginEndpoint:            BuildUnlockLoginEndpoint(s),
+		WebAuthnRegisterBeginEndpoint:  BuildWebAuthnRegisterBeginEndpoint(s),
+		WebAuthnRegisterFinishEndpoint: BuildWebAuthnRegisterFinishEndpoint(s),
+		WebAuthnLoginBeginEndpoint:     BuildWebAuthnLoginBeginEndpoint(s),
+		WebAuthnLoginFinishEndpoint:    BuildWebAuthnLoginFinishEndpoint(s),
+		RefreshEndpoint:                BuildRefreshEndpoint(s),
+		EmailLoginEndpoint:             BuildEmailLoginEndpoint(s),
+		EmailLoginRedeemEndpoint:       BuildEmailLoginRedeemEndpoint(s),
+	}
+}
+
+//WithRateLimits wraps endpoints with rate limiting middleware. Endpoints are named in limiter configuration after their paths
+func (e SessionsEndpoints) WithRateLimits(l *ratelimit.Limiter) SessionsEndpoints {
+	e.LoginEndpoint = loginUserKey(l.Middleware("login")(e.LoginEndpoint))
+	e.LogoutEndpoint = l.Middleware("logout")(e.LogoutEndpoint)
+	e.CheckTokenEndpoint = l.Middleware("check_token")(e.CheckTokenEndpoint)
+	e.CheckTokensEndpoint = l.Middleware("check_tokens")(e.CheckTokensEndpoint)
+	e.ForwardAuthEndpoint = l.Middleware("auth")(e.ForwardAuthEndpoint)
+	e.AuthorizeEndpoint = l.Middleware("authorize")(e.AuthorizeEndpoint)
+	e.DecideEndpoint = l.Middleware("decide")(e.DecideEndpoint)
+	e.LoginMFAEndpoint = l.Middleware("login_mfa")(e.LoginMFAEndpoint)
+	e.EnrollTOTPEndpoint = l.Middleware("totp_enroll")(e.EnrollTOTPEndpoint)
+	e.ConfirmTOTPEndpoint = l.Middleware("totp_confirm")(e.ConfirmTOTPEndpoint)
+	e.RecoveryCodesEndpoint = l.Middleware("recovery_codes")(e.RecoveryCodesEndpoint)
+	e.ResetMFAEndpoint = l.Middleware("mfa_reset")(e.ResetMFAEndpoint)
+	e.UnlockLoginEndpoint = l.Middleware("login_unlock")(e.UnlockLoginEndpoint)
+	e.WebAuthnRegisterBeginEndpoint = l.Middleware("webauthn_register_begin")(e.WebAuthnRegisterBeginEndpoint)
+	e.WebAuthnRegisterFinishEndpoint = l.Middleware("webauthn_register_finish")(e.WebAuthnRegisterFinishEndpoint)
+	e.WebAuthnLoginBeginEndpoint = l.Middleware("webauthn_login_begin")(e.WebAuthnLoginBeginEndpoint)
+	e.WebAuthnLoginFinishEndpoint = l.Middleware("webauthn_login_finish")(e.WebAuthnLoginFinishEndpoint)
+	e.RefreshEndpoint = l.Middleware("refresh")(e.RefreshEndpoint)
+	e.EmailLoginEndpoint = l.Middleware("login_email")(e.EmailLoginEndpoint)
+	e.EmailLoginRedeemEndpoint = l.Middleware("login_email_redeem")(e.EmailLoginRedeemEndpoint)
+	return e
+}
+
+//loginUserKey limits login by the user name from the request, as it is not in Basic auth when sent in the body
+func loginUserKey(next endpoint.Endpoint) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if req, ok := request.(LoginRequest); ok {
+			ctx = ratelimit.WithUser(ctx, strings.ToLower(req.Req.UserName))
+		}
+		return next(ctx, request)
 	}
 }
 
@@ -27,7 +98,7 @@ func BuildLoginEndpoint(svc models.ISessionService) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(LoginRequest)
 		at, rt, e := svc.Login(ctx, req.Req)
-		return LoginResponse{AccessToken: at, RefreshToken: rt, Err: e}, nil
+		return LoginResponse{AccessToken: at, RefreshToken: rt, SessionOnly: !req.Req.RememberMe, Err: e}, nil
 	}
 }
 func BuildLogoutEndpoint(svc models.ISessionService) endpoint.Endpoint {
@@ -44,6 +115,119 @@ func BuildCheckTokenEndpoint(svc models.ISessionService) endpoint.Endpoint {
 		return CheckTokenResponse{Req: t, Err: e}, nil
 	}
 }
+func BuildAuthorizeEndpoint(svc models.ISessionService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(AuthorizeRequest)
+		t, e := svc.Authorize(ctx, req.Req)
+		return CheckTokenResponse{Req: t, Err: e}, nil
+	}
+}
+func BuildDecideEndpoint(svc models.ISessionService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(DecideRequest)
+		d, e := svc.Decide(ctx, req.Req)
+		return DecideResponse{Decision: d, Err: e}, nil
+	}
+}
+func BuildCheckTokensEndpoint(svc models.ISessionService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(CheckTokensRequest)
+		v, e := svc.CheckTokens(ctx, req.Req)
+		return CheckTokensResponse{Verdicts: v, Err: e}, nil
+	}
+}
+
+func BuildLoginMFAEndpoint(svc models.ISessionService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(LoginMFARequest)
+		at, rt, dt, e := svc.LoginMFA(ctx, req.Req)
+		return LoginResponse{AccessToken: at, RefreshToken: rt, DeviceToken: dt, Err: e}, nil
+	}
+}
+func BuildEnrollTOTPEndpoint(svc models.ISessionService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(EnrollTOTPRequest)
+		res, e := svc.EnrollTOTP(ctx, req.Req)
+		return EnrollTOTPResponse{Res: res, Err: e}, nil
+	}
+}
+func BuildConfirmTOTPEndpoint(svc models.ISessionService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(ConfirmTOTPRequest)
+		e := svc.ConfirmTOTP(ctx, req.Req)
+		return ConfirmTOTPResponse{Err: e}, nil
+	}
+}
+func BuildRecoveryCodesEndpoint(svc models.ISessionService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(RecoveryCodesRequest)
+		res, e := svc.RegenerateRecoveryCodes(ctx, req.Req)
+		return RecoveryCodesResponse{Res: res, Err: e}, nil
+	}
+}
+func BuildResetMFAEndpoint(svc models.ISessionService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(ResetMFARequest)
+		e := svc.ResetMFA(ctx, req.Req)
+		return ResetMFAResponse{Err: e}, nil
+	}
+}
+func BuildUnlockLoginEndpoint(svc models.ISessionService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(UnlockLoginRequest)
+		e := svc.UnlockLogin(ctx, req.Req)
+		return UnlockLoginResponse{Err: e}, nil
+	}
+}
+func BuildWebAuthnRegisterBeginEndpoint(svc models.ISessionService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(WebAuthnRegisterBeginRequest)
+		res, e := svc.BeginWebAuthnRegistration(ctx, req.Req)
+		return WebAuthnRegisterBeginResponse{Res: res, Err: e}, nil
+	}
+}
+func BuildWebAuthnRegisterFinishEndpoint(svc models.ISessionService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(WebAuthnRegisterFinishRequest)
+		e := svc.FinishWebAuthnRegistration(ctx, req.Req)
+		return WebAuthnRegisterFinishResponse{Err: e}, nil
+	}
+}
+func BuildWebAuthnLoginBeginEndpoint(svc models.ISessionService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(WebAuthnLoginBeginRequest)
+		res, e := svc.BeginWebAuthnLogin(ctx, req.Req)
+		return WebAuthnLoginBeginResponse{Res: res, Err: e}, nil
+	}
+}
+func BuildWebAuthnLoginFinishEndpoint(svc models.ISessionService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(WebAuthnLoginFinishRequest)
+		at, rt, e := svc.FinishWebAuthnLogin(ctx, req.Req)
+		return LoginResponse{AccessToken: at, RefreshToken: rt, Err: e}, nil
+	}
+}
+func BuildRefreshEndpoint(svc models.ISessionService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(RefreshRequest)
+		at, rt, e := svc.Refresh(ctx, req.Req)
+		return RefreshResponse{AccessToken: at, RefreshToken: rt, Cookie: req.Req.FromCookie, Err: e}, nil
+	}
+}
+func BuildEmailLoginEndpoint(svc models.ISessionService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(EmailLoginRequest)
+		e := svc.RequestEmailLogin(ctx, req.Req)
+		return EmailLoginResponse{Err: e}, nil
+	}
+}
+func BuildEmailLoginRedeemEndpoint(svc models.ISessionService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(EmailLoginRedeemRequest)
+		at, rt, e := svc.RedeemEmailLogin(ctx, req.Req)
+		return LoginResponse{AccessToken: at, RefreshToken: rt, Err: e}, nil
+	}
+}
 
 type LoginRequest struct {
 	Req models.LoginData
@@ -53,9 +237,12 @@ type LogoutRequest struct {
 	Req models.LogoutData
 }
 
+//LoginResponse carries tokens of a new session. SessionOnly asks to keep refresh token until the browser is closed
 type LoginResponse struct {
 	AccessToken  models.TokenData
 	RefreshToken models.TokenData
+	DeviceToken  models.TokenData
+	SessionOnly  bool
 	Err          error
 }
 
@@ -68,10 +255,144 @@ type CheckTokenResponse struct {
 	Err error
 }
 
+type AuthorizeRequest struct {
+	Req models.AuthorizeData
+}
+
+type DecideRequest struct {
+	Req models.DecideData
+}
+
+type DecideResponse struct {
+	Decision models.Decision
+	Err      error
+}
+
+type CheckTokensRequest struct {
+	Req models.CheckTokensData
+}
+
+type CheckTokensResponse struct {
+	Verdicts []models.TokenVerdict
+	Err      error
+}
+
 type LogoutResponse struct {
 	Err error
 }
 
-func (resp LoginResponse) Error() error      { return resp.Err }
-func (resp LogoutResponse) Error() error     { return resp.Err }
-func (resp CheckTokenResponse) Error() error { return resp.Err }
+type LoginMFARequest struct {
+	Req models.LoginMFAData
+}
+
+type EnrollTOTPRequest struct {
+	Req models.EnrollTOTPData
+}
+
+type EnrollTOTPResponse struct {
+	Res models.TOTPEnrollment
+	Err error
+}
+
+type ConfirmTOTPRequest struct {
+	Req models.ConfirmTOTPData
+}
+
+type ConfirmTOTPResponse struct {
+	Err error
+}
+
+type RecoveryCodesRequest struct {
+	Req models.RecoveryCodesData
+}
+
+type RecoveryCodesResponse struct {
+	Res models.RecoveryCodes
+	Err error
+}
+
+type ResetMFARequest struct {
+	Req models.ResetMFAData
+}
+
+type ResetMFAResponse struct {
+	Err error
+}
+
+type UnlockLoginRequest struct {
+	Req models.UnlockLoginData
+}
+
+type UnlockLoginResponse struct {
+	Err error
+}
+
+type WebAuthnRegisterBeginRequest struct {
+	Req models.WebAuthnRegistrationData
+}
+
+type WebAuthnRegisterBeginResponse struct {
+	Res models.WebAuthnCreationOptions
+	Err error
+}
+
+type WebAuthnRegisterFinishRequest struct {
+	Req models.WebAuthnRegistrationFinishData
+}
+
+type WebAuthnRegisterFinishResponse struct {
+	Err error
+}
+
+type WebAuthnLoginBeginRequest struct {
+	Req models.WebAuthnLoginData
+}
+
+type WebAuthnLoginBeginResponse struct {
+	Res models.WebAuthnRequestOptions
+	Err error
+}
+
+type WebAuthnLoginFinishRequest struct {
+	Req models.WebAuthnLoginFinishData
+}
+
+type RefreshRequest struct {
+	Req models.RefreshData
+}
+
+//RefreshResponse carries rotated tokens. Cookie tells to return refresh token the way it came: in the cookie
+type RefreshResponse struct {
+	AccessToken  models.TokenData
+	RefreshToken models.TokenData
+	Cookie       bool
+	Err          error
+}
+
+type EmailLoginRequest struct {
+	Req models.EmailLoginData
+}
+
+type EmailLoginResponse struct {
+	Err error
+}
+
+type EmailLoginRedeemRequest struct {
+	Req models.EmailLoginRedeemData
+}
+
+func (resp LoginResponse) Error() error                  { return resp.Err }
+func (resp LogoutResponse) Error() error                 { return resp.Err }
+func (resp CheckTokenResponse) Error() error             { return resp.Err }
+func (resp CheckTokensResponse) Error() error            { return resp.Err }
+func (resp DecideResponse) Error() error                 { return resp.Err }
+func (resp EnrollTOTPResponse) Error() error             { return resp.Err }
+func (resp ConfirmTOTPResponse) Error() error            { return resp.Err }
+func (resp RecoveryCodesResponse) Error() error          { return resp.Err }
+func (resp ResetMFAResponse) Error() error               { return resp.Err }
+func (resp UnlockLoginResponse) Error() error            { return resp.Err }
+func (resp WebAuthnRegisterBeginResponse) Error() error  { return resp.Err }
+func (resp WebAuthnRegisterFinishResponse) Error() error { return resp.Err }
+func (resp WebAuthnLoginBeginResponse) Error() error     { return resp.Err }
+func (resp RefreshResponse) Error() error                { return resp.Err }
+func (resp EmailLoginResponse) Error() error             { return resp.Err }
@@ -2,24 +2,69 @@ package errors
 
 import (
 	"errors"
+	"time"
 
 	"github.com/Soroka-EDMS/svc/sessions/pkgs/constants"
 )
 
 var (
-	ErrMissingBody          = errors.New(constants.MissingBody)
-	ErrMisingRefreshToken   = errors.New(constants.MissingRefreshToken)
-	ErrExpiredRefreshToken  = errors.New(constants.ExpiredRefreshToken)
-	ErrExpiredAccessToken   = errors.New(constants.ExpiredAccessToken)
-	ErrNoPermissions        = errors.New(constants.NoPermissions)
-	ErrMalformedBody        = errors.New(constants.MalformedBody)
-	ErrEncoding             = errors.New(constants.Encoding)
-	ErrNonAuthorized        = errors.New(constants.NonAuthorized)
-	ErrRequestToUsersFailed = errors.New(constants.RequestToUsersFailed)
-	ErrClientUnknown        = errors.New(constants.ClientUnknown)
-	ErrInvalidClaimInToken  = errors.New(constants.InvalidClaimInToken)
-	ErrFailedToCreateJWT    = errors.New(constants.FailedToCreateJWT)
-	ErrPublicKeyIsMissing   = errors.New(constants.PublicKeyIsMissing)
-	ErrInvalidTokenType     = errors.New(constants.InvalidTokenType)
-	ErrNotImplemented       = errors.New(constants.NotImplemented)
+	ErrMissingBody                = errors.New(constants.MissingBody)
+	ErrMisingRefreshToken         = errors.New(constants.MissingRefreshToken)
+	ErrMissingAccessToken         = errors.New(constants.MissingAccessToken)
+	ErrExpiredRefreshToken        = errors.New(constants.ExpiredRefreshToken)
+	ErrExpiredAccessToken         = errors.New(constants.ExpiredAccessToken)
+	ErrNoPermissions              = errors.New(constants.NoPermissions)
+	ErrMalformedBody              = errors.New(constants.MalformedBody)
+	ErrAmbiguousToken             = errors.New(constants.AmbiguousToken)
+	ErrUnsupportedContentType     = errors.New(constants.UnsupportedContentType)
+	ErrEncoding                   = errors.New(constants.Encoding)
+	ErrNonAuthorized              = errors.New(constants.NonAuthorized)
+	ErrNotFound                   = errors.New(constants.NotFound)
+	ErrMethodNotAllowed           = errors.New(constants.MethodNotAllowed)
+	ErrRequestToUsersFailed       = errors.New(constants.RequestToUsersFailed)
+	ErrUsersUnavailable           = errors.New(constants.UsersUnavailable)
+	ErrSigningSecretMissing       = errors.New(constants.SigningSecretMissing)
+	ErrClientUnknown              = errors.New(constants.ClientUnknown)
+	ErrInvalidClaimInToken        = errors.New(constants.InvalidClaimInToken)
+	ErrFailedToCreateJWT          = errors.New(constants.FailedToCreateJWT)
+	ErrPublicKeyIsMissing         = errors.New(constants.PublicKeyIsMissing)
+	ErrInvalidCABundle            = errors.New(constants.InvalidCABundle)
+	ErrInvalidClientCert          = errors.New(constants.InvalidClientCert)
+	ErrInvalidTokenType           = errors.New(constants.InvalidTokenType)
+	ErrNotImplemented             = errors.New(constants.NotImplemented)
+	ErrInvalidMFACode             = errors.New(constants.InvalidMFACode)
+	ErrMFAAlreadyEnabled          = errors.New(constants.MFAAlreadyEnabled)
+	ErrMFANotEnrolled             = errors.New(constants.MFANotEnrolled)
+	ErrEncryptionKeyMissing       = errors.New(constants.EncryptionKeyMissing)
+	ErrDecryptionFailed           = errors.New(constants.DecryptionFailed)
+	ErrMalformedAuthenticatorData = errors.New(constants.MalformedAuthenticatorData)
+	ErrWebAuthnVerification       = errors.New(constants.WebAuthnVerification)
+	ErrWebAuthnChallenge          = errors.New(constants.WebAuthnChallenge)
+	ErrCredentialNotFound         = errors.New(constants.CredentialNotFound)
+	ErrCredentialAlreadyExists    = errors.New(constants.CredentialAlreadyExists)
+	ErrSignCountRegression        = errors.New(constants.SignCountRegression)
+	ErrTooManyAttempts            = errors.New(constants.TooManyAttempts)
+	ErrTooManySessions            = errors.New(constants.TooManySessions)
+	ErrInvalidEmailCode           = errors.New(constants.InvalidEmailCode)
+	ErrMailUnavailable            = errors.New(constants.MailUnavailable)
+	ErrNotificationRejected       = errors.New(constants.NotificationRejected)
+	ErrRateLimited                = errors.New(constants.RateLimited)
+	ErrAccountLocked              = errors.New(constants.AccountLocked)
+	ErrBatchTooLarge              = errors.New(constants.BatchTooLarge)
+	ErrUnknownPermission          = errors.New(constants.UnknownPermission)
+	ErrInvalidAuthorizationMode   = errors.New(constants.InvalidAuthorizationMode)
+	ErrInvalidPermissionBit       = errors.New(constants.InvalidPermissionBit)
+	ErrDuplicatePermissionBit     = errors.New(constants.DuplicatePermissionBit)
+	ErrMissingAdminPermission     = errors.New(constants.MissingAdminPermission)
+	ErrInvalidServiceKey          = errors.New(constants.InvalidServiceKey)
 )
+
+//RetryError is returned when a client is throttled. RetryAfter tells how long it has to wait before the next attempt
+type RetryError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryError) Error() string {
+	return e.Err.Error()
+}
@@ -0,0 +1,800 @@
+package handlers
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/transport"
+	kitgrpc "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/constants"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/endpoints"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/pb"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/ratelimit"
+)
+
+//grpcServer serves session operations over gRPC with the same endpoints as HTTP handlers
+type grpcServer struct {
+	pb.UnimplementedSessionsServer
+
+	login       kitgrpc.Handler
+	loginMFA    kitgrpc.Handler
+	refresh     kitgrpc.Handler
+	logout      kitgrpc.Handler
+	checkToken  kitgrpc.Handler
+	checkTokens kitgrpc.Handler
+	authorize   kitgrpc.Handler
+	decide      kitgrpc.Handler
+
+	emailLogin       kitgrpc.Handler
+	emailLoginRedeem kitgrpc.Handler
+
+	enrollTOTP    kitgrpc.Handler
+	confirmTOTP   kitgrpc.Handler
+	recoveryCodes kitgrpc.Handler
+
+	webAuthnRegisterBegin  kitgrpc.Handler
+	webAuthnRegisterFinish kitgrpc.Handler
+	webAuthnLoginBegin     kitgrpc.Handler
+	webAuthnLoginFinish    kitgrpc.Handler
+
+	resetMFA    kitgrpc.Handler
+	unlockLogin kitgrpc.Handler
+}
+
+//MakeGRPCServer registers session operations on a new gRPC server. Server options, e.g. TLS credentials, are passed to
+//grpc.NewServer.
+//
+//Operations that need a token take it from the request or from "authorization: Bearer" metadata. If both are given,
+//they must hold the same token. Tokens are always returned in responses, as gRPC clients keep them themselves.
+//Forward auth is left to HTTP: it serves reverse proxies, gRPC clients call CheckToken instead
+func MakeGRPCServer(endp endpoints.SessionsEndpoints, logger log.Logger, opts ...grpc.ServerOption) *grpc.Server {
+	options := []kitgrpc.ServerOption{
+		kitgrpc.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
+		kitgrpc.ServerBefore(populateGRPCRateLimitKeys),
+		kitgrpc.ServerAfter(writeGRPCRateLimitHeaders),
+	}
+
+	s := &grpcServer{
+		login:       kitgrpc.NewServer(endp.LoginEndpoint, decodeGRPCLoginRequest, encodeGRPCLoginResponse, options...),
+		loginMFA:    kitgrpc.NewServer(endp.LoginMFAEndpoint, decodeGRPCLoginMFARequest, encodeGRPCTokens, options...),
+		refresh:     kitgrpc.NewServer(endp.RefreshEndpoint, decodeGRPCRefreshRequest, encodeGRPCRefreshResponse, options...),
+		logout:      kitgrpc.NewServer(endp.LogoutEndpoint, decodeGRPCLogoutRequest, encodeGRPCEmpty, options...),
+		checkToken:  kitgrpc.NewServer(endp.CheckTokenEndpoint, decodeGRPCCheckTokenRequest, encodeGRPCTokenClaims, options...),
+		checkTokens: kitgrpc.NewServer(endp.CheckTokensEndpoint, decodeGRPCCheckTokensRequest, encodeGRPCCheckTokensResponse, options...),
+		authorize:   kitgrpc.NewServer(endp.AuthorizeEndpoint, decodeGRPCAuthorizeRequest, encodeGRPCTokenClaims, options...),
+		decide:      kitgrpc.NewServer(endp.DecideEndpoint, decodeGRPCDecideRequest, encodeGRPCDecideResponse, options...),
+
+		emailLogin:       kitgrpc.NewServer(endp.EmailLoginEndpoint, decodeGRPCEmailLoginRequest, encodeGRPCEmpty, options...),
+		emailLoginRedeem: kitgrpc.NewServer(endp.EmailLoginRedeemEndpoint, decodeGRPCEmailLoginRedeemRequest, encodeGRPCLoginResponse, options...),
+
+		enrollTOTP:    kitgrpc.NewServer(endp.EnrollTOTPEndpoint, decodeGRPCEnrollTOTPRequest, encodeGRPCEnrollTOTPResponse, options...),
+		confirmTOTP:   kitgrpc.NewServer(endp.ConfirmTOTPEndpoint, decodeGRPCConfirmTOTPRequest, encodeGRPCEmpty, options...),
+		recoveryCodes: kitgrpc.NewServer(endp.RecoveryCodesEndpoint, decodeGRPCRecoveryCodesRequest, encodeGRPCRecoveryCodesResponse, options...),
+
+		webAuthnRegisterBegin:  kitgrpc.NewServer(endp.WebAuthnRegisterBeginEndpoint, decodeGRPCWebAuthnRegisterBeginRequest, encodeGRPCWebAuthnRegisterBeginResponse, options...),
+		webAuthnRegisterFinish: kitgrpc.NewServer(endp.WebAuthnRegisterFinishEndpoint, decodeGRPCWebAuthnRegisterFinishRequest, encodeGRPCEmpty, options...),
+		webAuthnLoginBegin:     kitgrpc.NewServer(endp.WebAuthnLoginBeginEndpoint, decodeGRPCWebAuthnLoginBeginRequest, encodeGRPCWebAuthnLoginBeginResponse, options...),
+		webAuthnLoginFinish:    kitgrpc.NewServer(endp.WebAuthnLoginFinishEndpoint, decodeGRPCWebAuthnLoginFinishRequest, encodeGRPCTokens, options...),
+
+		resetMFA:    kitgrpc.NewServer(endp.ResetMFAEndpoint, decodeGRPCResetMFARequest, encodeGRPCEmpty, options...),
+		unlockLogin: kitgrpc.NewServer(endp.UnlockLoginEndpoint, decodeGRPCUnlockLoginRequest, encodeGRPCEmpty, options...),
+	}
+
+	server := grpc.NewServer(append(opts, grpc.UnaryInterceptor(kitgrpc.Interceptor))...)
+	pb.RegisterSessionsServer(server, s)
+
+	return server
+}
+
+func (s *grpcServer) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
+	_, resp, err := s.login.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.LoginResponse), nil
+}
+
+func (s *grpcServer) LoginMFA(ctx context.Context, req *pb.LoginMFARequest) (*pb.Tokens, error) {
+	_, resp, err := s.loginMFA.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.Tokens), nil
+}
+
+func (s *grpcServer) Refresh(ctx context.Context, req *pb.RefreshRequest) (*pb.Tokens, error) {
+	_, resp, err := s.refresh.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.Tokens), nil
+}
+
+func (s *grpcServer) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.Empty, error) {
+	_, resp, err := s.logout.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.Empty), nil
+}
+
+func (s *grpcServer) CheckToken(ctx context.Context, req *pb.CheckTokenRequest) (*pb.TokenClaims, error) {
+	_, resp, err := s.checkToken.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.TokenClaims), nil
+}
+
+func (s *grpcServer) CheckTokens(ctx context.Context, req *pb.CheckTokensRequest) (*pb.CheckTokensResponse, error) {
+	_, resp, err := s.checkTokens.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.CheckTokensResponse), nil
+}
+
+func (s *grpcServer) Authorize(ctx context.Context, req *pb.AuthorizeRequest) (*pb.TokenClaims, error) {
+	_, resp, err := s.authorize.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.TokenClaims), nil
+}
+
+func (s *grpcServer) Decide(ctx context.Context, req *pb.DecideRequest) (*pb.Decision, error) {
+	_, resp, err := s.decide.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.Decision), nil
+}
+
+func (s *grpcServer) RequestEmailLogin(ctx context.Context, req *pb.EmailLoginRequest) (*pb.Empty, error) {
+	_, resp, err := s.emailLogin.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.Empty), nil
+}
+
+func (s *grpcServer) RedeemEmailLogin(ctx context.Context, req *pb.EmailLoginRedeemRequest) (*pb.LoginResponse, error) {
+	_, resp, err := s.emailLoginRedeem.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.LoginResponse), nil
+}
+
+func (s *grpcServer) EnrollTOTP(ctx context.Context, req *pb.EnrollTOTPRequest) (*pb.TOTPEnrollment, error) {
+	_, resp, err := s.enrollTOTP.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.TOTPEnrollment), nil
+}
+
+func (s *grpcServer) ConfirmTOTP(ctx context.Context, req *pb.ConfirmTOTPRequest) (*pb.Empty, error) {
+	_, resp, err := s.confirmTOTP.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.Empty), nil
+}
+
+func (s *grpcServer) RegenerateRecoveryCodes(ctx context.Context, req *pb.RecoveryCodesRequest) (*pb.RecoveryCodes, error) {
+	_, resp, err := s.recoveryCodes.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.RecoveryCodes), nil
+}
+
+func (s *grpcServer) BeginWebAuthnRegistration(ctx context.Context, req *pb.WebAuthnRegistrationRequest) (*pb.WebAuthnCreationOptions, error) {
+	_, resp, err := s.webAuthnRegisterBegin.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.WebAuthnCreationOptions), nil
+}
+
+func (s *grpcServer) FinishWebAuthnRegistration(ctx context.Context, req *pb.WebAuthnRegistrationFinishRequest) (*pb.Empty, error) {
+	_, resp, err := s.webAuthnRegisterFinish.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.Empty), nil
+}
+
+func (s *grpcServer) BeginWebAuthnLogin(ctx context.Context, req *pb.WebAuthnLoginRequest) (*pb.WebAuthnRequestOptions, error) {
+	_, resp, err := s.webAuthnLoginBegin.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.WebAuthnRequestOptions), nil
+}
+
+func (s *grpcServer) FinishWebAuthnLogin(ctx context.Context, req *pb.WebAuthnLoginFinishRequest) (*pb.Tokens, error) {
+	_, resp, err := s.webAuthnLoginFinish.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.Tokens), nil
+}
+
+func (s *grpcServer) ResetMFA(ctx context.Context, req *pb.ResetMFARequest) (*pb.Empty, error) {
+	_, resp, err := s.resetMFA.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.Empty), nil
+}
+
+func (s *grpcServer) UnlockLogin(ctx context.Context, req *pb.UnlockLoginRequest) (*pb.Empty, error) {
+	_, resp, err := s.unlockLogin.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.Empty), nil
+}
+
+func decodeGRPCLoginRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	r := request.(*pb.LoginRequest)
+	if len(r.UserName) == 0 || len(r.Password) == 0 {
+		return nil, errors.ErrNonAuthorized
+	}
+
+	return endpoints.LoginRequest{Req: models.LoginData{
+		UserName:    r.UserName,
+		Password:    r.Password,
+		RememberMe:  true,
+		ClientID:    r.ClientId,
+		DeviceToken: r.DeviceToken,
+		ClientIP:    peerIP(ctx),
+		UserAgent:   firstValue(ctx, "user-agent"),
+	}}, nil
+}
+
+func encodeGRPCLoginResponse(_ context.Context, response interface{}) (interface{}, error) {
+	e, ok := response.(endpoints.LoginResponse)
+	if !ok {
+		return nil, errors.ErrEncoding
+	}
+	if err := e.Error(); err != nil {
+		return nil, err
+	}
+
+	//MFA is enabled for user: no tokens until the challenge is passed
+	if e.AccessToken.Type == constants.MFAChallengeType {
+		return &pb.LoginResponse{Result: &pb.LoginResponse_Challenge{Challenge: &pb.MFAChallenge{
+			ChallengeToken: e.AccessToken.Token,
+			Type:           e.AccessToken.Type,
+			ExpirationDate: e.AccessToken.ExpirationDate,
+		}}}, nil
+	}
+
+	return &pb.LoginResponse{Result: &pb.LoginResponse_Tokens{Tokens: tokensOf(e)}}, nil
+}
+
+func decodeGRPCLoginMFARequest(ctx context.Context, request interface{}) (interface{}, error) {
+	r := request.(*pb.LoginMFARequest)
+	return endpoints.LoginMFARequest{Req: models.LoginMFAData{
+		ChallengeToken: r.ChallengeToken,
+		Code:           r.Code,
+		RememberDevice: r.RememberDevice,
+		ClientIP:       peerIP(ctx),
+		UserAgent:      firstValue(ctx, "user-agent"),
+	}}, nil
+}
+
+func encodeGRPCTokens(_ context.Context, response interface{}) (interface{}, error) {
+	e, ok := response.(endpoints.LoginResponse)
+	if !ok {
+		return nil, errors.ErrEncoding
+	}
+	if err := e.Error(); err != nil {
+		return nil, err
+	}
+
+	return tokensOf(e), nil
+}
+
+func decodeGRPCRefreshRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	token, err := resolveGRPCToken(ctx, request.(*pb.RefreshRequest).RefreshToken, errors.ErrMisingRefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return endpoints.RefreshRequest{Req: models.RefreshData{RefreshToken: token}}, nil
+}
+
+func encodeGRPCRefreshResponse(_ context.Context, response interface{}) (interface{}, error) {
+	e, ok := response.(endpoints.RefreshResponse)
+	if !ok {
+		return nil, errors.ErrEncoding
+	}
+	if err := e.Error(); err != nil {
+		return nil, err
+	}
+
+	return tokensOf(endpoints.LoginResponse{AccessToken: e.AccessToken, RefreshToken: e.RefreshToken}), nil
+}
+
+//decodeGRPCLogoutRequest passes refresh token to the service the way browsers do, in the cookie
+func decodeGRPCLogoutRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	token, err := resolveGRPCToken(ctx, request.(*pb.LogoutRequest).RefreshToken, errors.ErrNonAuthorized)
+	if err != nil {
+		return nil, err
+	}
+
+	return endpoints.LogoutRequest{Req: models.LogoutData{Cookie: &http.Cookie{Name: "refresh_token", Value: token}}}, nil
+}
+
+func decodeGRPCCheckTokenRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	r := request.(*pb.CheckTokenRequest)
+	token, err := resolveGRPCToken(ctx, r.AccessToken, errors.ErrMissingAccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return endpoints.CheckTokenRequest{Req: models.CheckTokenServiceInput{AccessToken: token}}, nil
+}
+
+//encodeGRPCTokenClaims encodes responses of both CheckToken and Authorize
+func encodeGRPCTokenClaims(_ context.Context, response interface{}) (interface{}, error) {
+	e, ok := response.(endpoints.CheckTokenResponse)
+	if !ok {
+		return nil, errors.ErrEncoding
+	}
+	if err := e.Error(); err != nil {
+		return nil, err
+	}
+
+	return claimsOf(e.Req), nil
+}
+
+func decodeGRPCCheckTokensRequest(_ context.Context, request interface{}) (interface{}, error) {
+	r := request.(*pb.CheckTokensRequest)
+	if len(r.AccessTokens) == 0 {
+		return nil, errors.ErrMissingAccessToken
+	}
+
+	return endpoints.CheckTokensRequest{Req: models.CheckTokensData{AccessTokens: r.AccessTokens}}, nil
+}
+
+func encodeGRPCCheckTokensResponse(_ context.Context, response interface{}) (interface{}, error) {
+	e, ok := response.(endpoints.CheckTokensResponse)
+	if !ok {
+		return nil, errors.ErrEncoding
+	}
+	if err := e.Error(); err != nil {
+		return nil, err
+	}
+
+	verdicts := make([]*pb.TokenVerdict, 0, len(e.Verdicts))
+	for _, v := range describeVerdicts(e.Verdicts) {
+		verdict := &pb.TokenVerdict{Valid: v.Valid, Reason: v.Reason, Detail: v.Detail}
+		if v.Claims != nil {
+			verdict.Claims = claimsOf(*v.Claims)
+		}
+		verdicts = append(verdicts, verdict)
+	}
+
+	return &pb.CheckTokensResponse{Verdicts: verdicts}, nil
+}
+
+func decodeGRPCAuthorizeRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	r := request.(*pb.AuthorizeRequest)
+	token, err := resolveGRPCToken(ctx, r.AccessToken, errors.ErrMissingAccessToken)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.Permissions) == 0 {
+		return nil, errors.ErrMissingBody
+	}
+
+	return endpoints.AuthorizeRequest{Req: models.AuthorizeData{AccessToken: token, Permissions: r.Permissions, Mode: r.Mode}}, nil
+}
+
+func decodeGRPCDecideRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	r := request.(*pb.DecideRequest)
+	token, err := resolveGRPCToken(ctx, r.AccessToken, errors.ErrMissingAccessToken)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.Action) == 0 {
+		return nil, errors.ErrMissingBody
+	}
+
+	return endpoints.DecideRequest{Req: models.DecideData{
+		AccessToken: token,
+		Action:      r.Action,
+		Resource:    r.Resource,
+		IP:          r.Ip,
+		Context:     r.Context,
+	}}, nil
+}
+
+func encodeGRPCDecideResponse(_ context.Context, response interface{}) (interface{}, error) {
+	e, ok := response.(endpoints.DecideResponse)
+	if !ok {
+		return nil, errors.ErrEncoding
+	}
+	if err := e.Error(); err != nil {
+		return nil, err
+	}
+
+	return &pb.Decision{Allowed: e.Decision.Allowed, Policy: e.Decision.Policy}, nil
+}
+
+//failer is implemented by responses of all endpoints
+type failer interface {
+	Error() error
+}
+
+//encodeGRPCEmpty encodes responses of operations that return nothing but an error
+func encodeGRPCEmpty(_ context.Context, response interface{}) (interface{}, error) {
+	e, ok := response.(failer)
+	if !ok {
+		return nil, errors.ErrEncoding
+	}
+	if err := e.Error(); err != nil {
+		return nil, err
+	}
+
+	return &pb.Empty{}, nil
+}
+
+func decodeGRPCEmailLoginRequest(_ context.Context, request interface{}) (interface{}, error) {
+	return endpoints.EmailLoginRequest{Req: models.EmailLoginData{Email: request.(*pb.EmailLoginRequest).Email}}, nil
+}
+
+func decodeGRPCEmailLoginRedeemRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	r := request.(*pb.EmailLoginRedeemRequest)
+	return endpoints.EmailLoginRedeemRequest{Req: models.EmailLoginRedeemData{
+		Email:       r.Email,
+		Code:        r.Code,
+		DeviceToken: r.DeviceToken,
+		ClientIP:    peerIP(ctx),
+		UserAgent:   firstValue(ctx, "user-agent"),
+	}}, nil
+}
+
+func decodeGRPCEnrollTOTPRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	token, err := resolveGRPCToken(ctx, request.(*pb.EnrollTOTPRequest).AccessToken, errors.ErrMissingAccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return endpoints.EnrollTOTPRequest{Req: models.EnrollTOTPData{AccessToken: token}}, nil
+}
+
+func encodeGRPCEnrollTOTPResponse(_ context.Context, response interface{}) (interface{}, error) {
+	e, ok := response.(endpoints.EnrollTOTPResponse)
+	if !ok {
+		return nil, errors.ErrEncoding
+	}
+	if err := e.Error(); err != nil {
+		return nil, err
+	}
+
+	return &pb.TOTPEnrollment{Secret: e.Res.Secret, Uri: e.Res.URI}, nil
+}
+
+func decodeGRPCConfirmTOTPRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	r := request.(*pb.ConfirmTOTPRequest)
+	token, err := resolveGRPCToken(ctx, r.AccessToken, errors.ErrMissingAccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return endpoints.ConfirmTOTPRequest{Req: models.ConfirmTOTPData{AccessToken: token, Code: r.Code}}, nil
+}
+
+func decodeGRPCRecoveryCodesRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	token, err := resolveGRPCToken(ctx, request.(*pb.RecoveryCodesRequest).AccessToken, errors.ErrMissingAccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return endpoints.RecoveryCodesRequest{Req: models.RecoveryCodesData{AccessToken: token}}, nil
+}
+
+func encodeGRPCRecoveryCodesResponse(_ context.Context, response interface{}) (interface{}, error) {
+	e, ok := response.(endpoints.RecoveryCodesResponse)
+	if !ok {
+		return nil, errors.ErrEncoding
+	}
+	if err := e.Error(); err != nil {
+		return nil, err
+	}
+
+	return &pb.RecoveryCodes{Codes: e.Res.Codes}, nil
+}
+
+func decodeGRPCWebAuthnRegisterBeginRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	token, err := resolveGRPCToken(ctx, request.(*pb.WebAuthnRegistrationRequest).AccessToken, errors.ErrMissingAccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return endpoints.WebAuthnRegisterBeginRequest{Req: models.WebAuthnRegistrationData{AccessToken: token}}, nil
+}
+
+func encodeGRPCWebAuthnRegisterBeginResponse(_ context.Context, response interface{}) (interface{}, error) {
+	e, ok := response.(endpoints.WebAuthnRegisterBeginResponse)
+	if !ok {
+		return nil, errors.ErrEncoding
+	}
+	if err := e.Error(); err != nil {
+		return nil, err
+	}
+
+	params := make([]*pb.WebAuthnCredentialParameter, 0, len(e.Res.PubKeyCredParams))
+	for _, p := range e.Res.PubKeyCredParams {
+		params = append(params, &pb.WebAuthnCredentialParameter{Type: p.Type, Alg: p.Alg})
+	}
+
+	return &pb.WebAuthnCreationOptions{
+		Challenge:          e.Res.Challenge,
+		Rp:                 &pb.WebAuthnEntity{Id: e.Res.RP.ID, Name: e.Res.RP.Name},
+		User:               &pb.WebAuthnUser{Id: e.Res.User.ID, Name: e.Res.User.Name, DisplayName: e.Res.User.DisplayName},
+		PubKeyCredParams:   params,
+		Timeout:            e.Res.Timeout,
+		Attestation:        e.Res.Attestation,
+		ExcludeCredentials: descriptorsOf(e.Res.ExcludeCredentials),
+		AuthenticatorSelection: &pb.WebAuthnAuthenticatorSelection{
+			ResidentKey:      e.Res.AuthenticatorSelection.ResidentKey,
+			UserVerification: e.Res.AuthenticatorSelection.UserVerification,
+		},
+	}, nil
+}
+
+func decodeGRPCWebAuthnRegisterFinishRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	r := request.(*pb.WebAuthnRegistrationFinishRequest)
+	token, err := resolveGRPCToken(ctx, r.AccessToken, errors.ErrMissingAccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return endpoints.WebAuthnRegisterFinishRequest{Req: models.WebAuthnRegistrationFinishData{
+		AccessToken: token,
+		Credential:  credentialOf(r.Credential),
+	}}, nil
+}
+
+func decodeGRPCWebAuthnLoginBeginRequest(_ context.Context, request interface{}) (interface{}, error) {
+	return endpoints.WebAuthnLoginBeginRequest{Req: models.WebAuthnLoginData{UserName: request.(*pb.WebAuthnLoginRequest).UserName}}, nil
+}
+
+func encodeGRPCWebAuthnLoginBeginResponse(_ context.Context, response interface{}) (interface{}, error) {
+	e, ok := response.(endpoints.WebAuthnLoginBeginResponse)
+	if !ok {
+		return nil, errors.ErrEncoding
+	}
+	if err := e.Error(); err != nil {
+		return nil, err
+	}
+
+	return &pb.WebAuthnRequestOptions{
+		Challenge:        e.Res.Challenge,
+		Timeout:          e.Res.Timeout,
+		RpId:             e.Res.RPID,
+		AllowCredentials: descriptorsOf(e.Res.AllowCredentials),
+		UserVerification: e.Res.UserVerification,
+	}, nil
+}
+
+func decodeGRPCWebAuthnLoginFinishRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	return endpoints.WebAuthnLoginFinishRequest{Req: models.WebAuthnLoginFinishData{
+		Credential: credentialOf(request.(*pb.WebAuthnLoginFinishRequest).Credential),
+		ClientIP:   peerIP(ctx),
+		UserAgent:  firstValue(ctx, "user-agent"),
+	}}, nil
+}
+
+func decodeGRPCResetMFARequest(ctx context.Context, request interface{}) (interface{}, error) {
+	r := request.(*pb.ResetMFARequest)
+	token, err := resolveGRPCToken(ctx, r.AccessToken, errors.ErrMissingAccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return endpoints.ResetMFARequest{Req: models.ResetMFAData{
+		AccessToken:   token,
+		UserName:      r.UserName,
+		RecoveryCodes: r.RecoveryCodes,
+		Devices:       r.Devices,
+	}}, nil
+}
+
+func decodeGRPCUnlockLoginRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	r := request.(*pb.UnlockLoginRequest)
+	token, err := resolveGRPCToken(ctx, r.AccessToken, errors.ErrMissingAccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return endpoints.UnlockLoginRequest{Req: models.UnlockLoginData{AccessToken: token, UserName: r.UserName, IP: r.Ip}}, nil
+}
+
+func tokensOf(e endpoints.LoginResponse) *pb.Tokens {
+	return &pb.Tokens{
+		AccessToken:           e.AccessToken.Token,
+		Type:                  e.AccessToken.Type,
+		ExpirationDate:        e.AccessToken.ExpirationDate,
+		RefreshToken:          e.RefreshToken.Token,
+		RefreshExpirationDate: e.RefreshToken.ExpirationDate,
+		DeviceToken:           e.DeviceToken.Token,
+		DeviceExpirationDate:  e.DeviceToken.ExpirationDate,
+	}
+}
+
+func claimsOf(c models.CheckTokenServiceOutput) *pb.TokenClaims {
+	return &pb.TokenClaims{
+		AccessToken:    c.AccessToken,
+		Sub:            c.Subject,
+		Mask:           c.Mask,
+		Permissions:    c.Permissions,
+		ExpirationDate: c.ExpirationDate,
+		SessionId:      c.SessionID,
+	}
+}
+
+//credentialOf converts credential of a request. Missing credential is left empty for the service to reject
+func credentialOf(c *pb.WebAuthnCredential) models.WebAuthnCredentialData {
+	if c == nil {
+		return models.WebAuthnCredentialData{}
+	}
+
+	return models.WebAuthnCredentialData{
+		ID:   c.Id,
+		Type: c.Type,
+		Response: models.WebAuthnResponseData{
+			ClientDataJSON:    c.GetResponse().GetClientDataJson(),
+			AttestationObject: c.GetResponse().GetAttestationObject(),
+			AuthenticatorData: c.GetResponse().GetAuthenticatorData(),
+			Signature:         c.GetResponse().GetSignature(),
+			UserHandle:        c.GetResponse().GetUserHandle(),
+		},
+	}
+}
+
+func descriptorsOf(descriptors []models.WebAuthnCredentialDescriptor) []*pb.WebAuthnCredentialDescriptor {
+	res := make([]*pb.WebAuthnCredentialDescriptor, 0, len(descriptors))
+	for _, d := range descriptors {
+		res = append(res, &pb.WebAuthnCredentialDescriptor{Type: d.Type, Id: d.ID})
+	}
+
+	return res
+}
+
+//resolveGRPCToken picks token from the request or from "authorization: Bearer" metadata by the rules of
+//resolveAccessToken. missing is returned if there is neither
+func resolveGRPCToken(ctx context.Context, fromRequest string, missing error) (string, error) {
+	const prefix = "bearer "
+
+	var fromMetadata string
+	if header := firstValue(ctx, "authorization"); len(header) >= len(prefix) && strings.EqualFold(header[:len(prefix)], prefix) {
+		fromMetadata = strings.TrimSpace(header[len(prefix):])
+	}
+
+	switch {
+	case len(fromMetadata) != 0 && len(fromRequest) != 0 && fromMetadata != fromRequest:
+		return "", errors.ErrAmbiguousToken
+	case len(fromMetadata) != 0:
+		return fromMetadata, nil
+	case len(fromRequest) != 0:
+		return fromRequest, nil
+	default:
+		return "", missing
+	}
+}
+
+//firstValue returns the first value of incoming metadata key, if any
+func firstValue(ctx context.Context, key string) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+//peerIP returns address of the peer without port
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	return host
+}
+
+//populateGRPCRateLimitKeys puts keys a call is rate limited by into context: peer IP and calling service. The service
+//is identified by verified client certificate only, as metadata is up to the caller. User name is added by login
+//endpoint itself
+func populateGRPCRateLimitKeys(ctx context.Context, _ metadata.MD) context.Context {
+	var service string
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.VerifiedChains) > 0 {
+			service = info.State.VerifiedChains[0][0].Subject.CommonName
+		}
+	}
+
+	return ratelimit.WithKeys(ctx, peerIP(ctx), "", service)
+}
+
+//writeGRPCRateLimitHeaders reports state of rate limits in response metadata named as HTTP headers
+func writeGRPCRateLimitHeaders(ctx context.Context, header *metadata.MD, _ *metadata.MD) context.Context {
+	if state, ok := ratelimit.StateFrom(ctx); ok {
+		header.Set("ratelimit-limit", strconv.Itoa(state.Limit))
+		header.Set("ratelimit-remaining", strconv.Itoa(state.Remaining))
+		header.Set("ratelimit-reset", formatSeconds(state.Reset))
+	}
+
+	return ctx
+}
+
+//grpcCodes maps HTTP statuses of the error catalog to gRPC codes
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest:            codes.InvalidArgument,
+	http.StatusUnauthorized:          codes.Unauthenticated,
+	http.StatusForbidden:             codes.PermissionDenied,
+	http.StatusNotFound:              codes.NotFound,
+	http.StatusMethodNotAllowed:      codes.Unimplemented,
+	http.StatusConflict:              codes.FailedPrecondition,
+	http.StatusRequestEntityTooLarge: codes.InvalidArgument,
+	http.StatusUnsupportedMediaType:  codes.InvalidArgument,
+	http.StatusTooManyRequests:       codes.ResourceExhausted,
+	http.StatusNotImplemented:        codes.Unimplemented,
+	http.StatusBadGateway:            codes.Unavailable,
+	http.StatusServiceUnavailable:    codes.Unavailable,
+}
+
+//grpcError reports the error as gRPC status built from the error catalog. Code of the catalog is passed as reason of
+//ErrorInfo detail, throttled clients get RetryInfo detail
+func grpcError(err error) error {
+	var retry *errors.RetryError
+	if e, ok := err.(*errors.RetryError); ok {
+		retry, err = e, e.Err
+	}
+
+	p := problemFor(err)
+	code, ok := grpcCodes[p.status]
+	if !ok {
+		code = codes.Internal
+	}
+
+	st := status.New(code, p.title)
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: p.code, Domain: constants.ProblemDomain}}
+	if retry != nil {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(retry.RetryAfter)})
+	}
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+
+	return st.Err()
+}
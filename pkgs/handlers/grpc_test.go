@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"context"
+	stderrors "errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/constants"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/endpoints"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/pb"
+)
+
+//dialGRPC serves endpoints over in-memory connection and returns a client of them
+func dialGRPC(t *testing.T, endp endpoints.SessionsEndpoints) pb.SessionsClient {
+	listener := bufconn.Listen(1 << 20)
+	server := MakeGRPCServer(endp, log.NewNopLogger())
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewSessionsClient(conn)
+}
+
+//reasonOf returns reason of ErrorInfo detail of the error
+func reasonOf(err error) string {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+
+	return ""
+}
+
+func TestGRPCCheckToken(t *testing.T) {
+	client := dialGRPC(t, endpoints.SessionsEndpoints{
+		CheckTokenEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			if request.(endpoints.CheckTokenRequest).Req.AccessToken != "valid" {
+				return endpoints.CheckTokenResponse{Err: errors.ErrExpiredAccessToken}, nil
+			}
+			return endpoints.CheckTokenResponse{Req: models.CheckTokenServiceOutput{
+				AccessToken: "valid",
+				Subject:     "admin@edms.com",
+				Mask:        32767,
+				Permissions: []string{"admin"},
+				SessionID:   "sid",
+			}}, nil
+		},
+	})
+
+	claims, err := client.CheckToken(context.Background(), &pb.CheckTokenRequest{AccessToken: "valid"})
+	assert.NoError(t, err)
+	assert.Equal(t, "admin@edms.com", claims.Sub)
+	assert.Equal(t, int64(32767), claims.Mask)
+	assert.Equal(t, []string{"admin"}, claims.Permissions)
+	assert.Equal(t, "sid", claims.SessionId)
+
+	//Token may come in metadata instead of the request
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer valid")
+	claims, err = client.CheckToken(ctx, &pb.CheckTokenRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "admin@edms.com", claims.Sub)
+
+	_, err = client.CheckToken(ctx, &pb.CheckTokenRequest{AccessToken: "another"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, "ambiguous_token", reasonOf(err))
+
+	_, err = client.CheckToken(context.Background(), &pb.CheckTokenRequest{AccessToken: "expired"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Equal(t, constants.ExpiredAccessToken, status.Convert(err).Message())
+	assert.Equal(t, "expired_access_token", reasonOf(err))
+
+	_, err = client.CheckToken(context.Background(), &pb.CheckTokenRequest{})
+	assert.Equal(t, "missing_access_token", reasonOf(err))
+}
+
+func TestGRPCCheckTokens_Reasons(t *testing.T) {
+	client := dialGRPC(t, endpoints.SessionsEndpoints{
+		CheckTokensEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			return endpoints.CheckTokensResponse{Verdicts: []models.TokenVerdict{
+				{Valid: true, Claims: &models.CheckTokenServiceOutput{Subject: "admin@edms.com"}},
+				{Err: errors.ErrExpiredAccessToken},
+				{Err: stderrors.New("signature is invalid")},
+			}}, nil
+		},
+	})
+
+	res, err := client.CheckTokens(context.Background(), &pb.CheckTokensRequest{AccessTokens: []string{"valid", "expired", "forged"}})
+	assert.NoError(t, err)
+	assert.Len(t, res.Verdicts, 3)
+	assert.Equal(t, "admin@edms.com", res.Verdicts[0].Claims.Sub)
+	assert.Empty(t, res.Verdicts[0].Reason)
+
+	//Verdicts carry problem codes, texts of errors are not exposed
+	assert.Equal(t, "expired_access_token", res.Verdicts[1].Reason)
+	assert.Equal(t, constants.ExpiredAccessToken, res.Verdicts[1].Detail)
+	assert.Equal(t, "internal_error", res.Verdicts[2].Reason)
+}
+
+func TestGRPCLogin(t *testing.T) {
+	client := dialGRPC(t, endpoints.SessionsEndpoints{
+		LoginEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			req := request.(endpoints.LoginRequest).Req
+			if req.UserName == "mfa@edms.com" {
+				return endpoints.LoginResponse{AccessToken: models.TokenData{Token: "challenge", Type: constants.MFAChallengeType, ExpirationDate: 300}}, nil
+			}
+			return endpoints.LoginResponse{
+				AccessToken:  models.TokenData{Token: "access", Type: "Bearer", ExpirationDate: 600},
+				RefreshToken: models.TokenData{Token: "refresh", ExpirationDate: 3600},
+			}, nil
+		},
+	})
+
+	resp, err := client.Login(context.Background(), &pb.LoginRequest{UserName: "admin@edms.com", Password: "a@m1n"})
+	assert.NoError(t, err)
+	assert.Equal(t, "access", resp.GetTokens().AccessToken)
+	assert.Equal(t, "refresh", resp.GetTokens().RefreshToken)
+	assert.Equal(t, int64(3600), resp.GetTokens().RefreshExpirationDate)
+	assert.Nil(t, resp.GetChallenge())
+
+	resp, err = client.Login(context.Background(), &pb.LoginRequest{UserName: "mfa@edms.com", Password: "a@m1n"})
+	assert.NoError(t, err)
+	assert.Equal(t, "challenge", resp.GetChallenge().ChallengeToken)
+	assert.Nil(t, resp.GetTokens())
+
+	_, err = client.Login(context.Background(), &pb.LoginRequest{UserName: "admin@edms.com"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestGRPCLogout(t *testing.T) {
+	var revoked string
+	client := dialGRPC(t, endpoints.SessionsEndpoints{
+		LogoutEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			revoked = request.(endpoints.LogoutRequest).Req.Cookie.Value
+			return endpoints.LogoutResponse{}, nil
+		},
+	})
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer refresh")
+	_, err := client.Logout(ctx, &pb.LogoutRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "refresh", revoked)
+}
+
+func TestGRPCEmailLogin(t *testing.T) {
+	var requested string
+	client := dialGRPC(t, endpoints.SessionsEndpoints{
+		EmailLoginEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			requested = request.(endpoints.EmailLoginRequest).Req.Email
+			return endpoints.EmailLoginResponse{}, nil
+		},
+		EmailLoginRedeemEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			req := request.(endpoints.EmailLoginRedeemRequest).Req
+			if req.Code != "123456" {
+				return endpoints.LoginResponse{Err: errors.ErrInvalidEmailCode}, nil
+			}
+			if len(req.DeviceToken) == 0 {
+				return endpoints.LoginResponse{AccessToken: models.TokenData{Token: "challenge", Type: constants.MFAChallengeType}}, nil
+			}
+			return endpoints.LoginResponse{AccessToken: models.TokenData{Token: "access", Type: "Bearer"}}, nil
+		},
+	})
+
+	_, err := client.RequestEmailLogin(context.Background(), &pb.EmailLoginRequest{Email: "admin@edms.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, "admin@edms.com", requested)
+
+	resp, err := client.RedeemEmailLogin(context.Background(), &pb.EmailLoginRedeemRequest{Email: "admin@edms.com", Code: "123456"})
+	assert.NoError(t, err)
+	assert.Equal(t, "challenge", resp.GetChallenge().ChallengeToken)
+
+	resp, err = client.RedeemEmailLogin(context.Background(), &pb.EmailLoginRedeemRequest{Email: "admin@edms.com", Code: "123456", DeviceToken: "device"})
+	assert.NoError(t, err)
+	assert.Equal(t, "access", resp.GetTokens().AccessToken)
+
+	_, err = client.RedeemEmailLogin(context.Background(), &pb.EmailLoginRedeemRequest{Email: "admin@edms.com", Code: "000000"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Equal(t, "invalid_email_code", reasonOf(err))
+}
+
+func TestGRPCTOTP(t *testing.T) {
+	client := dialGRPC(t, endpoints.SessionsEndpoints{
+		EnrollTOTPEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			assert.Equal(t, "access", request.(endpoints.EnrollTOTPRequest).Req.AccessToken)
+			return endpoints.EnrollTOTPResponse{Res: models.TOTPEnrollment{Secret: "secret", URI: "otpauth://totp/EDMS"}}, nil
+		},
+		ConfirmTOTPEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			if request.(endpoints.ConfirmTOTPRequest).Req.Code != "123456" {
+				return endpoints.ConfirmTOTPResponse{Err: errors.ErrInvalidMFACode}, nil
+			}
+			return endpoints.ConfirmTOTPResponse{}, nil
+		},
+		RecoveryCodesEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			return endpoints.RecoveryCodesResponse{Res: models.RecoveryCodes{Codes: []string{"one", "two"}}}, nil
+		},
+	})
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer access")
+	enrollment, err := client.EnrollTOTP(ctx, &pb.EnrollTOTPRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", enrollment.Secret)
+	assert.Equal(t, "otpauth://totp/EDMS", enrollment.Uri)
+
+	_, err = client.ConfirmTOTP(ctx, &pb.ConfirmTOTPRequest{Code: "123456"})
+	assert.NoError(t, err)
+	_, err = client.ConfirmTOTP(ctx, &pb.ConfirmTOTPRequest{Code: "000000"})
+	assert.Equal(t, "invalid_mfa_code", reasonOf(err))
+
+	recovery, err := client.RegenerateRecoveryCodes(ctx, &pb.RecoveryCodesRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"one", "two"}, recovery.Codes)
+
+	_, err = client.EnrollTOTP(context.Background(), &pb.EnrollTOTPRequest{})
+	assert.Equal(t, "missing_access_token", reasonOf(err))
+}
+
+func TestGRPCWebAuthn(t *testing.T) {
+	client := dialGRPC(t, endpoints.SessionsEndpoints{
+		WebAuthnRegisterBeginEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			return endpoints.WebAuthnRegisterBeginResponse{Res: models.WebAuthnCreationOptions{
+				Challenge:          "challenge",
+				RP:                 models.WebAuthnEntity{ID: "edms.com", Name: "EDMS"},
+				User:               models.WebAuthnUser{ID: "dXNlcg", Name: "admin@edms.com", DisplayName: "Admin"},
+				PubKeyCredParams:   []models.WebAuthnCredentialParameter{{Type: "public-key", Alg: -7}},
+				ExcludeCredentials: []models.WebAuthnCredentialDescriptor{{Type: "public-key", ID: "existing"}},
+			}}, nil
+		},
+		WebAuthnRegisterFinishEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			req := request.(endpoints.WebAuthnRegisterFinishRequest).Req
+			assert.Equal(t, "access", req.AccessToken)
+			assert.Equal(t, "attestation", req.Credential.Response.AttestationObject)
+			return endpoints.WebAuthnRegisterFinishResponse{}, nil
+		},
+		WebAuthnLoginBeginEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			return endpoints.WebAuthnLoginBeginResponse{Res: models.WebAuthnRequestOptions{Challenge: "challenge", RPID: "edms.com"}}, nil
+		},
+		WebAuthnLoginFinishEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			req := request.(endpoints.WebAuthnLoginFinishRequest).Req
+			if req.Credential.Response.Signature != "signature" {
+				return endpoints.LoginResponse{Err: errors.ErrWebAuthnVerification}, nil
+			}
+			return endpoints.LoginResponse{AccessToken: models.TokenData{Token: "access", Type: "Bearer"}}, nil
+		},
+	})
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer access")
+	creation, err := client.BeginWebAuthnRegistration(ctx, &pb.WebAuthnRegistrationRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "edms.com", creation.Rp.Id)
+	assert.Equal(t, "admin@edms.com", creation.User.Name)
+	assert.Equal(t, int64(-7), creation.PubKeyCredParams[0].Alg)
+	assert.Equal(t, "existing", creation.ExcludeCredentials[0].Id)
+
+	_, err = client.FinishWebAuthnRegistration(ctx, &pb.WebAuthnRegistrationFinishRequest{
+		Credential: &pb.WebAuthnCredential{Id: "new", Type: "public-key", Response: &pb.WebAuthnResponse{AttestationObject: "attestation"}},
+	})
+	assert.NoError(t, err)
+
+	request, err := client.BeginWebAuthnLogin(context.Background(), &pb.WebAuthnLoginRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "edms.com", request.RpId)
+
+	tokens, err := client.FinishWebAuthnLogin(context.Background(), &pb.WebAuthnLoginFinishRequest{
+		Credential: &pb.WebAuthnCredential{Id: "new", Response: &pb.WebAuthnResponse{Signature: "signature"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "access", tokens.AccessToken)
+
+	//Missing credential reaches the service empty and is rejected there
+	_, err = client.FinishWebAuthnLogin(context.Background(), &pb.WebAuthnLoginFinishRequest{})
+	assert.Equal(t, "webauthn_verification_failed", reasonOf(err))
+}
+
+func TestGRPCAdmin(t *testing.T) {
+	var reset models.ResetMFAData
+	var unlocked models.UnlockLoginData
+	client := dialGRPC(t, endpoints.SessionsEndpoints{
+		ResetMFAEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			reset = request.(endpoints.ResetMFARequest).Req
+			return endpoints.ResetMFAResponse{}, nil
+		},
+		UnlockLoginEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			unlocked = request.(endpoints.UnlockLoginRequest).Req
+			if unlocked.AccessToken != "admin" {
+				return endpoints.UnlockLoginResponse{Err: errors.ErrNoPermissions}, nil
+			}
+			return endpoints.UnlockLoginResponse{}, nil
+		},
+	})
+
+	_, err := client.ResetMFA(context.Background(), &pb.ResetMFARequest{AccessToken: "admin", UserName: "user@edms.com", Devices: true})
+	assert.NoError(t, err)
+	assert.Equal(t, models.ResetMFAData{AccessToken: "admin", UserName: "user@edms.com", Devices: true}, reset)
+
+	_, err = client.UnlockLogin(context.Background(), &pb.UnlockLoginRequest{AccessToken: "admin", Ip: "192.0.2.10"})
+	assert.NoError(t, err)
+	assert.Equal(t, "192.0.2.10", unlocked.IP)
+
+	_, err = client.UnlockLogin(context.Background(), &pb.UnlockLoginRequest{AccessToken: "user", UserName: "user@edms.com"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Equal(t, "no_permissions", reasonOf(err))
+}
+
+func TestGRPCError(t *testing.T) {
+	err := grpcError(&errors.RetryError{Err: errors.ErrRateLimited, RetryAfter: 3 * time.Second})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, "rate_limited", reasonOf(err))
+
+	var retry *errdetails.RetryInfo
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			retry = info
+		}
+	}
+	if assert.NotNil(t, retry) {
+		assert.Equal(t, 3*time.Second, retry.RetryDelay.AsDuration())
+	}
+
+	//Errors out of the catalog do not leak their text
+	err = grpcError(stderrors.New("pq: connection refused"))
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, constants.InternalError, status.Convert(err).Message())
+	assert.Equal(t, "internal_error", reasonOf(err))
+}
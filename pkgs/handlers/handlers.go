@@ -3,14 +3,15 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"io"
+	"mime"
 	"net/http"
+	"strconv"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/transport"
 	httptransport "github.com/go-kit/kit/transport/http"
-	"github.com/gorilla/mux"
 
-	"github.com/Soroka-EDMS/svc/sessions/pkgs/config"
 	"github.com/Soroka-EDMS/svc/sessions/pkgs/constants"
 	"github.com/Soroka-EDMS/svc/sessions/pkgs/endpoints"
 	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
@@ -19,39 +20,205 @@ import (
 
 //MakeHTTPHandler wraps all service handlers in one HTTP handler
 func MakeHTTPHandler(endp endpoints.SessionsEndpoints, logger log.Logger) http.Handler {
-	r := mux.NewRouter()
-
 	options := []httptransport.ServerOption{
 		httptransport.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
 		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(httptransport.PopulateRequestContext, populateRateLimitKeys, populateClientType),
+		httptransport.ServerAfter(writeRateLimitHeaders),
 	}
 
-	// GET     /session/login                      generates a pair of tokens
-	// GET     /session/logout                     restoke refresh token from cookie
-	// PUT     /session/check_token                checks whether an access token is valid (contain valid priveledges and not expired). Regenerates token if expired
-
-	r.Methods("GET").Path(constants.LoginEndpoint).Handler(httptransport.NewServer(
-		endp.LoginEndpoint,
-		DecodeLoginRequest,
-		encodeLoginResponse,
-		options...,
-	))
-
-	r.Methods("GET").Path(constants.LogoutEndpoint).Handler(httptransport.NewServer(
-		endp.LogoutEndpoint,
-		DecodeLogoutRequest,
-		encodeLogoutResponse,
-		options...,
-	))
-
-	r.Methods("POST").Path(constants.CheckTokenEndpoint).Handler(httptransport.NewServer(
-		endp.CheckTokenEndpoint,
-		DecodeCheckTokenRequest,
-		encodeCheckTokenResponse,
-		options...,
-	))
+	//Routes are served under /v1. Unversioned paths of the first API, including GET login with Basic auth and GET
+	//logout, keep working as deprecated aliases.
+	//
+	//Endpoints that need an access token take it from "Authorization: Bearer" header or from "access_token" field of
+	//JSON body. If both are given, they must hold the same token. Models of requests and responses are described by
+	//OpenAPI document at /v1/openapi.json
+	loginResponses := []interface{}{models.TokenData{}, models.MFAChallenge{}, models.NativeTokens{}}
+	refreshResponses := []interface{}{models.TokenData{}, models.NativeTokens{}}
+
+	routes := []route{
+		{
+			legacyMethod: "GET",
+			path:         constants.LoginEndpoint,
+			summary:      "generates a pair of tokens for credentials in Basic auth, or an MFA challenge if the user has MFA enabled",
+			handler:      httptransport.NewServer(endp.LoginEndpoint, DecodeLoginRequest, encodeLoginResponse, options...),
+			responses:    loginResponses,
+		},
+		{
+			method:       "POST",
+			legacyMethod: "POST",
+			path:         constants.LoginEndpoint,
+			summary:      "generates a pair of tokens for credentials in JSON or form body, or an MFA challenge if the user has MFA enabled",
+			handler:      httptransport.NewServer(endp.LoginEndpoint, DecodeLoginPostRequest, encodeLoginResponse, options...),
+			request:      models.LoginData{},
+			responses:    loginResponses,
+		},
+		{
+			method:       "POST",
+			legacyMethod: "GET",
+			path:         constants.LogoutEndpoint,
+			summary:      "revokes refresh token from cookie or Authorization header",
+			handler:      httptransport.NewServer(endp.LogoutEndpoint, DecodeLogoutRequest, encodeLogoutResponse, options...),
+		},
+		{
+			method:       "POST",
+			legacyMethod: "POST",
+			path:         constants.RefreshEndpoint,
+			summary:      "exchanges refresh token from Authorization header, body or cookie for a new pair of tokens",
+			handler:      httptransport.NewServer(endp.RefreshEndpoint, DecodeRefreshRequest, encodeRefreshResponse, options...),
+			request:      models.RefreshData{},
+			responses:    refreshResponses,
+		},
+		{
+			method:       "POST",
+			legacyMethod: "POST",
+			path:         constants.CheckTokenEndpoint,
+			summary:      "checks whether an access token is valid and describes it. Expired tokens are refused: new ones are issued by refresh only",
+			handler:      httptransport.NewServer(endp.CheckTokenEndpoint, DecodeCheckTokenRequest, encodeCheckTokenResponse, options...),
+			request:      models.CheckTokenAnotherServiceInput{},
+			responses:    []interface{}{models.CheckTokenServiceOutput{}},
+		},
+		{
+			method:       "POST",
+			legacyMethod: "POST",
+			path:         constants.CheckTokensEndpoint,
+			summary:      "validates a batch of access tokens and returns a verdict per token",
+			handler:      httptransport.NewServer(endp.CheckTokensEndpoint, DecodeCheckTokensRequest, encodeCheckTokensResponse, options...),
+			request:      models.CheckTokensData{},
+			responses:    []interface{}{models.TokenVerdicts{}},
+		},
+		{
+			method:       "GET",
+			legacyMethod: "GET",
+			path:         constants.ForwardAuthEndpoint,
+			summary:      "forward authentication for reverse proxies: 200 with X-Auth-* headers if access token of Authorization header is valid, 401 otherwise",
+			handler:      httptransport.NewServer(endp.ForwardAuthEndpoint, DecodeForwardAuthRequest, encodeForwardAuthResponse, options...),
+		},
+		{
+			method:       "POST",
+			legacyMethod: "POST",
+			path:         constants.AuthorizeEndpoint,
+			summary:      "checks that an access token grants all or any of the named permissions. Responds 403 if it does not",
+			handler:      httptransport.NewServer(endp.AuthorizeEndpoint, DecodeAuthorizeRequest, encodeCheckTokenResponse, options...),
+			request:      models.AuthorizeData{},
+			responses:    []interface{}{models.CheckTokenServiceOutput{}},
+		},
+		{
+			method:       "POST",
+			legacyMethod: "POST",
+			path:         constants.DecideEndpoint,
+			summary:      "evaluates attribute based policies for an action of the user of an access token",
+			handler:      httptransport.NewServer(endp.DecideEndpoint, DecodeDecideRequest, encodeDecideResponse, options...),
+			request:      models.DecideData{},
+			responses:    []interface{}{models.Decision{}},
+		},
+		{
+			method:       "POST",
+			legacyMethod: "POST",
+			path:         constants.LoginMFAEndpoint,
+			summary:      "exchanges MFA challenge and TOTP or recovery code for a pair of tokens, remembers the device if asked",
+			handler:      httptransport.NewServer(endp.LoginMFAEndpoint, DecodeLoginMFARequest, encodeLoginResponse, options...),
+			request:      models.LoginMFAData{},
+			responses:    loginResponses,
+		},
+		{
+			method:       "POST",
+			legacyMethod: "POST",
+			path:         constants.EmailLoginEndpoint,
+			summary:      "sends one-time code and magic link to an email. Unknown emails get the same response, but no mail",
+			handler:      httptransport.NewServer(endp.EmailLoginEndpoint, DecodeEmailLoginRequest, encodeEmailLoginResponse, options...),
+			request:      models.EmailLoginData{},
+			status:       http.StatusAccepted,
+		},
+		{
+			method:       "POST",
+			legacyMethod: "POST",
+			path:         constants.EmailLoginRedeemEndpoint,
+			summary:      "exchanges one-time code or magic link token for a pair of tokens, or an MFA challenge if the user has MFA enabled",
+			handler:      httptransport.NewServer(endp.EmailLoginRedeemEndpoint, DecodeEmailLoginRedeemRequest, encodeLoginResponse, options...),
+			request:      models.EmailLoginRedeemData{},
+			responses:    loginResponses,
+		},
+		{
+			method:       "POST",
+			legacyMethod: "POST",
+			path:         constants.EnrollTOTPEndpoint,
+			summary:      "generates TOTP secret for the user of an access token",
+			handler:      httptransport.NewServer(endp.EnrollTOTPEndpoint, DecodeEnrollTOTPRequest, encodeEnrollTOTPResponse, options...),
+			request:      models.EnrollTOTPData{},
+			responses:    []interface{}{models.TOTPEnrollment{}},
+		},
+		{
+			method:       "POST",
+			legacyMethod: "POST",
+			path:         constants.ConfirmTOTPEndpoint,
+			summary:      "enables MFA after checking the first TOTP code",
+			handler:      httptransport.NewServer(endp.ConfirmTOTPEndpoint, DecodeConfirmTOTPRequest, encodeConfirmTOTPResponse, options...),
+			request:      models.ConfirmTOTPData{},
+		},
+		{
+			method:       "POST",
+			legacyMethod: "POST",
+			path:         constants.RecoveryCodesEndpoint,
+			summary:      "replaces recovery codes of the user of an access token with new ones",
+			handler:      httptransport.NewServer(endp.RecoveryCodesEndpoint, DecodeRecoveryCodesRequest, encodeRecoveryCodesResponse, options...),
+			request:      models.RecoveryCodesData{},
+			responses:    []interface{}{models.RecoveryCodes{}},
+		},
+		{
+			method:       "POST",
+			legacyMethod: "POST",
+			path:         constants.ResetMFAEndpoint,
+			summary:      "resets recovery codes and remembered devices of a user (admins only)",
+			handler:      httptransport.NewServer(endp.ResetMFAEndpoint, DecodeResetMFARequest, encodeResetMFAResponse, options...),
+			request:      models.ResetMFAData{},
+		},
+		{
+			method:       "POST",
+			legacyMethod: "POST",
+			path:         constants.UnlockLoginEndpoint,
+			summary:      "lifts login throttling of a user name and/or a client IP (admins only)",
+			handler:      httptransport.NewServer(endp.UnlockLoginEndpoint, DecodeUnlockLoginRequest, encodeUnlockLoginResponse, options...),
+			request:      models.UnlockLoginData{},
+		},
+		{
+			method:       "POST",
+			legacyMethod: "POST",
+			path:         constants.WebAuthnRegisterBeginEndpoint,
+			summary:      "returns options to create a passkey for the user of an access token",
+			handler:      httptransport.NewServer(endp.WebAuthnRegisterBeginEndpoint, DecodeWebAuthnRegisterBeginRequest, encodeWebAuthnRegisterBeginResponse, options...),
+			request:      models.WebAuthnRegistrationData{},
+			responses:    []interface{}{models.WebAuthnCreationOptions{}},
+		},
+		{
+			method:       "POST",
+			legacyMethod: "POST",
+			path:         constants.WebAuthnRegisterFinishEndpoint,
+			summary:      "verifies and stores a new passkey",
+			handler:      httptransport.NewServer(endp.WebAuthnRegisterFinishEndpoint, DecodeWebAuthnRegisterFinishRequest, encodeWebAuthnRegisterFinishResponse, options...),
+			request:      models.WebAuthnRegistrationFinishData{},
+		},
+		{
+			method:       "POST",
+			legacyMethod: "POST",
+			path:         constants.WebAuthnLoginBeginEndpoint,
+			summary:      "returns options to log in with a passkey",
+			handler:      httptransport.NewServer(endp.WebAuthnLoginBeginEndpoint, DecodeWebAuthnLoginBeginRequest, encodeWebAuthnLoginBeginResponse, options...),
+			request:      models.WebAuthnLoginData{},
+			responses:    []interface{}{models.WebAuthnRequestOptions{}},
+		},
+		{
+			method:       "POST",
+			legacyMethod: "POST",
+			path:         constants.WebAuthnLoginFinishEndpoint,
+			summary:      "verifies passkey assertion and generates a pair of tokens",
+			handler:      httptransport.NewServer(endp.WebAuthnLoginFinishEndpoint, DecodeWebAuthnLoginFinishRequest, encodeLoginResponse, options...),
+			request:      models.WebAuthnLoginFinishData{},
+			responses:    loginResponses,
+		},
+	}
 
-	return r
+	return newRouter(routes)
 }
 
 func DecodeLoginRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
@@ -64,8 +231,66 @@ func DecodeLoginRequest(_ context.Context, r *http.Request) (request interface{}
 
 	req.UserName = user
 	req.Password = pass
+	//Legacy clients always get a persistent session
+	req.RememberMe = true
+
+	return endpoints.LoginRequest{Req: withLoginMetadata(req, r)}, nil
+}
+
+//DecodeLoginPostRequest reads credentials from JSON or url-encoded form body. Credentials in a body do not end up in
+//logs and caches of proxies as query strings of GET requests may
+func DecodeLoginPostRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req models.LoginData
+
+	if r.Body == nil {
+		return nil, errors.ErrMissingBody
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, errors.ErrMalformedBody
+		}
+	case "application/x-www-form-urlencoded":
+		if err := r.ParseForm(); err != nil {
+			return nil, errors.ErrMalformedBody
+		}
+		req.UserName = r.PostForm.Get("user_name")
+		req.Password = r.PostForm.Get("password")
+		req.ClientID = r.PostForm.Get("client_id")
+		//Checkbox sends "on" when checked
+		switch value := r.PostForm.Get("remember_me"); value {
+		case "":
+		case "on":
+			req.RememberMe = true
+		default:
+			if req.RememberMe, err = strconv.ParseBool(value); err != nil {
+				return nil, errors.ErrMalformedBody
+			}
+		}
+	default:
+		return nil, errors.ErrUnsupportedContentType
+	}
 
-	return endpoints.LoginRequest{Req: req}, nil
+	if len(req.UserName) == 0 || len(req.Password) == 0 {
+		return nil, errors.ErrNonAuthorized
+	}
+
+	return endpoints.LoginRequest{Req: withLoginMetadata(req, r)}, nil
+}
+
+//withLoginMetadata adds client address, user agent and remembered device to login data
+func withLoginMetadata(req models.LoginData, r *http.Request) models.LoginData {
+	req.ClientIP = ClientIP(r)
+	req.UserAgent = r.UserAgent()
+
+	//Remembered device lets user skip MFA
+	if cookie, err := r.Cookie(constants.TrustedDeviceCookie); err == nil {
+		req.DeviceToken = cookie.Value
+	}
+
+	return req
 }
 
 func encodeLoginResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
@@ -79,8 +304,97 @@ func encodeLoginResponse(ctx context.Context, w http.ResponseWriter, response in
 		return err
 	}
 
-	AddCookie(w, e.RefreshToken.Token, e.RefreshToken.ExpirationDate)
+	//MFA is enabled for user: no tokens until the challenge is passed
+	if e.AccessToken.Type == constants.MFAChallengeType {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		return json.NewEncoder(w).Encode(models.MFAChallenge{
+			ChallengeToken: e.AccessToken.Token,
+			Type:           e.AccessToken.Type,
+			ExpirationDate: e.AccessToken.ExpirationDate,
+		})
+	}
+
+	//Native clients cannot use cookies of the browser, so they keep tokens themselves
+	if isNativeClient(ctx) {
+		return encodeNativeTokens(w, e)
+	}
+
+	if e.SessionOnly {
+		AddSessionCookie(w, e.RefreshToken.Token)
+	} else {
+		AddCookie(w, e.RefreshToken.Token, e.RefreshToken.ExpirationDate)
+	}
+	if len(e.DeviceToken.Token) > 0 {
+		AddDeviceCookie(w, e.DeviceToken.Token, e.DeviceToken.ExpirationDate)
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(e.AccessToken)
+}
+
+func encodeNativeTokens(w http.ResponseWriter, e endpoints.LoginResponse) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	return json.NewEncoder(w).Encode(models.NativeTokens{
+		Token:                 e.AccessToken.Token,
+		Type:                  e.AccessToken.Type,
+		ExpirationDate:        e.AccessToken.ExpirationDate,
+		RefreshToken:          e.RefreshToken.Token,
+		RefreshExpirationDate: e.RefreshToken.ExpirationDate,
+		DeviceToken:           e.DeviceToken.Token,
+		DeviceExpirationDate:  e.DeviceToken.ExpirationDate,
+	})
+}
 
+//DecodeRefreshRequest reads refresh token from Authorization header or JSON body, which native clients use, or from
+//the cookie of a browser. If both header and body are given they must hold the same token. The cookie is used only if
+//neither is given
+func DecodeRefreshRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req models.RefreshData
+
+	if r.Body != nil {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			return nil, errors.ErrMalformedBody
+		}
+	}
+
+	if header := bearerToken(r); len(header) != 0 {
+		if len(req.RefreshToken) != 0 && req.RefreshToken != header {
+			return nil, errors.ErrAmbiguousToken
+		}
+		req.RefreshToken = header
+	}
+
+	if len(req.RefreshToken) == 0 {
+		cookie, err := r.Cookie("refresh_token")
+		if err != nil || len(cookie.Value) == 0 {
+			return nil, errors.ErrMisingRefreshToken
+		}
+		req.RefreshToken = cookie.Value
+		req.FromCookie = true
+	}
+
+	return endpoints.RefreshRequest{Req: req}, nil
+}
+
+//encodeRefreshResponse returns rotated refresh token the way the old one came: in the cookie or in the body
+func encodeRefreshResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	e, ok := response.(endpoints.RefreshResponse)
+	if !ok {
+		return errors.ErrEncoding
+	}
+
+	err := e.Error()
+	if err != nil {
+		return err
+	}
+
+	tokens := endpoints.LoginResponse{AccessToken: e.AccessToken, RefreshToken: e.RefreshToken}
+	if !e.Cookie {
+		return encodeNativeTokens(w, tokens)
+	}
+
+	AddCookie(w, e.RefreshToken.Token, e.RefreshToken.ExpirationDate)
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	return json.NewEncoder(w).Encode(e.AccessToken)
 }
@@ -89,6 +403,11 @@ func DecodeLogoutRequest(_ context.Context, r *http.Request) (request interface{
 	var req models.LogoutData
 	req.Cookie, err = r.Cookie("refresh_token")
 
+	//Native clients send refresh token in Authorization header
+	if token := bearerToken(r); err != nil && len(token) != 0 {
+		req.Cookie, err = &http.Cookie{Name: "refresh_token", Value: token}, nil
+	}
+
 	if err != nil {
 		return nil, errors.ErrNonAuthorized
 	}
@@ -105,28 +424,78 @@ func DecodeCheckTokenRequest(_ context.Context, r *http.Request) (request interf
 	var reqAnotherService models.CheckTokenAnotherServiceInput
 	var reqCurrentService models.CheckTokenServiceInput
 
-	//Parse request body and get access token
+	//Get access token from Authorization header or request body
+	if err := decodeAuthenticatedBody(r, &reqAnotherService); err != nil {
+		return nil, err
+	}
+	if reqCurrentService.AccessToken, err = resolveAccessToken(r, reqAnotherService.AccessToken); err != nil {
+		return nil, err
+	}
+
+	return endpoints.CheckTokenRequest{Req: reqCurrentService}, nil
+}
+
+func encodeCheckTokenResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	e, ok := response.(endpoints.CheckTokenResponse)
+	if !ok {
+		return errors.ErrEncoding
+	}
+
+	err := e.Error()
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(e.Req)
+}
+
+func DecodeCheckTokensRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req models.CheckTokensData
+
 	if r.Body == nil {
 		return nil, errors.ErrMissingBody
 	}
-	if err := json.NewDecoder(r.Body).Decode(&reqAnotherService); err != nil {
-		return nil, err
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.ErrMalformedBody
+	}
+	if len(req.AccessTokens) == 0 {
+		return nil, errors.ErrMissingAccessToken
 	}
-	reqCurrentService.AccessToken = reqAnotherService.AccessToken
 
-	//Parse cookie and get refresh token
-	cookie, err := r.Cookie("refresh_token")
+	return endpoints.CheckTokensRequest{Req: req}, nil
+}
+
+func encodeCheckTokensResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	e, ok := response.(endpoints.CheckTokensResponse)
+	if !ok {
+		return errors.ErrEncoding
+	}
+
+	err := e.Error()
 	if err != nil {
-		return nil, errors.ErrMisingRefreshToken
+		return err
 	}
-	reqCurrentService.RefreshToken = cookie.Value
 
-	config.GetLogger().Logger.Log("a", reqCurrentService.AccessToken, "r", reqCurrentService.RefreshToken)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(models.TokenVerdicts{Verdicts: describeVerdicts(e.Verdicts)})
+}
 
-	return endpoints.CheckTokenRequest{Req: reqCurrentService}, nil
+//DecodeForwardAuthRequest reads access token of a request a reverse proxy, e.g. nginx auth_request or Traefik
+//ForwardAuth, asks about. The token comes in Authorization header only: the service sets no access token cookie, so
+//browser clients send the header themselves. Cookies, the refresh one included, are ignored, so that the token is only validated
+func DecodeForwardAuthRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	token := bearerToken(r)
+	if len(token) == 0 {
+		return nil, errors.ErrMissingAccessToken
+	}
+
+	return endpoints.CheckTokenRequest{Req: models.CheckTokenServiceInput{AccessToken: token}}, nil
 }
 
-func encodeCheckTokenResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+//encodeForwardAuthResponse passes identity of the user to the proxy in headers, which the proxy copies to the request
+//of the protected application
+func encodeForwardAuthResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
 	e, ok := response.(endpoints.CheckTokenResponse)
 	if !ok {
 		return errors.ErrEncoding
@@ -137,41 +506,337 @@ func encodeCheckTokenResponse(ctx context.Context, w http.ResponseWriter, respon
 		return err
 	}
 
+	w.Header().Set(constants.AuthUserHeader, e.Req.Subject)
+	w.Header().Set(constants.AuthMaskHeader, strconv.FormatInt(e.Req.Mask, 10))
+	if len(e.Req.SessionID) != 0 {
+		w.Header().Set(constants.AuthSessionHeader, e.Req.SessionID)
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func DecodeAuthorizeRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req models.AuthorizeData
+
+	if err := decodeAuthenticatedBody(r, &req); err != nil {
+		return nil, err
+	}
+	if req.AccessToken, err = resolveAccessToken(r, req.AccessToken); err != nil {
+		return nil, err
+	}
+	if len(req.Permissions) == 0 {
+		return nil, errors.ErrMissingBody
+	}
+
+	return endpoints.AuthorizeRequest{Req: req}, nil
+}
+
+func DecodeDecideRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req models.DecideData
+
+	if err := decodeAuthenticatedBody(r, &req); err != nil {
+		return nil, err
+	}
+	if req.AccessToken, err = resolveAccessToken(r, req.AccessToken); err != nil {
+		return nil, err
+	}
+	if len(req.Action) == 0 {
+		return nil, errors.ErrMissingBody
+	}
+
+	return endpoints.DecideRequest{Req: req}, nil
+}
+
+//encodeDecideResponse returns the decision with 200 whether the action is allowed or not, as the request itself succeeded
+func encodeDecideResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	e, ok := response.(endpoints.DecideResponse)
+	if !ok {
+		return errors.ErrEncoding
+	}
+
+	err := e.Error()
+	if err != nil {
+		return err
+	}
+
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	return json.NewEncoder(w).Encode(e.Req)
+	return json.NewEncoder(w).Encode(e.Decision)
+}
+
+func DecodeLoginMFARequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req models.LoginMFAData
+
+	if r.Body == nil {
+		return nil, errors.ErrMissingBody
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.ErrMalformedBody
+	}
+	req.ClientIP = ClientIP(r)
+	req.UserAgent = r.UserAgent()
+
+	return endpoints.LoginMFARequest{Req: req}, nil
+}
+
+func DecodeEmailLoginRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req models.EmailLoginData
+
+	if r.Body == nil {
+		return nil, errors.ErrMissingBody
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.ErrMalformedBody
+	}
+
+	return endpoints.EmailLoginRequest{Req: req}, nil
+}
+
+//encodeEmailLoginResponse answers the same whether the email is known or not
+func encodeEmailLoginResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	e, ok := response.(endpoints.EmailLoginResponse)
+	if !ok {
+		return errors.ErrEncoding
+	}
+
+	err := e.Error()
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	return nil
+}
+
+func DecodeEmailLoginRedeemRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req models.EmailLoginRedeemData
+
+	if r.Body == nil {
+		return nil, errors.ErrMissingBody
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.ErrMalformedBody
+	}
+	if cookie, err := r.Cookie(constants.TrustedDeviceCookie); err == nil {
+		req.DeviceToken = cookie.Value
+	}
+	req.ClientIP = ClientIP(r)
+	req.UserAgent = r.UserAgent()
+
+	return endpoints.EmailLoginRedeemRequest{Req: req}, nil
 }
 
-func encodeError(_ context.Context, err error, w http.ResponseWriter) {
-	errCode, errReason := codeFrom(err)
+func DecodeEnrollTOTPRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req models.EnrollTOTPData
+
+	if err := decodeAuthenticatedBody(r, &req); err != nil {
+		return nil, err
+	}
+	if req.AccessToken, err = resolveAccessToken(r, req.AccessToken); err != nil {
+		return nil, err
+	}
+
+	return endpoints.EnrollTOTPRequest{Req: req}, nil
+}
+
+func encodeEnrollTOTPResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	e, ok := response.(endpoints.EnrollTOTPResponse)
+	if !ok {
+		return errors.ErrEncoding
+	}
+
+	err := e.Error()
+	if err != nil {
+		return err
+	}
+
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.WriteHeader(errCode)
+	return json.NewEncoder(w).Encode(e.Res)
+}
 
-	json.NewEncoder(w).Encode(models.ErrorResponse{
-		Reason:  errReason,
-		Message: err.Error(),
-	})
+func DecodeConfirmTOTPRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req models.ConfirmTOTPData
+
+	if err := decodeAuthenticatedBody(r, &req); err != nil {
+		return nil, err
+	}
+	if req.AccessToken, err = resolveAccessToken(r, req.AccessToken); err != nil {
+		return nil, err
+	}
+
+	return endpoints.ConfirmTOTPRequest{Req: req}, nil
 }
 
-func codeFrom(err error) (int, string) {
-	switch err {
-	case errors.ErrMissingBody:
-		return http.StatusBadRequest, constants.MissingBody
-	case errors.ErrMalformedBody:
-		return http.StatusBadRequest, constants.MalformedBody
-	case errors.ErrMisingRefreshToken:
-		return http.StatusUnauthorized, constants.MissingRefreshToken
-	case errors.ErrInvalidClaimInToken:
-		return http.StatusUnauthorized, constants.InvalidClaimInToken
-	case errors.ErrNonAuthorized:
-		return http.StatusUnauthorized, constants.NonAuthorized
-	case errors.ErrEncoding:
-		return http.StatusInternalServerError, constants.Encoding
-	default:
-		return http.StatusInternalServerError, err.Error()
+func encodeConfirmTOTPResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	e, ok := response.(endpoints.ConfirmTOTPResponse)
+	if !ok {
+		return errors.ErrEncoding
+	}
+
+	return e.Error()
+}
+
+func DecodeRecoveryCodesRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req models.RecoveryCodesData
+
+	if err := decodeAuthenticatedBody(r, &req); err != nil {
+		return nil, err
+	}
+	if req.AccessToken, err = resolveAccessToken(r, req.AccessToken); err != nil {
+		return nil, err
 	}
+
+	return endpoints.RecoveryCodesRequest{Req: req}, nil
 }
 
-type ErrorResponse struct {
-	Reason  string `json:"reason"`
-	Message string `json:"message"`
+func encodeRecoveryCodesResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	e, ok := response.(endpoints.RecoveryCodesResponse)
+	if !ok {
+		return errors.ErrEncoding
+	}
+
+	err := e.Error()
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(e.Res)
+}
+
+func DecodeResetMFARequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req models.ResetMFAData
+
+	if err := decodeAuthenticatedBody(r, &req); err != nil {
+		return nil, err
+	}
+	if req.AccessToken, err = resolveAccessToken(r, req.AccessToken); err != nil {
+		return nil, err
+	}
+
+	return endpoints.ResetMFARequest{Req: req}, nil
+}
+
+func encodeResetMFAResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	e, ok := response.(endpoints.ResetMFAResponse)
+	if !ok {
+		return errors.ErrEncoding
+	}
+
+	return e.Error()
+}
+
+func DecodeUnlockLoginRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req models.UnlockLoginData
+
+	if err := decodeAuthenticatedBody(r, &req); err != nil {
+		return nil, err
+	}
+	if req.AccessToken, err = resolveAccessToken(r, req.AccessToken); err != nil {
+		return nil, err
+	}
+
+	return endpoints.UnlockLoginRequest{Req: req}, nil
+}
+
+func encodeUnlockLoginResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	e, ok := response.(endpoints.UnlockLoginResponse)
+	if !ok {
+		return errors.ErrEncoding
+	}
+
+	return e.Error()
+}
+
+func DecodeWebAuthnRegisterBeginRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req models.WebAuthnRegistrationData
+
+	if err := decodeAuthenticatedBody(r, &req); err != nil {
+		return nil, err
+	}
+	if req.AccessToken, err = resolveAccessToken(r, req.AccessToken); err != nil {
+		return nil, err
+	}
+
+	return endpoints.WebAuthnRegisterBeginRequest{Req: req}, nil
+}
+
+func encodeWebAuthnRegisterBeginResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	e, ok := response.(endpoints.WebAuthnRegisterBeginResponse)
+	if !ok {
+		return errors.ErrEncoding
+	}
+
+	err := e.Error()
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(e.Res)
+}
+
+func DecodeWebAuthnRegisterFinishRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req models.WebAuthnRegistrationFinishData
+
+	if err := decodeAuthenticatedBody(r, &req); err != nil {
+		return nil, err
+	}
+	if req.AccessToken, err = resolveAccessToken(r, req.AccessToken); err != nil {
+		return nil, err
+	}
+
+	return endpoints.WebAuthnRegisterFinishRequest{Req: req}, nil
+}
+
+func encodeWebAuthnRegisterFinishResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	e, ok := response.(endpoints.WebAuthnRegisterFinishResponse)
+	if !ok {
+		return errors.ErrEncoding
+	}
+
+	return e.Error()
+}
+
+func DecodeWebAuthnLoginBeginRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req models.WebAuthnLoginData
+
+	if r.Body == nil {
+		return nil, errors.ErrMissingBody
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.ErrMalformedBody
+	}
+
+	return endpoints.WebAuthnLoginBeginRequest{Req: req}, nil
+}
+
+func encodeWebAuthnLoginBeginResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	e, ok := response.(endpoints.WebAuthnLoginBeginResponse)
+	if !ok {
+		return errors.ErrEncoding
+	}
+
+	err := e.Error()
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(e.Res)
+}
+
+func DecodeWebAuthnLoginFinishRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req models.WebAuthnLoginFinishData
+
+	if r.Body == nil {
+		return nil, errors.ErrMissingBody
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.ErrMalformedBody
+	}
+	req.ClientIP = ClientIP(r)
+	req.UserAgent = r.UserAgent()
+
+	return endpoints.WebAuthnLoginFinishRequest{Req: req}, nil
 }
@@ -3,11 +3,20 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Soroka-EDMS/svc/sessions/pkgs/endpoints"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
 	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/ratelimit"
+	"github.com/go-kit/kit/log"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,8 +28,9 @@ func TestDecodeLoginRequest(t *testing.T) {
 
 	testData := endpoints.LoginRequest{
 		Req: models.LoginData{
-			UserName: "admin",
-			Password: "a@m1n",
+			UserName:   "admin",
+			Password:   "a@m1n",
+			RememberMe: true,
 		},
 	}
 
@@ -29,6 +39,64 @@ func TestDecodeLoginRequest(t *testing.T) {
 	assert.Equal(t, testData, resp)
 }
 
+func TestDecodeLoginPostRequest_JSON(t *testing.T) {
+	body := `{"user_name": "admin", "password": "a@m1n", "remember_me": true, "client_id": "web"}`
+	rawRequest, err := http.NewRequest("POST", "https://edms.com/session/login", strings.NewReader(body))
+	assert.NoError(t, err)
+	rawRequest.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	resp, err := DecodeLoginPostRequest(context.Background(), rawRequest)
+	assert.NoError(t, err)
+	assert.Equal(t, models.LoginData{UserName: "admin", Password: "a@m1n", RememberMe: true, ClientID: "web"}, resp.(endpoints.LoginRequest).Req)
+}
+
+func TestDecodeLoginPostRequest_Form(t *testing.T) {
+	form := url.Values{"user_name": {"admin"}, "password": {"a@m1n"}, "remember_me": {"on"}, "client_id": {"web"}}
+	rawRequest, err := http.NewRequest("POST", "https://edms.com/session/login", strings.NewReader(form.Encode()))
+	assert.NoError(t, err)
+	rawRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := DecodeLoginPostRequest(context.Background(), rawRequest)
+	assert.NoError(t, err)
+	assert.Equal(t, models.LoginData{UserName: "admin", Password: "a@m1n", RememberMe: true, ClientID: "web"}, resp.(endpoints.LoginRequest).Req)
+}
+
+func TestDecodeLoginPostRequest_Invalid(t *testing.T) {
+	for _, tc := range []struct {
+		contentType string
+		body        string
+		err         error
+	}{
+		{"text/plain", "admin:a@m1n", errors.ErrUnsupportedContentType},
+		{"application/json", "{", errors.ErrMalformedBody},
+		{"application/json", `{"user_name": "admin"}`, errors.ErrNonAuthorized},
+		{"application/x-www-form-urlencoded", "user_name=admin&password=a&remember_me=maybe", errors.ErrMalformedBody},
+	} {
+		rawRequest, err := http.NewRequest("POST", "https://edms.com/session/login", strings.NewReader(tc.body))
+		assert.NoError(t, err)
+		rawRequest.Header.Set("Content-Type", tc.contentType)
+
+		_, err = DecodeLoginPostRequest(context.Background(), rawRequest)
+		assert.Equal(t, tc.err, err, tc.body)
+	}
+}
+
+func TestEncodeLoginResponse_SessionCookie(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := encodeLoginResponse(context.Background(), w, endpoints.LoginResponse{
+		AccessToken:  models.TokenData{Token: "access", Type: "Bearer"},
+		RefreshToken: models.TokenData{Token: "refresh", ExpirationDate: time.Now().Add(time.Hour).Unix()},
+		SessionOnly:  true,
+	})
+	assert.NoError(t, err)
+
+	cookies := w.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, "refresh", cookies[0].Value)
+	assert.True(t, cookies[0].Expires.IsZero())
+	assert.Zero(t, cookies[0].MaxAge)
+}
+
 func TestDecodeLogoutRequest(t *testing.T) {
 	rawRequest, err := http.NewRequest("GET", "https://edms.com/api/v1/sessions/logout", nil)
 	assert.NoError(t, err)
@@ -59,24 +127,336 @@ func TestDecodeCheckTokenRequest(t *testing.T) {
 	rawRequest, err := http.NewRequest("POST", "https://edms.com/api/v1/users/check_token", bytes.NewBuffer(rawTokenString))
 	assert.NoError(t, err)
 
-	cookie := &http.Cookie{
-		Name:  "refresh_token",
-		Value: "x34H56Bar45=",
+	//Refresh cookie is ignored: check_token only validates, refresh issues new tokens
+	rawRequest.AddCookie(&http.Cookie{Name: "refresh_token", Value: "x34H56Bar45="})
+
+	resp, err := DecodeCheckTokenRequest(context.Background(), rawRequest)
+	assert.NoError(t, err)
+	req, ok := resp.(endpoints.CheckTokenRequest)
+	assert.True(t, ok)
+	assert.Equal(t, models.CheckTokenServiceInput{AccessToken: "x78H56Bar90="}, req.Req)
+}
+
+func TestDecodeCheckTokenRequest_BearerHeader(t *testing.T) {
+	for _, tc := range []struct {
+		header string
+		body   string
+		token  string
+		err    error
+	}{
+		{"Bearer header", "", "header", nil},
+		{"Bearer header", `{"access_token": "header"}`, "header", nil},
+		{"", `{"access_token": "body"}`, "body", nil},
+		{"Bearer header", `{"access_token": "body"}`, "", errors.ErrAmbiguousToken},
+		{"", "", "", errors.ErrMissingAccessToken},
+		{"Bearer header", "[", "", errors.ErrMalformedBody},
+	} {
+		rawRequest, err := http.NewRequest("POST", "https://edms.com/session/check_token", strings.NewReader(tc.body))
+		assert.NoError(t, err)
+		if len(tc.header) != 0 {
+			rawRequest.Header.Set("Authorization", tc.header)
+		}
+
+		resp, err := DecodeCheckTokenRequest(context.Background(), rawRequest)
+		assert.Equal(t, tc.err, err, tc.header+" "+tc.body)
+		if err == nil {
+			assert.Equal(t, tc.token, resp.(endpoints.CheckTokenRequest).Req.AccessToken)
+		}
 	}
+}
 
-	rawRequest.AddCookie(cookie)
+func TestDecodeCheckTokensRequest(t *testing.T) {
+	rawRequest, err := http.NewRequest("POST", "https://edms.com/session/check_tokens", strings.NewReader(`{"access_token": "one"}`))
+	assert.NoError(t, err)
+	_, err = DecodeCheckTokensRequest(context.Background(), rawRequest)
+	assert.Equal(t, errors.ErrMissingAccessToken, err)
 
-	testData := endpoints.CheckTokenRequest{
-		Req: models.CheckTokenServiceInput{
-			AccessToken:  "x78H56Bar90=",
-			RefreshToken: "x34H56Bar45=",
-		},
+	rawRequest, err = http.NewRequest("POST", "https://edms.com/session/check_tokens", strings.NewReader(`{"access_tokens": ["one", "two"]}`))
+	assert.NoError(t, err)
+	resp, err := DecodeCheckTokensRequest(context.Background(), rawRequest)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"one", "two"}, resp.(endpoints.CheckTokensRequest).Req.AccessTokens)
+}
+
+func TestDecodeAuthorizeRequest(t *testing.T) {
+	rawRequest, err := http.NewRequest("POST", "https://edms.com/session/authorize", strings.NewReader(`{"permissions": ["documents.read"], "mode": "any"}`))
+	assert.NoError(t, err)
+	rawRequest.Header.Set("Authorization", "Bearer token")
+	resp, err := DecodeAuthorizeRequest(context.Background(), rawRequest)
+	assert.NoError(t, err)
+	assert.Equal(t, models.AuthorizeData{AccessToken: "token", Permissions: []string{"documents.read"}, Mode: "any"}, resp.(endpoints.AuthorizeRequest).Req)
+
+	rawRequest, err = http.NewRequest("POST", "https://edms.com/session/authorize", strings.NewReader(`{"access_token": "token"}`))
+	assert.NoError(t, err)
+	_, err = DecodeAuthorizeRequest(context.Background(), rawRequest)
+	assert.Equal(t, errors.ErrMissingBody, err)
+}
+
+func TestEncodeError_NoPermissions(t *testing.T) {
+	w := httptest.NewRecorder()
+	encodeError(context.Background(), errors.ErrNoPermissions, w)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestDecodeAuthenticatedRequests_BearerHeader(t *testing.T) {
+	for name, decode := range map[string]func(context.Context, *http.Request) (interface{}, error){
+		"enroll":   DecodeEnrollTOTPRequest,
+		"confirm":  DecodeConfirmTOTPRequest,
+		"recovery": DecodeRecoveryCodesRequest,
+		"reset":    DecodeResetMFARequest,
+		"unlock":   DecodeUnlockLoginRequest,
+		"begin":    DecodeWebAuthnRegisterBeginRequest,
+		"finish":   DecodeWebAuthnRegisterFinishRequest,
+	} {
+		rawRequest, err := http.NewRequest("POST", "https://edms.com/session", nil)
+		assert.NoError(t, err)
+		rawRequest.Header.Set("Authorization", "Bearer header")
+
+		resp, err := decode(context.Background(), rawRequest)
+		assert.NoError(t, err, name)
+		assert.Contains(t, fmt.Sprintf("%+v", resp), "AccessToken:header", name)
+
+		rawRequest, err = http.NewRequest("POST", "https://edms.com/session", strings.NewReader(`{"access_token": "body"}`))
+		assert.NoError(t, err)
+		rawRequest.Header.Set("Authorization", "Bearer header")
+
+		_, err = decode(context.Background(), rawRequest)
+		assert.Equal(t, errors.ErrAmbiguousToken, err, name)
 	}
+}
 
-	resp, err := DecodeCheckTokenRequest(context.Background(), rawRequest)
+func TestDecodeLoginMFARequest(t *testing.T) {
+	var rawBody = []byte(`{"challenge_token": "x78H56Bar90=", "code": "123456"}`)
+	rawRequest, err := http.NewRequest("POST", "https://edms.com/session/login/mfa", bytes.NewBuffer(rawBody))
 	assert.NoError(t, err)
-	req, ok := resp.(endpoints.CheckTokenRequest)
+
+	resp, err := DecodeLoginMFARequest(context.Background(), rawRequest)
+	assert.NoError(t, err)
+	req, ok := resp.(endpoints.LoginMFARequest)
 	assert.True(t, ok)
-	assert.Equal(t, req.Req.AccessToken, testData.Req.AccessToken)
-	assert.Equal(t, req.Req.RefreshToken, testData.Req.RefreshToken)
+	assert.Equal(t, "x78H56Bar90=", req.Req.ChallengeToken)
+	assert.Equal(t, "123456", req.Req.Code)
+
+	rawRequest, err = http.NewRequest("POST", "https://edms.com/session/login/mfa", bytes.NewBufferString("{"))
+	assert.NoError(t, err)
+	_, err = DecodeLoginMFARequest(context.Background(), rawRequest)
+	assert.Equal(t, errors.ErrMalformedBody, err)
+}
+
+func TestEncodeLoginResponse_NativeClient(t *testing.T) {
+	rawRequest, err := http.NewRequest("POST", "https://edms.com/session/login", nil)
+	assert.NoError(t, err)
+	rawRequest.Header.Set("X-Client-Type", "Native")
+	ctx := populateClientType(context.Background(), rawRequest)
+
+	w := httptest.NewRecorder()
+	err = encodeLoginResponse(ctx, w, endpoints.LoginResponse{
+		AccessToken:  models.TokenData{Token: "access", Type: "Bearer", ExpirationDate: 100},
+		RefreshToken: models.TokenData{Token: "refresh", Type: "Bearer", ExpirationDate: 200},
+	})
+	assert.NoError(t, err)
+
+	assert.Empty(t, w.Result().Cookies())
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.JSONEq(t, `{"access_token": "access", "type": "Bearer", "expiration_date": 100, "refresh_token": "refresh", "refresh_expiration_date": 200}`, w.Body.String())
+}
+
+func TestDecodeRefreshRequest(t *testing.T) {
+	for _, tc := range []struct {
+		header string
+		body   string
+		token  string
+		err    error
+	}{
+		{"", `{"refresh_token": "body"}`, "body", nil},
+		{"Bearer header", "", "header", nil},
+		{"bearer header", `{"refresh_token": "header"}`, "header", nil},
+		{"Bearer header", `{"refresh_token": "body"}`, "", errors.ErrAmbiguousToken},
+		{"Basic YWRtaW46YUBtMW4=", "", "", errors.ErrMisingRefreshToken},
+		{"Basic YWRtaW46YUBtMW4=", `{"refresh_token": ""}`, "", errors.ErrMisingRefreshToken},
+		{"", "{", "", errors.ErrMalformedBody},
+	} {
+		rawRequest, err := http.NewRequest("POST", "https://edms.com/session/refresh", strings.NewReader(tc.body))
+		assert.NoError(t, err)
+		if len(tc.header) != 0 {
+			rawRequest.Header.Set("Authorization", tc.header)
+		}
+
+		resp, err := DecodeRefreshRequest(context.Background(), rawRequest)
+		assert.Equal(t, tc.err, err, tc.header+" "+tc.body)
+		if err == nil {
+			assert.Equal(t, tc.token, resp.(endpoints.RefreshRequest).Req.RefreshToken)
+		}
+	}
+}
+
+func TestDecodeRefreshRequest_Cookie(t *testing.T) {
+	rawRequest, err := http.NewRequest("POST", "https://edms.com/session/refresh", nil)
+	assert.NoError(t, err)
+	rawRequest.AddCookie(&http.Cookie{Name: "refresh_token", Value: "cookie"})
+
+	resp, err := DecodeRefreshRequest(context.Background(), rawRequest)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RefreshData{RefreshToken: "cookie", FromCookie: true}, resp.(endpoints.RefreshRequest).Req)
+
+	//Token sent explicitly wins over the cookie
+	rawRequest.Header.Set("Authorization", "Bearer header")
+	resp, err = DecodeRefreshRequest(context.Background(), rawRequest)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RefreshData{RefreshToken: "header"}, resp.(endpoints.RefreshRequest).Req)
+}
+
+func TestEncodeRefreshResponse_RotatesCookie(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := encodeRefreshResponse(context.Background(), w, endpoints.RefreshResponse{
+		AccessToken:  models.TokenData{Token: "access", Type: "Bearer", ExpirationDate: 100},
+		RefreshToken: models.TokenData{Token: "rotated", Type: "Bearer", ExpirationDate: time.Now().Add(time.Hour).Unix()},
+		Cookie:       true,
+	})
+	assert.NoError(t, err)
+
+	cookies := w.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, "rotated", cookies[0].Value)
+	assert.JSONEq(t, `{"access_token": "access", "type": "Bearer", "expiration_date": 100}`, w.Body.String())
+}
+
+func TestEncodeError_RetryAfter(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	encodeError(context.Background(), &errors.RetryError{Err: errors.ErrAccountLocked, RetryAfter: 1500 * time.Millisecond}, w)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "temporarily locked")
+}
+
+func TestDecodeLoginRequest_ClientIP(t *testing.T) {
+	rawRequest, err := http.NewRequest("GET", "https://edms.com/session/login", nil)
+	assert.NoError(t, err)
+	rawRequest.SetBasicAuth("admin", "a@m1n")
+	rawRequest.RemoteAddr = "192.0.2.10:53211"
+	rawRequest.Header.Set("X-Forwarded-For", "198.51.100.1")
+	rawRequest.Header.Set("User-Agent", "Mozilla/5.0")
+
+	resp, err := DecodeLoginRequest(context.Background(), rawRequest)
+	assert.NoError(t, err)
+	assert.Equal(t, "192.0.2.10", resp.(endpoints.LoginRequest).Req.ClientIP)
+	assert.Equal(t, "Mozilla/5.0", resp.(endpoints.LoginRequest).Req.UserAgent)
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	limiter := ratelimit.NewLimiter(ratelimit.Config{
+		Endpoints: map[string]ratelimit.Limits{"login": {IP: ratelimit.Limit{Rate: 1, Burst: 1}}},
+		MaxKeys:   10,
+	})
+	endp := endpoints.SessionsEndpoints{
+		LoginEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			return endpoints.LoginResponse{Err: errors.ErrNonAuthorized}, nil
+		},
+	}.WithRateLimits(limiter)
+	handler := MakeHTTPHandler(endp, log.NewNopLogger())
+
+	request := httptest.NewRequest("GET", "https://edms.com/session/login", nil)
+	request.SetBasicAuth("admin", "a@m1n")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+}
+
+func TestForwardAuth(t *testing.T) {
+	endp := endpoints.SessionsEndpoints{
+		ForwardAuthEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			if request.(endpoints.CheckTokenRequest).Req.AccessToken != "valid" {
+				return endpoints.CheckTokenResponse{Err: errors.ErrExpiredAccessToken}, nil
+			}
+			return endpoints.CheckTokenResponse{Req: models.CheckTokenServiceOutput{Subject: "admin@edms.com", Mask: 32767, SessionID: "sid"}}, nil
+		},
+	}
+	handler := MakeHTTPHandler(endp, log.NewNopLogger())
+
+	request := httptest.NewRequest("GET", "https://edms.com/session/auth", nil)
+	request.Header.Set("Authorization", "Bearer valid")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "admin@edms.com", w.Header().Get("X-Auth-User"))
+	assert.Equal(t, "32767", w.Header().Get("X-Auth-Mask"))
+	assert.Equal(t, "sid", w.Header().Get("X-Auth-Session"))
+
+	//Access token is taken from the header only
+	request = httptest.NewRequest("GET", "https://edms.com/session/auth", nil)
+	request.AddCookie(&http.Cookie{Name: "access_token", Value: "valid"})
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	for _, header := range []string{"", "Bearer expired"} {
+		request = httptest.NewRequest("GET", "https://edms.com/session/auth", nil)
+		request.Header.Set("Authorization", header)
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, request)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, header)
+		assert.Empty(t, w.Header().Get("X-Auth-User"))
+	}
+}
+
+func TestVersionedRoutes(t *testing.T) {
+	logout := func(ctx context.Context, request interface{}) (interface{}, error) {
+		return endpoints.LogoutResponse{}, nil
+	}
+	handler := MakeHTTPHandler(endpoints.SessionsEndpoints{LogoutEndpoint: logout}, log.NewNopLogger())
+
+	request := httptest.NewRequest("POST", "https://edms.com/v1/session/logout", nil)
+	request.Header.Set("Authorization", "Bearer refresh")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Deprecation"))
+
+	//Unversioned path is a deprecated alias
+	request = httptest.NewRequest("GET", "https://edms.com/session/logout", nil)
+	request.Header.Set("Authorization", "Bearer refresh")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "true", w.Header().Get("Deprecation"))
+	assert.Equal(t, `</v1/session/logout>; rel="successor-version"`, w.Header().Get("Link"))
+
+	//Versioned logout takes POST only
+	request = httptest.NewRequest("GET", "https://edms.com/v1/session/logout", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	handler := MakeHTTPHandler(endpoints.SessionsEndpoints{}, log.NewNopLogger())
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "https://edms.com/v1/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var doc struct {
+		Paths      map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Contains(t, doc.Paths["/v1/session/login"], "post")
+	assert.NotContains(t, doc.Paths["/v1/session/login"], "get")
+	assert.Contains(t, doc.Paths["/v1/session/logout"], "post")
+	assert.NotContains(t, doc.Paths, "/session/login")
+	for _, model := range []string{"LoginData", "TokenData", "MFAChallenge", "NativeTokens", "CheckTokenServiceOutput", "Decision", "Problem"} {
+		assert.Contains(t, doc.Components.Schemas, model)
+	}
 }
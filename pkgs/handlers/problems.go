@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+
+	httptransport "github.com/go-kit/kit/transport/http"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/constants"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+)
+
+//problemType describes how an error is reported to clients. Codes are part of the API and must not change
+type problemType struct {
+	err    error
+	status int
+	code   string
+	title  string
+}
+
+//internalProblem is reported for errors that are not in the catalog. Such errors may carry internal details, so their
+//text is logged only
+var internalProblem = problemType{status: http.StatusInternalServerError, code: "internal_error", title: constants.InternalError}
+
+//catalog lists errors clients may get. Errors of configuration and of calls to other services are internal and are
+//left out on purpose. Unknown user is reported as invalid credentials, so that accounts cannot be enumerated
+var catalog = []problemType{
+	{errors.ErrMissingBody, http.StatusBadRequest, "missing_body", constants.MissingBody},
+	{errors.ErrMalformedBody, http.StatusBadRequest, "malformed_body", constants.MalformedBody},
+	{errors.ErrAmbiguousToken, http.StatusBadRequest, "ambiguous_token", constants.AmbiguousToken},
+	{errors.ErrUnsupportedContentType, http.StatusUnsupportedMediaType, "unsupported_content_type", constants.UnsupportedContentType},
+	{errors.ErrBatchTooLarge, http.StatusRequestEntityTooLarge, "batch_too_large", constants.BatchTooLarge},
+	{errors.ErrUnknownPermission, http.StatusBadRequest, "unknown_permission", constants.UnknownPermission},
+	{errors.ErrInvalidAuthorizationMode, http.StatusBadRequest, "invalid_authorization_mode", constants.InvalidAuthorizationMode},
+	{errors.ErrNotFound, http.StatusNotFound, "not_found", constants.NotFound},
+	{errors.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "method_not_allowed", constants.MethodNotAllowed},
+	{errors.ErrNotImplemented, http.StatusNotImplemented, "not_implemented", constants.NotImplemented},
+
+	{errors.ErrNonAuthorized, http.StatusUnauthorized, "unauthorized", constants.NonAuthorized},
+	{errors.ErrClientUnknown, http.StatusUnauthorized, "invalid_credentials", constants.InvalidCredentials},
+	{errors.ErrMisingRefreshToken, http.StatusUnauthorized, "missing_refresh_token", constants.MissingRefreshToken},
+	{errors.ErrMissingAccessToken, http.StatusUnauthorized, "missing_access_token", constants.MissingAccessToken},
+	{errors.ErrExpiredRefreshToken, http.StatusUnauthorized, "expired_refresh_token", constants.ExpiredRefreshToken},
+	{errors.ErrExpiredAccessToken, http.StatusUnauthorized, "expired_access_token", constants.ExpiredAccessToken},
+	{errors.ErrInvalidClaimInToken, http.StatusUnauthorized, "invalid_claim", constants.InvalidClaimInToken},
+	{errors.ErrInvalidTokenType, http.StatusUnauthorized, "invalid_token_type", constants.InvalidTokenType},
+	{errors.ErrNoPermissions, http.StatusForbidden, "no_permissions", constants.NoPermissions},
+
+	{errors.ErrInvalidMFACode, http.StatusUnauthorized, "invalid_mfa_code", constants.InvalidMFACode},
+	{errors.ErrMFAAlreadyEnabled, http.StatusConflict, "mfa_already_enabled", constants.MFAAlreadyEnabled},
+	{errors.ErrMFANotEnrolled, http.StatusBadRequest, "mfa_not_enrolled", constants.MFANotEnrolled},
+	{errors.ErrInvalidEmailCode, http.StatusUnauthorized, "invalid_email_code", constants.InvalidEmailCode},
+	{errors.ErrMalformedAuthenticatorData, http.StatusBadRequest, "malformed_authenticator_data", constants.MalformedAuthenticatorData},
+	{errors.ErrWebAuthnVerification, http.StatusUnauthorized, "webauthn_verification_failed", constants.WebAuthnVerification},
+	{errors.ErrWebAuthnChallenge, http.StatusUnauthorized, "webauthn_challenge_invalid", constants.WebAuthnChallenge},
+	{errors.ErrCredentialNotFound, http.StatusUnauthorized, "credential_not_found", constants.CredentialNotFound},
+	{errors.ErrCredentialAlreadyExists, http.StatusConflict, "credential_already_exists", constants.CredentialAlreadyExists},
+	{errors.ErrSignCountRegression, http.StatusUnauthorized, "sign_count_regression", constants.SignCountRegression},
+	{errors.ErrTooManySessions, http.StatusConflict, "too_many_sessions", constants.TooManySessions},
+
+	{errors.ErrRateLimited, http.StatusTooManyRequests, "rate_limited", constants.RateLimited},
+	{errors.ErrTooManyAttempts, http.StatusTooManyRequests, "too_many_attempts", constants.TooManyAttempts},
+	{errors.ErrAccountLocked, http.StatusTooManyRequests, "account_locked", constants.AccountLocked},
+
+	{errors.ErrUsersUnavailable, http.StatusServiceUnavailable, "users_unavailable", constants.UsersUnavailable},
+	{errors.ErrRequestToUsersFailed, http.StatusBadGateway, "users_request_failed", constants.RequestToUsersFailed},
+	{errors.ErrMailUnavailable, http.StatusServiceUnavailable, "mail_unavailable", constants.MailUnavailable},
+}
+
+//problemFor finds the catalog entry of an error. Wrapped errors are matched too
+func problemFor(err error) problemType {
+	for _, p := range catalog {
+		if stderrors.Is(err, p.err) {
+			return p
+		}
+	}
+
+	return internalProblem
+}
+
+//describeVerdicts reports errors of invalid tokens by problem codes, so that clients do not depend on error texts.
+//Errors that are not in the catalog are reported as internal ones
+func describeVerdicts(verdicts []models.TokenVerdict) []models.TokenVerdict {
+	res := make([]models.TokenVerdict, len(verdicts))
+	for i, v := range verdicts {
+		res[i] = v
+		if !v.Valid {
+			p := problemFor(v.Err)
+			res[i].Reason, res[i].Detail = p.code, p.title
+		}
+	}
+
+	return res
+}
+
+//encodeError writes the error as application/problem+json
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	writeRateLimitHeaders(ctx, w)
+
+	//Throttled clients are told when to retry
+	if e, ok := err.(*errors.RetryError); ok {
+		w.Header().Set("Retry-After", formatSeconds(e.RetryAfter))
+		err = e.Err
+	}
+
+	p := problemFor(err)
+	instance, _ := ctx.Value(httptransport.ContextKeyRequestPath).(string)
+
+	w.Header().Set("Content-Type", constants.ProblemContentType)
+	w.WriteHeader(p.status)
+	json.NewEncoder(w).Encode(models.Problem{
+		Type:     constants.ProblemTypeBase + p.code,
+		Title:    p.title,
+		Status:   p.status,
+		Code:     p.code,
+		Instance: instance,
+	})
+}
+
+//problemHandler reports errors of routing, e.g. unknown path, in the same way as errors of endpoints
+func problemHandler(err error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodeError(context.WithValue(r.Context(), httptransport.ContextKeyRequestPath, r.URL.Path), err, w)
+	})
+}
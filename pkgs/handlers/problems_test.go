@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/endpoints"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+)
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) models.Problem {
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+
+	var p models.Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	return p
+}
+
+func TestEncodeError_Problem(t *testing.T) {
+	for _, tc := range []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{errors.ErrClientUnknown, http.StatusUnauthorized, "invalid_credentials"},
+		{errors.ErrExpiredRefreshToken, http.StatusUnauthorized, "expired_refresh_token"},
+		{errors.ErrRequestToUsersFailed, http.StatusBadGateway, "users_request_failed"},
+		{errors.ErrMalformedBody, http.StatusBadRequest, "malformed_body"},
+		{fmt.Errorf("login: %w", errors.ErrNoPermissions), http.StatusForbidden, "no_permissions"},
+		{errors.ErrSigningSecretMissing, http.StatusInternalServerError, "internal_error"},
+	} {
+		ctx := context.WithValue(context.Background(), httptransport.ContextKeyRequestPath, "/v1/session/login")
+		w := httptest.NewRecorder()
+		encodeError(ctx, tc.err, w)
+
+		assert.Equal(t, tc.status, w.Code, tc.code)
+		p := decodeProblem(t, w)
+		assert.Equal(t, models.Problem{
+			Type:     "https://edms.com/problems/" + tc.code,
+			Title:    problemFor(tc.err).title,
+			Status:   tc.status,
+			Code:     tc.code,
+			Instance: "/v1/session/login",
+		}, p)
+	}
+}
+
+func TestEncodeCheckTokensResponse_Reasons(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := encodeCheckTokensResponse(context.Background(), w, endpoints.CheckTokensResponse{Verdicts: []models.TokenVerdict{
+		{Valid: true, Claims: &models.CheckTokenServiceOutput{Subject: "admin@edms.com"}},
+		{Err: fmt.Errorf("verify: %w", errors.ErrNonAuthorized)},
+	}})
+	assert.NoError(t, err)
+
+	var res models.TokenVerdicts
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, "admin@edms.com", res.Verdicts[0].Claims.Subject)
+	assert.Empty(t, res.Verdicts[0].Reason)
+	assert.Equal(t, models.TokenVerdict{Reason: "unauthorized", Detail: problemFor(errors.ErrNonAuthorized).title}, res.Verdicts[1])
+}
+
+func TestEncodeError_NoInternalDetails(t *testing.T) {
+	w := httptest.NewRecorder()
+	encodeError(context.Background(), fmt.Errorf("dial tcp 10.0.0.5:5432: connection refused"), w)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "10.0.0.5")
+	assert.Equal(t, "internal_error", decodeProblem(t, w).Code)
+}
+
+func TestCatalog_UniqueCodes(t *testing.T) {
+	codes := map[string]bool{internalProblem.code: true}
+	for _, p := range catalog {
+		assert.False(t, codes[p.code], p.code)
+		codes[p.code] = true
+		assert.NotEmpty(t, p.title, p.code)
+	}
+}
+
+func TestRoutingProblems(t *testing.T) {
+	handler := MakeHTTPHandler(endpoints.SessionsEndpoints{}, log.NewNopLogger())
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "https://edms.com/v1/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "/v1/unknown", decodeProblem(t, w).Instance)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("DELETE", "https://edms.com/v1/session/login", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "method_not_allowed", decodeProblem(t, w).Code)
+}
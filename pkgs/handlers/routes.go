@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/constants"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/openapi"
+)
+
+//route binds a handler to a path. The handler is served under the version prefix with method and, as a deprecated alias,
+//at the unversioned path with legacyMethod. Either method may be empty when there is no such route. Request, responses
+//and status describe the route in OpenAPI document
+type route struct {
+	method       string
+	legacyMethod string
+	path         string
+	summary      string
+	handler      http.Handler
+	request      interface{}
+	responses    []interface{}
+	status       int
+}
+
+//newRouter registers routes and serves OpenAPI document generated from them
+func newRouter(routes []route) *mux.Router {
+	r := mux.NewRouter()
+	r.NotFoundHandler = problemHandler(errors.ErrNotFound)
+	r.MethodNotAllowedHandler = problemHandler(errors.ErrMethodNotAllowed)
+
+	operations := make([]openapi.Operation, 0, len(routes))
+	for _, rt := range routes {
+		versioned := constants.APIVersionPrefix + rt.path
+
+		if len(rt.method) != 0 {
+			r.Methods(rt.method).Path(versioned).Handler(rt.handler)
+			operations = append(operations, openapi.Operation{
+				Method:    rt.method,
+				Path:      versioned,
+				Summary:   rt.summary,
+				Request:   rt.request,
+				Responses: rt.responses,
+				Status:    rt.status,
+				Error:     models.Problem{},
+			})
+		}
+		if len(rt.legacyMethod) != 0 {
+			successor := versioned
+			if len(rt.method) == 0 {
+				successor = ""
+			}
+			r.Methods(rt.legacyMethod).Path(rt.path).Handler(deprecated(successor, rt.handler))
+		}
+	}
+
+	//The document is generated once, routes do not change at runtime
+	document, _ := json.Marshal(openapi.Document(constants.APITitle, constants.APIVersion, operations))
+	r.Methods("GET").Path(constants.OpenAPIEndpoint).HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write(document)
+	})
+
+	return r
+}
+
+//deprecated marks responses of an unversioned alias as suggested in https://datatracker.ietf.org/doc/draft-ietf-httpapi-deprecation-header/
+//and points to the versioned route that replaces it, if any
+func deprecated(successor string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		if len(successor) != 0 {
+			w.Header().Set("Link", "<"+successor+`>; rel="successor-version"`)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
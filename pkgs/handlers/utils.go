@@ -1,8 +1,21 @@
 package handlers
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/constants"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/ratelimit"
 )
 
 //AddCookie adds cookie http-only in response
@@ -25,3 +38,135 @@ func GetCookieWithToken(value string, expiresIn int64) http.Cookie {
 		HttpOnly: true,
 	}
 }
+
+//AddSessionCookie adds http-only cookie with refresh token that browser drops when it is closed
+func AddSessionCookie(w http.ResponseWriter, tokenValue string) {
+	cookie := GetCookieWithToken(tokenValue, 0)
+	cookie.Expires = time.Time{}
+	http.SetCookie(w, &cookie)
+}
+
+//AddDeviceCookie adds http-only cookie that identifies a remembered device. It is sent to both versioned and
+//unversioned login routes
+func AddDeviceCookie(w http.ResponseWriter, tokenValue string, expiresIn int64) {
+	cookie := GetCookieWithToken(tokenValue, expiresIn)
+	cookie.Name = constants.TrustedDeviceCookie
+	http.SetCookie(w, &cookie)
+}
+
+//ClientIP returns address of the peer. Forwarding headers are ignored since they can be set by the client itself
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+//MakeServerTLSConfig returns TLS settings of HTTPS and gRPC listeners. If CA bundle of calling services is given, client
+//certificates signed by it are verified, so that rate limits tell the services apart. Clients without certificate,
+//e.g. browsers, are served as before
+func MakeServerTLSConfig(cert tls.Certificate, clientCA []byte) (*tls.Config, error) {
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}}
+	if len(clientCA) == 0 {
+		return tlsConfig, nil
+	}
+
+	pool := x509.NewCertPool()
+	if ok := pool.AppendCertsFromPEM(clientCA); !ok {
+		return nil, errors.ErrInvalidCABundle
+	}
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+
+	return tlsConfig, nil
+}
+
+//populateRateLimitKeys puts keys a request is rate limited by into context: client IP, user name of Basic auth and
+//calling service. The service is identified by verified client certificate only: anything the client sends itself could
+//be changed to get a fresh bucket
+func populateRateLimitKeys(ctx context.Context, r *http.Request) context.Context {
+	user, _, _ := r.BasicAuth()
+	user = strings.ToLower(user)
+
+	var service string
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		service = r.TLS.VerifiedChains[0][0].Subject.CommonName
+	}
+
+	return ratelimit.WithKeys(ctx, ClientIP(r), user, service)
+}
+
+type contextKey int
+
+const clientTypeContextKey contextKey = iota
+
+//populateClientType puts client type from the header into context. Native clients get tokens in response body
+func populateClientType(ctx context.Context, r *http.Request) context.Context {
+	return context.WithValue(ctx, clientTypeContextKey, r.Header.Get(constants.ClientTypeHeader))
+}
+
+//isNativeClient tells whether the request came from a native client, e.g. a mobile app or CLI, rather than a browser
+func isNativeClient(ctx context.Context) bool {
+	clientType, _ := ctx.Value(clientTypeContextKey).(string)
+	return strings.EqualFold(clientType, constants.NativeClientType)
+}
+
+//bearerToken returns token from Authorization header of Bearer scheme, if any
+func bearerToken(r *http.Request) string {
+	const prefix = "bearer "
+
+	header := r.Header.Get("Authorization")
+	if len(header) < len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return ""
+	}
+
+	return strings.TrimSpace(header[len(prefix):])
+}
+
+//resolveAccessToken picks access token from Authorization header or from request body. Either may be used; if both are
+//given, they must be equal, so that a proxy adding the header cannot be confused with the caller sending another token
+func resolveAccessToken(r *http.Request, fromBody string) (string, error) {
+	fromHeader := bearerToken(r)
+
+	switch {
+	case len(fromHeader) != 0 && len(fromBody) != 0 && fromHeader != fromBody:
+		return "", errors.ErrAmbiguousToken
+	case len(fromHeader) != 0:
+		return fromHeader, nil
+	case len(fromBody) != 0:
+		return fromBody, nil
+	default:
+		return "", errors.ErrMissingAccessToken
+	}
+}
+
+//decodeAuthenticatedBody decodes JSON body of a request that carries access token. Body may be empty, since the token
+//may come in Authorization header
+func decodeAuthenticatedBody(r *http.Request, v interface{}) error {
+	if r.Body == nil {
+		return nil
+	}
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil && err != io.EOF {
+		return errors.ErrMalformedBody
+	}
+
+	return nil
+}
+
+//writeRateLimitHeaders reports state of rate limits as suggested in https://datatracker.ietf.org/doc/draft-ietf-httpapi-ratelimit-headers/
+func writeRateLimitHeaders(ctx context.Context, w http.ResponseWriter) context.Context {
+	if state, ok := ratelimit.StateFrom(ctx); ok {
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(state.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(state.Remaining))
+		w.Header().Set("RateLimit-Reset", formatSeconds(state.Reset))
+	}
+
+	return ctx
+}
+
+//formatSeconds rounds duration up to whole seconds as HTTP headers expect
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
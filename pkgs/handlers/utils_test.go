@@ -1,11 +1,27 @@
 package handlers
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/go-kit/kit/log"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/endpoints"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
 	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/ratelimit"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -53,3 +69,84 @@ func TestGetCookieWithNewToken(t *testing.T) {
 
 	assert.Equal(t, testCookie, cookie)
 }
+
+//issueCert creates a certificate signed by parent, or a self-signed CA if parent is nil
+func issueCert(t *testing.T, name string, parent *tls.Certificate) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := template, interface{}(key)
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	} else {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	assert.NoError(t, err)
+	leaf, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestMakeServerTLSConfig_ServiceRateLimit(t *testing.T) {
+	ca := issueCert(t, "edms-ca", nil)
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Certificate[0]})
+
+	_, err := MakeServerTLSConfig(issueCert(t, "sessions", &ca), []byte("not a bundle"))
+	assert.Equal(t, errors.ErrInvalidCABundle, err)
+	tlsConfig, err := MakeServerTLSConfig(issueCert(t, "sessions", &ca), caPEM)
+	assert.NoError(t, err)
+
+	limiter := ratelimit.NewLimiter(ratelimit.Config{
+		Endpoints: map[string]ratelimit.Limits{"check_token": {Service: ratelimit.Limit{Rate: 0.01, Burst: 1}}},
+		MaxKeys:   10,
+	})
+	endp := endpoints.SessionsEndpoints{
+		CheckTokenEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			return endpoints.CheckTokenResponse{Req: models.CheckTokenServiceOutput{AccessToken: "valid"}}, nil
+		},
+	}.WithRateLimits(limiter)
+
+	server := httptest.NewUnstartedServer(MakeHTTPHandler(endp, log.NewNopLogger()))
+	server.TLS = tlsConfig
+	server.StartTLS()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Leaf)
+	client := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs}}}
+	}
+	checkToken := func(c *http.Client) int {
+		request, err := http.NewRequest("POST", server.URL+"/session/check_token", nil)
+		assert.NoError(t, err)
+		request.Header.Set("Authorization", "Bearer valid")
+		response, err := c.Do(request)
+		assert.NoError(t, err)
+		response.Body.Close()
+		return response.StatusCode
+	}
+
+	//Verified service gets its own bucket
+	documents := client(issueCert(t, "documents", &ca))
+	assert.Equal(t, http.StatusOK, checkToken(documents))
+	assert.Equal(t, http.StatusTooManyRequests, checkToken(documents))
+	assert.Equal(t, http.StatusOK, checkToken(client(issueCert(t, "archive", &ca))))
+
+	//Clients without certificate are not limited per service
+	anonymous := client()
+	assert.Equal(t, http.StatusOK, checkToken(anonymous))
+	assert.Equal(t, http.StatusOK, checkToken(anonymous))
+}
@@ -0,0 +1,223 @@
+package lockout
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+//Policy describes how failed attempts of a single key are throttled
+type Policy struct {
+	FreeAttempts     int           //failures allowed before back-off starts
+	BaseDelay        time.Duration //back-off after the first throttled failure, it doubles with every next failure
+	MaxDelay         time.Duration //upper bound of back-off
+	LockoutThreshold int           //failures after which the key is locked out
+	LockoutDuration  time.Duration //how long a locked out key stays locked
+	ResetAfter       time.Duration //failures are forgotten after this period without new ones
+}
+
+//Config holds policies of user names and client IPs. IPs are allowed more failures, as many users may share one address
+type Config struct {
+	User    Policy
+	IP      Policy
+	MaxKeys int //number of tracked keys, the key that failed least recently makes room for a new one
+}
+
+//DefaultConfig returns throttling settings used by the service unless overridden
+func DefaultConfig() Config {
+	return Config{
+		User: Policy{
+			FreeAttempts:     3,
+			BaseDelay:        time.Second,
+			MaxDelay:         time.Minute,
+			LockoutThreshold: 10,
+			LockoutDuration:  15 * time.Minute,
+			ResetAfter:       time.Hour,
+		},
+		IP: Policy{
+			FreeAttempts:     20,
+			BaseDelay:        time.Second,
+			MaxDelay:         time.Minute,
+			LockoutThreshold: 100,
+			LockoutDuration:  15 * time.Minute,
+			ResetAfter:       time.Hour,
+		},
+		MaxKeys: 100000,
+	}
+}
+
+type attempts struct {
+	key          string
+	failures     int
+	lastFailure  time.Time
+	blockedUntil time.Time
+	locked       bool
+}
+
+//userLock serialises attempts of a user. It is dropped once nobody holds or waits for it
+type userLock struct {
+	mtx  sync.Mutex
+	refs int
+}
+
+//Guard tracks failed login attempts per user name and per client IP in memory
+type Guard struct {
+	cfg Config
+	now func() time.Time
+
+	mtx      sync.Mutex
+	attempts map[string]*list.Element
+	lru      *list.List //attempts from the most recently failed key to the least recently failed one
+	locks    map[string]*userLock
+}
+
+//NewGuard creates guard with the configuration. Default number of keys is used unless MaxKeys is set
+func NewGuard(cfg Config) *Guard {
+	if cfg.MaxKeys <= 0 {
+		cfg.MaxKeys = DefaultConfig().MaxKeys
+	}
+
+	return &Guard{
+		cfg:      cfg,
+		now:      time.Now,
+		attempts: make(map[string]*list.Element),
+		lru:      list.New(),
+		locks:    make(map[string]*userLock),
+	}
+}
+
+//userKey and ipKey keep user names and IPs apart, as both are tracked in one set
+func userKey(user string) string { return "user|" + user }
+func ipKey(ip string) string     { return "ip|" + ip }
+
+//Acquire waits until no other attempt of the user is in progress and returns a function that ends the attempt. Check,
+//the attempt itself and Fail or Succeed are to be called in between, so that concurrent attempts cannot all pass Check
+//before a failure of any of them is recorded. Empty user is not serialised
+func (g *Guard) Acquire(user string) (release func()) {
+	if len(user) == 0 {
+		return func() {}
+	}
+
+	g.mtx.Lock()
+	l, ok := g.locks[user]
+	if !ok {
+		l = &userLock{}
+		g.locks[user] = l
+	}
+	l.refs++
+	g.mtx.Unlock()
+
+	l.mtx.Lock()
+	return func() {
+		l.mtx.Unlock()
+
+		g.mtx.Lock()
+		defer g.mtx.Unlock()
+		if l.refs--; l.refs == 0 {
+			delete(g.locks, user)
+		}
+	}
+}
+
+//Check tells whether a login attempt of the user from the IP may proceed. If not, it returns how long the client has to wait
+//and whether the wait is caused by a lockout rather than back-off. Empty user or IP is not checked
+func (g *Guard) Check(user, ip string) (retryAfter time.Duration, locked bool) {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	now := g.now()
+	for _, key := range []string{userKey(user), ipKey(ip)} {
+		e, ok := g.attempts[key]
+		if !ok {
+			continue
+		}
+		a := e.Value.(*attempts)
+		if !a.blockedUntil.After(now) {
+			continue
+		}
+		if wait := a.blockedUntil.Sub(now); wait > retryAfter {
+			retryAfter = wait
+		}
+		locked = locked || a.locked
+	}
+
+	return retryAfter, locked
+}
+
+//Fail records a failed attempt of the user from the IP
+func (g *Guard) Fail(user, ip string) {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	now := g.now()
+	if len(user) != 0 {
+		g.fail(userKey(user), g.cfg.User, now)
+	}
+	if len(ip) != 0 {
+		g.fail(ipKey(ip), g.cfg.IP, now)
+	}
+}
+
+//Succeed forgets failures of the user. Failures of the IP are kept, so that a valid account cannot be used to reset them
+func (g *Guard) Succeed(user string) {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	g.forget(userKey(user))
+}
+
+//Unlock forgets failures of the user and of the IP. Empty values are ignored
+func (g *Guard) Unlock(user, ip string) {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	g.forget(userKey(user))
+	g.forget(ipKey(ip))
+}
+
+//fail records a failure of the key. The number of keys is capped: at the cap the key that failed least recently is
+//dropped, so that memory is bounded and every failure does constant work whatever keys come in. Such a key has been
+//quiet the longest, so it is the least likely to be blocked
+func (g *Guard) fail(key string, p Policy, now time.Time) {
+	e, ok := g.attempts[key]
+	if ok {
+		g.lru.MoveToFront(e)
+	} else {
+		if g.lru.Len() >= g.cfg.MaxKeys {
+			g.forget(g.lru.Back().Value.(*attempts).key)
+		}
+		e = g.lru.PushFront(&attempts{key: key})
+		g.attempts[key] = e
+	}
+
+	a := e.Value.(*attempts)
+	if now.Sub(a.lastFailure) > p.ResetAfter && !a.blockedUntil.After(now) {
+		*a = attempts{key: key}
+	}
+
+	a.failures++
+	a.lastFailure = now
+
+	switch {
+	case a.failures >= p.LockoutThreshold:
+		a.locked = true
+		a.blockedUntil = now.Add(p.LockoutDuration)
+	case a.failures > p.FreeAttempts:
+		delay := p.BaseDelay
+		for i := p.FreeAttempts + 1; i < a.failures && delay < p.MaxDelay; i++ {
+			delay *= 2
+		}
+		if delay > p.MaxDelay {
+			delay = p.MaxDelay
+		}
+		a.blockedUntil = now.Add(delay)
+	}
+}
+
+//forget drops failures of the key
+func (g *Guard) forget(key string) {
+	if e, ok := g.attempts[key]; ok {
+		g.lru.Remove(e)
+		delete(g.attempts, key)
+	}
+}
@@ -0,0 +1,170 @@
+package lockout
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestGuard() (*Guard, *time.Time) {
+	now := time.Unix(1600000000, 0)
+	g := NewGuard(Config{
+		User: Policy{
+			FreeAttempts:     2,
+			BaseDelay:        time.Second,
+			MaxDelay:         4 * time.Second,
+			LockoutThreshold: 6,
+			LockoutDuration:  time.Minute,
+			ResetAfter:       time.Hour,
+		},
+		IP: Policy{
+			FreeAttempts:     4,
+			BaseDelay:        time.Second,
+			MaxDelay:         time.Second,
+			LockoutThreshold: 100,
+			LockoutDuration:  time.Minute,
+			ResetAfter:       time.Hour,
+		},
+		MaxKeys: 10,
+	})
+	g.now = func() time.Time { return now }
+
+	return g, &now
+}
+
+func TestGuard_ExponentialBackoffAndLockout(t *testing.T) {
+	g, now := newTestGuard()
+
+	var testData = []struct {
+		retryAfter time.Duration
+		locked     bool
+	}{
+		{0, false},
+		{0, false},
+		{time.Second, false},
+		{2 * time.Second, false},
+		{4 * time.Second, false},
+		{time.Minute, true},
+	}
+
+	for _, td := range testData {
+		g.Fail("user@email.com", "")
+		retryAfter, locked := g.Check("user@email.com", "10.0.0.1")
+		assert.Equal(t, td.retryAfter, retryAfter)
+		assert.Equal(t, td.locked, locked)
+		*now = now.Add(retryAfter)
+	}
+
+	//Another user from the same address is not affected
+	retryAfter, _ := g.Check("other@email.com", "10.0.0.1")
+	assert.Zero(t, retryAfter)
+}
+
+func TestGuard_PerIP(t *testing.T) {
+	g, _ := newTestGuard()
+
+	//Failures spread over many user names are still counted for the address
+	for _, user := range []string{"a", "b", "c", "d", "e"} {
+		g.Fail(user, "10.0.0.1")
+	}
+
+	retryAfter, locked := g.Check("f", "10.0.0.1")
+	assert.Equal(t, time.Second, retryAfter)
+	assert.False(t, locked)
+
+	retryAfter, _ = g.Check("f", "10.0.0.2")
+	assert.Zero(t, retryAfter)
+}
+
+func TestGuard_SucceedAndUnlock(t *testing.T) {
+	g, _ := newTestGuard()
+
+	for i := 0; i < 6; i++ {
+		g.Fail("user@email.com", "10.0.0.1")
+	}
+	_, locked := g.Check("user@email.com", "")
+	assert.True(t, locked)
+
+	g.Unlock("user@email.com", "")
+	retryAfter, _ := g.Check("user@email.com", "")
+	assert.Zero(t, retryAfter)
+
+	//Success forgets failures of the user but not of the address
+	for i := 0; i < 5; i++ {
+		g.Fail("user@email.com", "10.0.0.1")
+	}
+	g.Succeed("user@email.com")
+	retryAfter, _ = g.Check("user@email.com", "")
+	assert.Zero(t, retryAfter)
+	retryAfter, _ = g.Check("", "10.0.0.1")
+	assert.Equal(t, time.Second, retryAfter)
+}
+
+func TestGuard_ResetAfter(t *testing.T) {
+	g, now := newTestGuard()
+
+	for i := 0; i < 3; i++ {
+		g.Fail("user@email.com", "")
+	}
+
+	//Failures are forgotten after a quiet period
+	*now = now.Add(2 * time.Hour)
+	g.Fail("user@email.com", "")
+	retryAfter, _ := g.Check("user@email.com", "")
+	assert.Zero(t, retryAfter)
+}
+
+func TestGuard_AcquireSerialisesUser(t *testing.T) {
+	g, _ := newTestGuard()
+
+	//Every attempt checks and fails under the lock, so the ones after free attempts see the back-off
+	var (
+		wg     sync.WaitGroup
+		passed int32
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release := g.Acquire("user@email.com")
+			defer release()
+
+			if retryAfter, _ := g.Check("user@email.com", ""); retryAfter == 0 {
+				atomic.AddInt32(&passed, 1)
+				g.Fail("user@email.com", "")
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(3), passed)
+	assert.Empty(t, g.locks)
+}
+
+func TestGuard_MaxKeys(t *testing.T) {
+	g, _ := newTestGuard()
+	for i := 0; i < 4; i++ {
+		g.Fail("user@email.com", "")
+	}
+
+	//Every failure brings new keys, the number of tracked keys stays at the cap
+	for i := 0; i < 100; i++ {
+		g.Fail(fmt.Sprintf("user%d@email.com", i), fmt.Sprintf("192.0.2.%d", i))
+	}
+	assert.Len(t, g.attempts, 10)
+	assert.Equal(t, 10, g.lru.Len())
+
+	//Keys that failed recently are kept, the ones that failed least recently make room
+	retryAfter, _ := g.Check("user99@email.com", "192.0.2.99")
+	assert.Zero(t, retryAfter)
+	_, ok := g.attempts[userKey("user99@email.com")]
+	assert.True(t, ok)
+	_, ok = g.attempts[ipKey("192.0.2.99")]
+	assert.True(t, ok)
+	_, ok = g.attempts[userKey("user@email.com")]
+	assert.False(t, ok)
+}
@@ -0,0 +1,61 @@
+package mfa
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"io"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+)
+
+//Cipher encrypts MFA secrets before they are saved in session store. It uses AES-256-GCM, so tampered records fail to decrypt
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+//NewCipher creates cipher from a key of arbitrary length. The key is stretched to 256 bits with SHA-256
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) == 0 {
+		return nil, errors.ErrEncryptionKeyMissing
+	}
+
+	sum := sha256.Sum256(key)
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cipher{aead: aead}, nil
+}
+
+//Encrypt seals plaintext. A random nonce is prepended to the result
+func (c *Cipher) Encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return c.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+//Decrypt opens data produced by Encrypt
+func (c *Cipher) Decrypt(data []byte) ([]byte, error) {
+	size := c.aead.NonceSize()
+	if len(data) < size {
+		return nil, errors.ErrDecryptionFailed
+	}
+
+	plaintext, err := c.aead.Open(nil, data[:size], data[size:], nil)
+	if err != nil {
+		return nil, errors.ErrDecryptionFailed
+	}
+
+	return plaintext, nil
+}
@@ -0,0 +1,96 @@
+package mfa
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//rfcSecret is "12345678901234567890" encoded in base32. See test vectors in https://tools.ietf.org/html/rfc6238#appendix-B
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateCode_RFCVectors(t *testing.T) {
+	var testData = []struct {
+		time int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, td := range testData {
+		code, err := GenerateCode(rfcSecret, Step(time.Unix(td.time, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, td.code, code)
+	}
+}
+
+func TestValidateCode(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+
+	step, ok := ValidateCode(rfcSecret, "005924", now)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	//Code of the previous step is accepted to tolerate clock drift
+	_, ok = ValidateCode(rfcSecret, "005924", now.Add(Period))
+	assert.True(t, ok)
+
+	_, ok = ValidateCode(rfcSecret, "005924", now.Add(3*Period))
+	assert.False(t, ok)
+
+	_, ok = ValidateCode(rfcSecret, "123", now)
+	assert.False(t, ok)
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	uri := KeyURI("Soroka EDMS", "gladys.champl@edms.com", secret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/"))
+	assert.Contains(t, uri, "secret="+secret)
+}
+
+func TestCipher(t *testing.T) {
+	c, err := NewCipher([]byte("key"))
+	assert.NoError(t, err)
+
+	sealed, err := c.Encrypt([]byte(rfcSecret))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(sealed), rfcSecret)
+
+	opened, err := c.Decrypt(sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, rfcSecret, string(opened))
+
+	other, err := NewCipher([]byte("another key"))
+	assert.NoError(t, err)
+	_, err = other.Decrypt(sealed)
+	assert.Error(t, err)
+
+	_, err = NewCipher(nil)
+	assert.Error(t, err)
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	assert.NoError(t, err)
+	assert.Len(t, codes, 10)
+
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		assert.Len(t, code, 11)
+		assert.False(t, seen[code])
+		seen[code] = true
+	}
+
+	//Codes typed by hand still match
+	assert.Equal(t, HashRecoveryCode(codes[0]), HashRecoveryCode(" "+strings.ToUpper(strings.Replace(codes[0], "-", "", 1))))
+	assert.NotEqual(t, HashRecoveryCode(codes[0]), HashRecoveryCode(codes[1]))
+}
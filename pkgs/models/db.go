@@ -1,8 +1,86 @@
 package models
 
+//ISessionDatabase stores refresh tokens of user sessions. A user may have several sessions at once:
+//Save adds a session, Get returns the latest one, List returns all of them from the oldest to the latest.
+//Delete tells whether the token was stored: of concurrent callers deleting the same token only one gets true
 type ISessionDatabase interface {
 	Save(userID string, token string) (err error)
 	Exist(userID string, token string) (flag bool)
 	Get(userID string) (token string, err error)
-	Delete(userID string, token string) (err error)
+	List(userID string) (tokens []string, err error)
+	Delete(userID string, token string) (deleted bool, err error)
+	IMFADatabase
+	IWebAuthnDatabase
+	IDeviceDatabase
+	IEmailLoginDatabase
+}
+
+//IMFADatabase stores MFA enrollments of users
+type IMFADatabase interface {
+	SaveMFA(userID string, record MFARecord) (err error)
+	GetMFA(userID string) (record MFARecord, err error)
+	DeleteMFA(userID string) (err error)
+}
+
+//MFARecord describes TOTP enrollment. Secret is encrypted, LastStep keeps the time step of the last accepted code to prevent its reuse.
+//RecoveryCodes keeps hashes of unused recovery codes, TrustedDevices maps remembered device IDs to their expiration date
+type MFARecord struct {
+	Secret         []byte
+	Confirmed      bool
+	LastStep       int64
+	RecoveryCodes  []string
+	TrustedDevices map[string]int64
+}
+
+//IWebAuthnDatabase stores passkeys of users and challenges of ceremonies in progress
+type IWebAuthnDatabase interface {
+	SaveCredential(credential WebAuthnCredential) (err error)
+	GetCredential(credentialID string) (credential WebAuthnCredential, err error)
+	ListCredentials(userID string) (credentials []WebAuthnCredential, err error)
+	SaveChallenge(challenge WebAuthnChallenge) (err error)
+	ConsumeChallenge(challenge string) (record WebAuthnChallenge, err error)
+}
+
+//WebAuthnCredential is a registered passkey. ID is base64url encoded credential ID, PublicKey is in PKIX form.
+//SignCount is the last signature counter reported by authenticator
+type WebAuthnCredential struct {
+	ID        string
+	UserID    string
+	PublicKey []byte
+	SignCount uint32
+	CreatedAt int64
+}
+
+//WebAuthnChallenge is issued at the beginning of a ceremony and can be consumed only once before ExpirationDate.
+//UserID is empty if login was started without user name
+type WebAuthnChallenge struct {
+	Challenge      string
+	Ceremony       string
+	UserID         string
+	ExpirationDate int64
+}
+
+//IDeviceDatabase stores devices users have logged in from. KnownDevices maps device IDs to the time they were last seen
+type IDeviceDatabase interface {
+	SaveDevice(userID string, deviceID string, seenAt int64) (err error)
+	KnownDevices(userID string) (devices map[string]int64, err error)
+}
+
+//IEmailLoginDatabase stores pending passwordless logins, at most one per email. DeleteExpiredEmailLogins removes logins
+//expired by now, given as unix time
+type IEmailLoginDatabase interface {
+	SaveEmailLogin(record EmailLogin) (err error)
+	GetEmailLogin(email string) (record EmailLogin, err error)
+	DeleteEmailLogin(email string) (err error)
+	DeleteExpiredEmailLogins(now int64) (err error)
+}
+
+//EmailLogin keeps hashes of the one-time code and of the magic link token sent to an email. Attempts counts wrong codes
+type EmailLogin struct {
+	Email          string
+	CodeHash       string
+	LinkHash       string
+	Attempts       int
+	CreatedAt      int64
+	ExpirationDate int64
 }
@@ -1,8 +1,13 @@
 package models
 
-type ErrorResponse struct {
-	Reason  string `json:"reason"`
-	Message string `json:"message"`
+//Problem describes an error as defined by RFC 7807. Code is a stable machine-readable identifier of the error, Type is
+//a URI built from it. Title never carries internal details of the error
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Code     string `json:"code"`
+	Instance string `json:"instance,omitempty"`
 }
 
 type MissingRefresh struct {
@@ -14,28 +14,302 @@ type ISessionService interface {
 	Login(cntx context.Context, request LoginData) (resAccess, resRefresh TokenData, err error)
 	Logout(cntx context.Context, request LogoutData) error
 	CheckToken(cntx context.Context, request CheckTokenServiceInput) (res CheckTokenServiceOutput, err error)
+	CheckTokens(cntx context.Context, request CheckTokensData) (res []TokenVerdict, err error)
+	Authorize(cntx context.Context, request AuthorizeData) (res CheckTokenServiceOutput, err error)
+	Decide(cntx context.Context, request DecideData) (res Decision, err error)
+	LoginMFA(cntx context.Context, request LoginMFAData) (resAccess, resRefresh, resDevice TokenData, err error)
+	EnrollTOTP(cntx context.Context, request EnrollTOTPData) (res TOTPEnrollment, err error)
+	ConfirmTOTP(cntx context.Context, request ConfirmTOTPData) error
+	RegenerateRecoveryCodes(cntx context.Context, request RecoveryCodesData) (res RecoveryCodes, err error)
+	ResetMFA(cntx context.Context, request ResetMFAData) error
+	UnlockLogin(cntx context.Context, request UnlockLoginData) error
+	BeginWebAuthnRegistration(cntx context.Context, request WebAuthnRegistrationData) (res WebAuthnCreationOptions, err error)
+	FinishWebAuthnRegistration(cntx context.Context, request WebAuthnRegistrationFinishData) error
+	BeginWebAuthnLogin(cntx context.Context, request WebAuthnLoginData) (res WebAuthnRequestOptions, err error)
+	FinishWebAuthnLogin(cntx context.Context, request WebAuthnLoginFinishData) (resAccess, resRefresh TokenData, err error)
+	Refresh(cntx context.Context, request RefreshData) (resAccess, resRefresh TokenData, err error)
+	RequestEmailLogin(cntx context.Context, request EmailLoginData) (err error)
+	RedeemEmailLogin(cntx context.Context, request EmailLoginRedeemData) (resAccess, resRefresh TokenData, err error)
 }
 
+//IUsersClient describes calls to Users service
+type IUsersClient interface {
+	EnsureUserCreds(ctx context.Context, username, password string) error
+	GetUserProfile(ctx context.Context, email string) (UserProfile, error)
+}
+
+//INotifier delivers security events to users, e.g. by email
+type INotifier interface {
+	Notify(ctx context.Context, event SecurityEvent) error
+}
+
+//IMailer sends plain text emails
+type IMailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+//SecurityEvent tells a user about activity on the account. UserID is the email of the user
+type SecurityEvent struct {
+	Type      string `json:"type"`
+	UserID    string `json:"user_id"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"user_agent"`
+	Time      int64  `json:"time"`
+}
+
+//ServiceTokenSource returns a token that authorizes Sessions service in calls to other services
+type ServiceTokenSource func() (TokenData, error)
+
+//LoginData holds credentials of a user. RememberMe keeps the session after the browser is closed, ClientID names
+//the application the user logs in to
 type LoginData struct {
+	UserName    string `json:"user_name"`
+	Password    string `json:"password"`
+	RememberMe  bool   `json:"remember_me"`
+	ClientID    string `json:"client_id"`
+	DeviceToken string `json:"-"`
+	ClientIP    string `json:"-"`
+	UserAgent   string `json:"-"`
+}
+
+//EmailLoginData asks for a one-time code and a magic link to be sent to the email
+type EmailLoginData struct {
+	Email string `json:"email"`
+}
+
+//EmailLoginRedeemData exchanges a one-time code or the token of a magic link for a pair of tokens.
+//DeviceToken identifies a remembered device, so that the user may skip MFA
+type EmailLoginRedeemData struct {
+	Email       string `json:"email"`
+	Code        string `json:"code"`
+	DeviceToken string `json:"-"`
+	ClientIP    string `json:"-"`
+	UserAgent   string `json:"-"`
+}
+
+//LoginMFAData is the second step of login for users with MFA enabled. Code is either TOTP or recovery code.
+//RememberDevice asks to skip MFA on this device for a while
+type LoginMFAData struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+	RememberDevice bool   `json:"remember_device"`
+	ClientIP       string `json:"-"`
+	UserAgent      string `json:"-"`
+}
+
+type EnrollTOTPData struct {
+	AccessToken string `json:"access_token"`
+}
+
+type ConfirmTOTPData struct {
+	AccessToken string `json:"access_token"`
+	Code        string `json:"code"`
+}
+
+//TOTPEnrollment contains a shared secret to be added to an authenticator app, either manually or as a QR code built from URI
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type RecoveryCodesData struct {
+	AccessToken string `json:"access_token"`
+}
+
+//RecoveryCodes are shown to user once, only their hashes are stored
+type RecoveryCodes struct {
+	Codes []string `json:"codes"`
+}
+
+//ResetMFAData is an admin request to reset recovery codes and/or remembered devices of a user
+type ResetMFAData struct {
+	AccessToken   string `json:"access_token"`
+	UserName      string `json:"user_name"`
+	RecoveryCodes bool   `json:"recovery_codes"`
+	Devices       bool   `json:"devices"`
+}
+
+//MFAChallenge is returned by the first step of login instead of tokens if user has MFA enabled
+type MFAChallenge struct {
+	ChallengeToken string `json:"challenge_token"`
+	Type           string `json:"type"`
+	ExpirationDate int64  `json:"expiration_date"`
+}
+
+//UnlockLoginData lifts login throttling of a user name and/or a client IP
+type UnlockLoginData struct {
+	AccessToken string `json:"access_token"`
+	UserName    string `json:"user_name"`
+	IP          string `json:"ip"`
+}
+
+//WebAuthnRegistrationData starts registration of a passkey for the user authorized by AccessToken
+type WebAuthnRegistrationData struct {
+	AccessToken string `json:"access_token"`
+}
+
+type WebAuthnRegistrationFinishData struct {
+	AccessToken string                 `json:"access_token"`
+	Credential  WebAuthnCredentialData `json:"credential"`
+}
+
+//WebAuthnLoginData starts passwordless login. Empty UserName lets authenticator offer any discoverable credential
+type WebAuthnLoginData struct {
 	UserName string `json:"user_name"`
-	Password string `json:"password"`
+}
+
+type WebAuthnLoginFinishData struct {
+	Credential WebAuthnCredentialData `json:"credential"`
+	ClientIP   string                 `json:"-"`
+	UserAgent  string                 `json:"-"`
+}
+
+//WebAuthnCredentialData is PublicKeyCredential returned by a browser. Field names and base64url encoding of binary values
+//follow PublicKeyCredential.toJSON(), so its result can be sent as is
+type WebAuthnCredentialData struct {
+	ID       string               `json:"id"`
+	Type     string               `json:"type"`
+	Response WebAuthnResponseData `json:"response"`
+}
+
+type WebAuthnResponseData struct {
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AttestationObject string `json:"attestationObject,omitempty"`
+	AuthenticatorData string `json:"authenticatorData,omitempty"`
+	Signature         string `json:"signature,omitempty"`
+	UserHandle        string `json:"userHandle,omitempty"`
+}
+
+//WebAuthnCreationOptions are passed to navigator.credentials.create() as publicKey options
+type WebAuthnCreationOptions struct {
+	Challenge              string                         `json:"challenge"`
+	RP                     WebAuthnEntity                 `json:"rp"`
+	User                   WebAuthnUser                   `json:"user"`
+	PubKeyCredParams       []WebAuthnCredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                          `json:"timeout"`
+	Attestation            string                         `json:"attestation"`
+	ExcludeCredentials     []WebAuthnCredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection WebAuthnAuthenticatorSelection `json:"authenticatorSelection"`
+}
+
+//WebAuthnRequestOptions are passed to navigator.credentials.get() as publicKey options
+type WebAuthnRequestOptions struct {
+	Challenge        string                         `json:"challenge"`
+	Timeout          int64                          `json:"timeout"`
+	RPID             string                         `json:"rpId"`
+	AllowCredentials []WebAuthnCredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                         `json:"userVerification"`
+}
+
+type WebAuthnEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type WebAuthnUser struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type WebAuthnCredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type WebAuthnCredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type WebAuthnAuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
 }
 
 type LogoutData struct {
 	Cookie *http.Cookie
 }
 
+//RefreshData holds refresh token of a session. FromCookie tells that a browser sent it in the cookie
+type RefreshData struct {
+	RefreshToken string `json:"refresh_token"`
+	FromCookie   bool   `json:"-"`
+}
+
+//NativeTokens is returned to native clients, e.g. mobile apps and CLIs, that cannot keep refresh token in a cookie.
+//Access token fields are the same as of TokenData
+type NativeTokens struct {
+	Token                 string `json:"access_token"`
+	Type                  string `json:"type"`
+	ExpirationDate        int64  `json:"expiration_date"`
+	RefreshToken          string `json:"refresh_token"`
+	RefreshExpirationDate int64  `json:"refresh_expiration_date"`
+	DeviceToken           string `json:"device_token,omitempty"`
+	DeviceExpirationDate  int64  `json:"device_expiration_date,omitempty"`
+}
+
 type CheckTokenAnotherServiceInput struct {
 	AccessToken string `json:"access_token"`
 }
 
+//CheckTokenServiceInput holds access token to validate. Expired access tokens are not renewed: clients use refresh for it
 type CheckTokenServiceInput struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
+	AccessToken string `json:"access_token"`
 }
 
+//CheckTokenServiceOutput describes a valid access token, so that callers can authorize requests without decoding it
 type CheckTokenServiceOutput struct {
-	AccessToken string `json:"access_token"`
+	AccessToken    string   `json:"access_token"`
+	Subject        string   `json:"sub"`
+	Mask           int64    `json:"mask"`
+	Permissions    []string `json:"permissions"`
+	ExpirationDate int64    `json:"expiration_date"`
+	SessionID      string   `json:"session_id,omitempty"`
+}
+
+//CheckTokensData holds access tokens validated in one batch
+type CheckTokensData struct {
+	AccessTokens []string `json:"access_tokens"`
+}
+
+//TokenVerdict tells whether an access token of a batch is valid. Valid tokens are described by Claims, invalid ones
+//by Err. Transport reports Err by Reason, a problem code clients may rely on, and Detail, the title of the problem
+type TokenVerdict struct {
+	Valid  bool                     `json:"valid"`
+	Reason string                   `json:"reason,omitempty"`
+	Detail string                   `json:"detail,omitempty"`
+	Claims *CheckTokenServiceOutput `json:"claims,omitempty"`
+	Err    error                    `json:"-"`
+}
+
+//AuthorizeData asks whether access token grants all or any of the named permissions. Mode is either "all", the
+//default, or "any"
+type AuthorizeData struct {
+	AccessToken string   `json:"access_token"`
+	Permissions []string `json:"permissions"`
+	Mode        string   `json:"mode"`
+}
+
+//DecideData asks whether the user of access token may perform an action. IP is the address the user came from, Context
+//holds other attributes of the request, e.g. owner of the document
+type DecideData struct {
+	AccessToken string            `json:"access_token"`
+	Action      string            `json:"action"`
+	Resource    string            `json:"resource"`
+	IP          string            `json:"ip"`
+	Context     map[string]string `json:"context"`
+}
+
+//Decision tells whether an action is allowed and which policy decided it. Policy is empty if no policy matched
+type Decision struct {
+	Allowed bool   `json:"allowed"`
+	Policy  string `json:"policy,omitempty"`
+}
+
+//TokenVerdicts holds verdicts in the order tokens were given
+type TokenVerdicts struct {
+	Verdicts []TokenVerdict `json:"verdicts"`
 }
 
 type TokenData struct {
@@ -44,6 +318,14 @@ type TokenData struct {
 	ExpirationDate int64  `json:"expiration_date"`
 }
 
+//UsersTLSConfig holds TLS material used to reach Users service. Empty CA means system roots are used, Cert and Key enable mutual TLS
+type UsersTLSConfig struct {
+	CA         []byte
+	Cert       []byte
+	Key        []byte
+	ServerName string
+}
+
 type UserRole struct {
 	Name string
 	Mask int64
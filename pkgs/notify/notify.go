@@ -0,0 +1,162 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/constants"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+)
+
+//Config selects notification sink. Webhook is used if its URL is set, otherwise SMTP relay if its address is set
+type Config struct {
+	Webhook WebhookConfig `json:"webhook"`
+	SMTP    SMTPConfig    `json:"smtp"`
+}
+
+//WebhookConfig describes HTTP endpoint that receives security events as JSON
+type WebhookConfig struct {
+	URL     string `json:"url"`
+	Timeout int    `json:"timeout"` //seconds
+}
+
+//SMTPConfig describes local mail relay. The relay is trusted, so no authentication is used
+type SMTPConfig struct {
+	Addr string `json:"addr"`
+	From string `json:"from"`
+}
+
+//ParseConfig reads JSON configuration of the notification sink
+func ParseConfig(data []byte) (Config, error) {
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+//New creates the sink described by configuration. If no sink is configured, events are dropped
+func New(cfg Config) models.INotifier {
+	switch {
+	case len(cfg.Webhook.URL) != 0:
+		timeout := time.Duration(cfg.Webhook.Timeout) * time.Second
+		if timeout <= 0 {
+			timeout = 5 * time.Second
+		}
+		return &Webhook{URL: cfg.Webhook.URL, Client: &http.Client{Timeout: timeout}}
+	case len(cfg.SMTP.Addr) != 0:
+		return NewMailer(cfg.SMTP).(*SMTP)
+	default:
+		return Nop{}
+	}
+}
+
+//Nop drops events
+type Nop struct{}
+
+//Notify does nothing
+func (Nop) Notify(ctx context.Context, event models.SecurityEvent) error {
+	return nil
+}
+
+//Webhook posts events as JSON
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+//Notify posts the event. Any response other than 2xx is an error
+func (w *Webhook) Notify(ctx context.Context, event models.SecurityEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.ErrNotificationRejected
+	}
+
+	return nil
+}
+
+//SMTP emails events to users through a mail relay. It is also used to send any other mail, e.g. login codes
+type SMTP struct {
+	Addr string
+	From string
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+//NewMailer creates mail sender of the relay. It returns nil if no relay is configured
+func NewMailer(cfg SMTPConfig) models.IMailer {
+	if len(cfg.Addr) == 0 {
+		return nil
+	}
+
+	return &SMTP{Addr: cfg.Addr, From: cfg.From, send: smtp.SendMail}
+}
+
+//Notify emails the event to the user
+func (s *SMTP) Notify(ctx context.Context, event models.SecurityEvent) error {
+	return s.Send(ctx, event.UserID, subject(event.Type), EventText(event))
+}
+
+//Send emails plain text message
+func (s *SMTP) Send(ctx context.Context, to, subject, body string) error {
+	return s.send(s.Addr, nil, s.From, []string{to}, Message(s.From, to, subject, body))
+}
+
+//Message composes email. Line breaks are removed from header values, so that they cannot add headers
+func Message(from, to, subject, body string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", lineBreaks.Replace(from))
+	fmt.Fprintf(&b, "To: %s\r\n", lineBreaks.Replace(to))
+	fmt.Fprintf(&b, "Subject: %s\r\n", lineBreaks.Replace(subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().UTC().Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.Replace(body, "\n", "\r\n", -1))
+
+	return []byte(b.String())
+}
+
+//EventText describes the event for the user
+func EventText(event models.SecurityEvent) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", subject(event.Type))
+	fmt.Fprintf(&b, "Time: %s\n", time.Unix(event.Time, 0).UTC().Format(time.RFC1123))
+	fmt.Fprintf(&b, "IP address: %s\n", event.IP)
+	fmt.Fprintf(&b, "Device: %s\n\n", lineBreaks.Replace(event.UserAgent))
+	b.WriteString("If this was not you, change your password and contact the administrator.\n")
+
+	return b.String()
+}
+
+//lineBreaks removes line breaks from values set by clients, so that they cannot forge parts of the message
+var lineBreaks = strings.NewReplacer("\r", " ", "\n", " ")
+
+func subject(eventType string) string {
+	if eventType == constants.NewDeviceEvent {
+		return "New sign-in to your EDMS account"
+	}
+
+	return "Security alert for your EDMS account"
+}
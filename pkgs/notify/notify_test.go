@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/constants"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/notify/notifytest"
+)
+
+var event = models.SecurityEvent{
+	Type:      constants.NewDeviceEvent,
+	UserID:    "gladys.champl@edms.com",
+	IP:        "192.0.2.10",
+	UserAgent: "Mozilla/5.0\r\nBcc: attacker@example.com",
+	Time:      1568292184,
+}
+
+func TestNew_SelectsSink(t *testing.T) {
+	assert.IsType(t, Nop{}, New(Config{}))
+	assert.IsType(t, &SMTP{}, New(Config{SMTP: SMTPConfig{Addr: "localhost:25"}}))
+	assert.IsType(t, &Webhook{}, New(Config{Webhook: WebhookConfig{URL: "https://hooks.edms.com"}, SMTP: SMTPConfig{Addr: "localhost:25"}}))
+}
+
+func TestWebhook_Notify(t *testing.T) {
+	var received models.SecurityEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	sink := New(Config{Webhook: WebhookConfig{URL: server.URL}})
+	assert.NoError(t, sink.Notify(context.Background(), event))
+	assert.Equal(t, event, received)
+}
+
+func TestWebhook_Rejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	sink := New(Config{Webhook: WebhookConfig{URL: server.URL}})
+	assert.Equal(t, errors.ErrNotificationRejected, sink.Notify(context.Background(), event))
+}
+
+func TestSMTP_Notify(t *testing.T) {
+	var (
+		to  []string
+		msg string
+	)
+	sink := &SMTP{Addr: "localhost:25", From: "noreply@edms.com", send: func(addr string, a smtp.Auth, from string, rcpt []string, m []byte) error {
+		to, msg = rcpt, string(m)
+		return nil
+	}}
+
+	assert.NoError(t, sink.Notify(context.Background(), event))
+	assert.Equal(t, []string{"gladys.champl@edms.com"}, to)
+	assert.Contains(t, msg, "Subject: New sign-in to your EDMS account\r\n")
+	assert.Contains(t, msg, "IP address: 192.0.2.10\r\n")
+
+	//Client controlled user agent cannot add headers
+	headers := msg[:strings.Index(msg, "\r\n\r\n")]
+	assert.NotContains(t, headers, "Bcc")
+	assert.NotContains(t, msg, "\r\nBcc")
+}
+
+func TestMailer_SMTPStandIn(t *testing.T) {
+	server, err := notifytest.NewSMTPServer()
+	assert.NoError(t, err)
+	defer server.Close()
+
+	assert.Nil(t, NewMailer(SMTPConfig{}))
+
+	mailer := NewMailer(SMTPConfig{Addr: server.Addr, From: "noreply@edms.com"})
+	assert.NoError(t, mailer.Send(context.Background(), "gladys.champl@edms.com", "Your EDMS login code", "Code: 123456\n.hidden"))
+
+	mail := <-server.Mails
+	assert.Equal(t, "noreply@edms.com", mail.From)
+	assert.Equal(t, []string{"gladys.champl@edms.com"}, mail.To)
+	assert.Contains(t, mail.Data, "Subject: Your EDMS login code\r\n")
+	assert.Contains(t, mail.Data, "\r\n\r\nCode: 123456\r\n.hidden")
+}
@@ -0,0 +1,177 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+//Version of OpenAPI specification documents are generated for
+const Version = "3.0.3"
+
+//problemContentType is media type of error responses, see RFC 7807
+const problemContentType = "application/problem+json"
+
+//Operation describes a route of the API. Request is a model of JSON body, nil if the route takes none. Responses are
+//models of successful response body, one of which is returned; none means the response has no body. Error is a model of
+//problem details
+type Operation struct {
+	Method    string
+	Path      string
+	Summary   string
+	Request   interface{}
+	Responses []interface{}
+	Status    int
+	Error     interface{}
+}
+
+//Document generates OpenAPI document of operations. Models are described in components by their Go type names, so
+//that the document follows the code
+func Document(title, version string, operations []Operation) map[string]interface{} {
+	g := &generator{components: map[string]interface{}{}}
+
+	paths := map[string]interface{}{}
+	for _, op := range operations {
+		item, ok := paths[op.Path].(map[string]interface{})
+		if !ok {
+			item = map[string]interface{}{}
+			paths[op.Path] = item
+		}
+		item[strings.ToLower(op.Method)] = g.operation(op)
+	}
+
+	return map[string]interface{}{
+		"openapi": Version,
+		"info": map[string]interface{}{
+			"title":   title,
+			"version": version,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": g.components,
+			"securitySchemes": map[string]interface{}{
+				"bearer": map[string]interface{}{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+				"basic":  map[string]interface{}{"type": "http", "scheme": "basic"},
+			},
+		},
+	}
+}
+
+type generator struct {
+	components map[string]interface{}
+}
+
+func (g *generator) operation(op Operation) map[string]interface{} {
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	success := map[string]interface{}{"description": http.StatusText(status)}
+	switch len(op.Responses) {
+	case 0:
+	case 1:
+		success["content"] = jsonContent(g.schema(reflect.TypeOf(op.Responses[0])))
+	default:
+		alternatives := make([]interface{}, 0, len(op.Responses))
+		for _, response := range op.Responses {
+			alternatives = append(alternatives, g.schema(reflect.TypeOf(response)))
+		}
+		success["content"] = jsonContent(map[string]interface{}{"oneOf": alternatives})
+	}
+
+	responses := map[string]interface{}{strconv.Itoa(status): success}
+	if op.Error != nil {
+		responses["default"] = map[string]interface{}{
+			"description": "Error",
+			"content":     content(problemContentType, g.schema(reflect.TypeOf(op.Error))),
+		}
+	}
+
+	res := map[string]interface{}{
+		"summary":   op.Summary,
+		"responses": responses,
+	}
+	if op.Request != nil {
+		res["requestBody"] = map[string]interface{}{
+			"content": jsonContent(g.schema(reflect.TypeOf(op.Request))),
+		}
+	}
+
+	return res
+}
+
+func jsonContent(schema interface{}) map[string]interface{} {
+	return content("application/json", schema)
+}
+
+func content(mediaType string, schema interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		mediaType: map[string]interface{}{"schema": schema},
+	}
+}
+
+//schema describes a type the way encoding/json encodes it. Named structs go to components and are referenced
+func (g *generator) schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if len(t.Name()) == 0 {
+			return g.object(t)
+		}
+		if _, ok := g.components[t.Name()]; !ok {
+			//Placeholder stops recursion of self referencing types
+			g.components[t.Name()] = nil
+			g.components[t.Name()] = g.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func (g *generator) object(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if len(field.PkgPath) != 0 {
+			continue //unexported
+		}
+
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			tagName := strings.Split(tag, ",")[0]
+			if tagName == "-" {
+				continue
+			}
+			if len(tagName) != 0 {
+				name = tagName
+			}
+		}
+
+		properties[name] = g.schema(field.Type)
+	}
+
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
@@ -0,0 +1,57 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type credentials struct {
+	User     string `json:"user_name"`
+	Password string `json:"password,omitempty"`
+	Internal string `json:"-"`
+	Untagged int64
+}
+
+type tokens struct {
+	Token  string            `json:"access_token"`
+	Scopes []string          `json:"scopes"`
+	Extra  map[string]string `json:"extra"`
+	Next   *tokens           `json:"next"`
+}
+
+func TestDocument(t *testing.T) {
+	doc := Document("Test", "1", []Operation{
+		{Method: "POST", Path: "/v1/login", Summary: "Log in", Request: credentials{}, Responses: []interface{}{tokens{}}},
+		{Method: "GET", Path: "/v1/auth", Summary: "Check", Status: 204},
+	})
+
+	raw, err := json.Marshal(doc)
+	assert.NoError(t, err)
+
+	var parsed struct {
+		OpenAPI string                                       `json:"openapi"`
+		Paths   map[string]map[string]map[string]interface{} `json:"paths"`
+		Comps   struct {
+			Schemas map[string]struct {
+				Properties map[string]map[string]interface{} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	assert.NoError(t, json.Unmarshal(raw, &parsed))
+
+	assert.Equal(t, Version, parsed.OpenAPI)
+	assert.Contains(t, parsed.Paths["/v1/login"]["post"], "requestBody")
+	assert.Contains(t, parsed.Paths["/v1/auth"]["get"]["responses"], "204")
+
+	login := parsed.Comps.Schemas["credentials"].Properties
+	assert.Equal(t, map[string]interface{}{"type": "string"}, login["user_name"])
+	assert.Equal(t, map[string]interface{}{"type": "integer", "format": "int64"}, login["Untagged"])
+	assert.NotContains(t, login, "Internal")
+
+	token := parsed.Comps.Schemas["tokens"].Properties
+	assert.Equal(t, "array", token["scopes"]["type"])
+	assert.Equal(t, "object", token["extra"]["type"])
+	assert.Equal(t, "#/components/schemas/tokens", token["next"]["$ref"])
+}
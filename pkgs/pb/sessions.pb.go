@@ -0,0 +1,2537 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: sessions.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Empty struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Empty) Reset() {
+	*x = Empty{}
+	mi := &file_sessions_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Empty) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Empty) ProtoMessage() {}
+
+func (x *Empty) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Empty.ProtoReflect.Descriptor instead.
+func (*Empty) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{0}
+}
+
+type LoginRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	UserName string                 `protobuf:"bytes,1,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"`
+	Password string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	ClientId string                 `protobuf:"bytes,3,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	// device_token identifies a remembered device, so that the user may skip MFA.
+	DeviceToken   string `protobuf:"bytes,4,opt,name=device_token,json=deviceToken,proto3" json:"device_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LoginRequest) Reset() {
+	*x = LoginRequest{}
+	mi := &file_sessions_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoginRequest) ProtoMessage() {}
+
+func (x *LoginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoginRequest.ProtoReflect.Descriptor instead.
+func (*LoginRequest) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *LoginRequest) GetUserName() string {
+	if x != nil {
+		return x.UserName
+	}
+	return ""
+}
+
+func (x *LoginRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *LoginRequest) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *LoginRequest) GetDeviceToken() string {
+	if x != nil {
+		return x.DeviceToken
+	}
+	return ""
+}
+
+type LoginResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Result:
+	//
+	//	*LoginResponse_Tokens
+	//	*LoginResponse_Challenge
+	Result        isLoginResponse_Result `protobuf_oneof:"result"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LoginResponse) Reset() {
+	*x = LoginResponse{}
+	mi := &file_sessions_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoginResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoginResponse) ProtoMessage() {}
+
+func (x *LoginResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoginResponse.ProtoReflect.Descriptor instead.
+func (*LoginResponse) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *LoginResponse) GetResult() isLoginResponse_Result {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *LoginResponse) GetTokens() *Tokens {
+	if x != nil {
+		if x, ok := x.Result.(*LoginResponse_Tokens); ok {
+			return x.Tokens
+		}
+	}
+	return nil
+}
+
+func (x *LoginResponse) GetChallenge() *MFAChallenge {
+	if x != nil {
+		if x, ok := x.Result.(*LoginResponse_Challenge); ok {
+			return x.Challenge
+		}
+	}
+	return nil
+}
+
+type isLoginResponse_Result interface {
+	isLoginResponse_Result()
+}
+
+type LoginResponse_Tokens struct {
+	Tokens *Tokens `protobuf:"bytes,1,opt,name=tokens,proto3,oneof"`
+}
+
+type LoginResponse_Challenge struct {
+	Challenge *MFAChallenge `protobuf:"bytes,2,opt,name=challenge,proto3,oneof"`
+}
+
+func (*LoginResponse_Tokens) isLoginResponse_Result() {}
+
+func (*LoginResponse_Challenge) isLoginResponse_Result() {}
+
+type MFAChallenge struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ChallengeToken string                 `protobuf:"bytes,1,opt,name=challenge_token,json=challengeToken,proto3" json:"challenge_token,omitempty"`
+	Type           string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	ExpirationDate int64                  `protobuf:"varint,3,opt,name=expiration_date,json=expirationDate,proto3" json:"expiration_date,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *MFAChallenge) Reset() {
+	*x = MFAChallenge{}
+	mi := &file_sessions_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MFAChallenge) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MFAChallenge) ProtoMessage() {}
+
+func (x *MFAChallenge) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MFAChallenge.ProtoReflect.Descriptor instead.
+func (*MFAChallenge) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *MFAChallenge) GetChallengeToken() string {
+	if x != nil {
+		return x.ChallengeToken
+	}
+	return ""
+}
+
+func (x *MFAChallenge) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *MFAChallenge) GetExpirationDate() int64 {
+	if x != nil {
+		return x.ExpirationDate
+	}
+	return 0
+}
+
+type LoginMFARequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ChallengeToken string                 `protobuf:"bytes,1,opt,name=challenge_token,json=challengeToken,proto3" json:"challenge_token,omitempty"`
+	Code           string                 `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`
+	RememberDevice bool                   `protobuf:"varint,3,opt,name=remember_device,json=rememberDevice,proto3" json:"remember_device,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *LoginMFARequest) Reset() {
+	*x = LoginMFARequest{}
+	mi := &file_sessions_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoginMFARequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoginMFARequest) ProtoMessage() {}
+
+func (x *LoginMFARequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoginMFARequest.ProtoReflect.Descriptor instead.
+func (*LoginMFARequest) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *LoginMFARequest) GetChallengeToken() string {
+	if x != nil {
+		return x.ChallengeToken
+	}
+	return ""
+}
+
+func (x *LoginMFARequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *LoginMFARequest) GetRememberDevice() bool {
+	if x != nil {
+		return x.RememberDevice
+	}
+	return false
+}
+
+type Tokens struct {
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	AccessToken           string                 `protobuf:"bytes,1,opt,name=access_token,json=accessToken,proto3" json:"access_token,omitempty"`
+	Type                  string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	ExpirationDate        int64                  `protobuf:"varint,3,opt,name=expiration_date,json=expirationDate,proto3" json:"expiration_date,omitempty"`
+	RefreshToken          string                 `protobuf:"bytes,4,opt,name=refresh_token,json=refreshToken,proto3" json:"refresh_token,omitempty"`
+	RefreshExpirationDate int64                  `protobuf:"varint,5,opt,name=refresh_expiration_date,json=refreshExpirationDate,proto3" json:"refresh_expiration_date,omitempty"`
+	DeviceToken           string                 `protobuf:"bytes,6,opt,name=device_token,json=deviceToken,proto3" json:"device_token,omitempty"`
+	DeviceExpirationDate  int64                  `protobuf:"varint,7,opt,name=device_expiration_date,json=deviceExpirationDate,proto3" json:"device_expiration_date,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *Tokens) Reset() {
+	*x = Tokens{}
+	mi := &file_sessions_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tokens) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tokens) ProtoMessage() {}
+
+func (x *Tokens) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tokens.ProtoReflect.Descriptor instead.
+func (*Tokens) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Tokens) GetAccessToken() string {
+	if x != nil {
+		return x.AccessToken
+	}
+	return ""
+}
+
+func (x *Tokens) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Tokens) GetExpirationDate() int64 {
+	if x != nil {
+		return x.ExpirationDate
+	}
+	return 0
+}
+
+func (x *Tokens) GetRefreshToken() string {
+	if x != nil {
+		return x.RefreshToken
+	}
+	return ""
+}
+
+func (x *Tokens) GetRefreshExpirationDate() int64 {
+	if x != nil {
+		return x.RefreshExpirationDate
+	}
+	return 0
+}
+
+func (x *Tokens) GetDeviceToken() string {
+	if x != nil {
+		return x.DeviceToken
+	}
+	return ""
+}
+
+func (x *Tokens) GetDeviceExpirationDate() int64 {
+	if x != nil {
+		return x.DeviceExpirationDate
+	}
+	return 0
+}
+
+type RefreshRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RefreshToken  string                 `protobuf:"bytes,1,opt,name=refresh_token,json=refreshToken,proto3" json:"refresh_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RefreshRequest) Reset() {
+	*x = RefreshRequest{}
+	mi := &file_sessions_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefreshRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefreshRequest) ProtoMessage() {}
+
+func (x *RefreshRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefreshRequest.ProtoReflect.Descriptor instead.
+func (*RefreshRequest) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RefreshRequest) GetRefreshToken() string {
+	if x != nil {
+		return x.RefreshToken
+	}
+	return ""
+}
+
+type LogoutRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RefreshToken  string                 `protobuf:"bytes,1,opt,name=refresh_token,json=refreshToken,proto3" json:"refresh_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogoutRequest) Reset() {
+	*x = LogoutRequest{}
+	mi := &file_sessions_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogoutRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogoutRequest) ProtoMessage() {}
+
+func (x *LogoutRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogoutRequest.ProtoReflect.Descriptor instead.
+func (*LogoutRequest) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *LogoutRequest) GetRefreshToken() string {
+	if x != nil {
+		return x.RefreshToken
+	}
+	return ""
+}
+
+type CheckTokenRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccessToken   string                 `protobuf:"bytes,1,opt,name=access_token,json=accessToken,proto3" json:"access_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckTokenRequest) Reset() {
+	*x = CheckTokenRequest{}
+	mi := &file_sessions_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckTokenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckTokenRequest) ProtoMessage() {}
+
+func (x *CheckTokenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckTokenRequest.ProtoReflect.Descriptor instead.
+func (*CheckTokenRequest) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CheckTokenRequest) GetAccessToken() string {
+	if x != nil {
+		return x.AccessToken
+	}
+	return ""
+}
+
+type TokenClaims struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	AccessToken    string                 `protobuf:"bytes,1,opt,name=access_token,json=accessToken,proto3" json:"access_token,omitempty"`
+	Sub            string                 `protobuf:"bytes,2,opt,name=sub,proto3" json:"sub,omitempty"`
+	Mask           int64                  `protobuf:"varint,3,opt,name=mask,proto3" json:"mask,omitempty"`
+	Permissions    []string               `protobuf:"bytes,4,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	ExpirationDate int64                  `protobuf:"varint,5,opt,name=expiration_date,json=expirationDate,proto3" json:"expiration_date,omitempty"`
+	SessionId      string                 `protobuf:"bytes,6,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *TokenClaims) Reset() {
+	*x = TokenClaims{}
+	mi := &file_sessions_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TokenClaims) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TokenClaims) ProtoMessage() {}
+
+func (x *TokenClaims) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TokenClaims.ProtoReflect.Descriptor instead.
+func (*TokenClaims) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *TokenClaims) GetAccessToken() string {
+	if x != nil {
+		return x.AccessToken
+	}
+	return ""
+}
+
+func (x *TokenClaims) GetSub() string {
+	if x != nil {
+		return x.Sub
+	}
+	return ""
+}
+
+func (x *TokenClaims) GetMask() int64 {
+	if x != nil {
+		return x.Mask
+	}
+	return 0
+}
+
+func (x *TokenClaims) GetPermissions() []string {
+	if x != nil {
+		return x.Permissions
+	}
+	return nil
+}
+
+func (x *TokenClaims) GetExpirationDate() int64 {
+	if x != nil {
+		return x.ExpirationDate
+	}
+	return 0
+}
+
+func (x *TokenClaims) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+type CheckTokensRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccessTokens  []string               `protobuf:"bytes,1,rep,name=access_tokens,json=accessTokens,proto3" json:"access_tokens,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckTokensRequest) Reset() {
+	*x = CheckTokensRequest{}
+	mi := &file_sessions_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckTokensRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckTokensRequest) ProtoMessage() {}
+
+func (x *CheckTokensRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckTokensRequest.ProtoReflect.Descriptor instead.
+func (*CheckTokensRequest) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *CheckTokensRequest) GetAccessTokens() []string {
+	if x != nil {
+		return x.AccessTokens
+	}
+	return nil
+}
+
+type TokenVerdict struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Valid bool                   `protobuf:"varint,1,opt,name=valid,proto3" json:"valid,omitempty"`
+	// reason is the problem code of an invalid token, e.g. "expired_access_token".
+	Reason string       `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	Claims *TokenClaims `protobuf:"bytes,3,opt,name=claims,proto3" json:"claims,omitempty"`
+	// detail is the human readable title of the problem.
+	Detail        string `protobuf:"bytes,4,opt,name=detail,proto3" json:"detail,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TokenVerdict) Reset() {
+	*x = TokenVerdict{}
+	mi := &file_sessions_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TokenVerdict) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TokenVerdict) ProtoMessage() {}
+
+func (x *TokenVerdict) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TokenVerdict.ProtoReflect.Descriptor instead.
+func (*TokenVerdict) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *TokenVerdict) GetValid() bool {
+	if x != nil {
+		return x.Valid
+	}
+	return false
+}
+
+func (x *TokenVerdict) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *TokenVerdict) GetClaims() *TokenClaims {
+	if x != nil {
+		return x.Claims
+	}
+	return nil
+}
+
+func (x *TokenVerdict) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
+type CheckTokensResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Verdicts      []*TokenVerdict        `protobuf:"bytes,1,rep,name=verdicts,proto3" json:"verdicts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckTokensResponse) Reset() {
+	*x = CheckTokensResponse{}
+	mi := &file_sessions_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckTokensResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckTokensResponse) ProtoMessage() {}
+
+func (x *CheckTokensResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckTokensResponse.ProtoReflect.Descriptor instead.
+func (*CheckTokensResponse) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *CheckTokensResponse) GetVerdicts() []*TokenVerdict {
+	if x != nil {
+		return x.Verdicts
+	}
+	return nil
+}
+
+type AuthorizeRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	AccessToken string                 `protobuf:"bytes,1,opt,name=access_token,json=accessToken,proto3" json:"access_token,omitempty"`
+	Permissions []string               `protobuf:"bytes,2,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	// mode is either "all", the default, or "any".
+	Mode          string `protobuf:"bytes,3,opt,name=mode,proto3" json:"mode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuthorizeRequest) Reset() {
+	*x = AuthorizeRequest{}
+	mi := &file_sessions_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuthorizeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuthorizeRequest) ProtoMessage() {}
+
+func (x *AuthorizeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuthorizeRequest.ProtoReflect.Descriptor instead.
+func (*AuthorizeRequest) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *AuthorizeRequest) GetAccessToken() string {
+	if x != nil {
+		return x.AccessToken
+	}
+	return ""
+}
+
+func (x *AuthorizeRequest) GetPermissions() []string {
+	if x != nil {
+		return x.Permissions
+	}
+	return nil
+}
+
+func (x *AuthorizeRequest) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+type DecideRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccessToken   string                 `protobuf:"bytes,1,opt,name=access_token,json=accessToken,proto3" json:"access_token,omitempty"`
+	Action        string                 `protobuf:"bytes,2,opt,name=action,proto3" json:"action,omitempty"`
+	Resource      string                 `protobuf:"bytes,3,opt,name=resource,proto3" json:"resource,omitempty"`
+	Ip            string                 `protobuf:"bytes,4,opt,name=ip,proto3" json:"ip,omitempty"`
+	Context       map[string]string      `protobuf:"bytes,5,rep,name=context,proto3" json:"context,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DecideRequest) Reset() {
+	*x = DecideRequest{}
+	mi := &file_sessions_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DecideRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DecideRequest) ProtoMessage() {}
+
+func (x *DecideRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DecideRequest.ProtoReflect.Descriptor instead.
+func (*DecideRequest) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *DecideRequest) GetAccessToken() string {
+	if x != nil {
+		return x.AccessToken
+	}
+	return ""
+}
+
+func (x *DecideRequest) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *DecideRequest) GetResource() string {
+	if x != nil {
+		return x.Resource
+	}
+	return ""
+}
+
+func (x *DecideRequest) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+func (x *DecideRequest) GetContext() map[string]string {
+	if x != nil {
+		return x.Context
+	}
+	return nil
+}
+
+type Decision struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Allowed       bool                   `protobuf:"varint,1,opt,name=allowed,proto3" json:"allowed,omitempty"`
+	Policy        string                 `protobuf:"bytes,2,opt,name=policy,proto3" json:"policy,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Decision) Reset() {
+	*x = Decision{}
+	mi := &file_sessions_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Decision) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Decision) ProtoMessage() {}
+
+func (x *Decision) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Decision.ProtoReflect.Descriptor instead.
+func (*Decision) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Decision) GetAllowed() bool {
+	if x != nil {
+		return x.Allowed
+	}
+	return false
+}
+
+func (x *Decision) GetPolicy() string {
+	if x != nil {
+		return x.Policy
+	}
+	return ""
+}
+
+type EmailLoginRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Email         string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EmailLoginRequest) Reset() {
+	*x = EmailLoginRequest{}
+	mi := &file_sessions_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmailLoginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmailLoginRequest) ProtoMessage() {}
+
+func (x *EmailLoginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmailLoginRequest.ProtoReflect.Descriptor instead.
+func (*EmailLoginRequest) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *EmailLoginRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+type EmailLoginRedeemRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Email string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	// code is either the one-time code or the token of the magic link.
+	Code string `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`
+	// device_token identifies a remembered device, so that the user may skip MFA.
+	DeviceToken   string `protobuf:"bytes,3,opt,name=device_token,json=deviceToken,proto3" json:"device_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EmailLoginRedeemRequest) Reset() {
+	*x = EmailLoginRedeemRequest{}
+	mi := &file_sessions_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmailLoginRedeemRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmailLoginRedeemRequest) ProtoMessage() {}
+
+func (x *EmailLoginRedeemRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmailLoginRedeemRequest.ProtoReflect.Descriptor instead.
+func (*EmailLoginRedeemRequest) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *EmailLoginRedeemRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *EmailLoginRedeemRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *EmailLoginRedeemRequest) GetDeviceToken() string {
+	if x != nil {
+		return x.DeviceToken
+	}
+	return ""
+}
+
+type EnrollTOTPRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccessToken   string                 `protobuf:"bytes,1,opt,name=access_token,json=accessToken,proto3" json:"access_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnrollTOTPRequest) Reset() {
+	*x = EnrollTOTPRequest{}
+	mi := &file_sessions_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnrollTOTPRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnrollTOTPRequest) ProtoMessage() {}
+
+func (x *EnrollTOTPRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnrollTOTPRequest.ProtoReflect.Descriptor instead.
+func (*EnrollTOTPRequest) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *EnrollTOTPRequest) GetAccessToken() string {
+	if x != nil {
+		return x.AccessToken
+	}
+	return ""
+}
+
+type TOTPEnrollment struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Secret string                 `protobuf:"bytes,1,opt,name=secret,proto3" json:"secret,omitempty"`
+	// uri is an otpauth:// URI to be shown as a QR code.
+	Uri           string `protobuf:"bytes,2,opt,name=uri,proto3" json:"uri,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TOTPEnrollment) Reset() {
+	*x = TOTPEnrollment{}
+	mi := &file_sessions_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TOTPEnrollment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TOTPEnrollment) ProtoMessage() {}
+
+func (x *TOTPEnrollment) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TOTPEnrollment.ProtoReflect.Descriptor instead.
+func (*TOTPEnrollment) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *TOTPEnrollment) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+func (x *TOTPEnrollment) GetUri() string {
+	if x != nil {
+		return x.Uri
+	}
+	return ""
+}
+
+type ConfirmTOTPRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccessToken   string                 `protobuf:"bytes,1,opt,name=access_token,json=accessToken,proto3" json:"access_token,omitempty"`
+	Code          string                 `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConfirmTOTPRequest) Reset() {
+	*x = ConfirmTOTPRequest{}
+	mi := &file_sessions_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfirmTOTPRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfirmTOTPRequest) ProtoMessage() {}
+
+func (x *ConfirmTOTPRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfirmTOTPRequest.ProtoReflect.Descriptor instead.
+func (*ConfirmTOTPRequest) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ConfirmTOTPRequest) GetAccessToken() string {
+	if x != nil {
+		return x.AccessToken
+	}
+	return ""
+}
+
+func (x *ConfirmTOTPRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+type RecoveryCodesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccessToken   string                 `protobuf:"bytes,1,opt,name=access_token,json=accessToken,proto3" json:"access_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecoveryCodesRequest) Reset() {
+	*x = RecoveryCodesRequest{}
+	mi := &file_sessions_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecoveryCodesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecoveryCodesRequest) ProtoMessage() {}
+
+func (x *RecoveryCodesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecoveryCodesRequest.ProtoReflect.Descriptor instead.
+func (*RecoveryCodesRequest) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *RecoveryCodesRequest) GetAccessToken() string {
+	if x != nil {
+		return x.AccessToken
+	}
+	return ""
+}
+
+type RecoveryCodes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Codes         []string               `protobuf:"bytes,1,rep,name=codes,proto3" json:"codes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecoveryCodes) Reset() {
+	*x = RecoveryCodes{}
+	mi := &file_sessions_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecoveryCodes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecoveryCodes) ProtoMessage() {}
+
+func (x *RecoveryCodes) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecoveryCodes.ProtoReflect.Descriptor instead.
+func (*RecoveryCodes) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *RecoveryCodes) GetCodes() []string {
+	if x != nil {
+		return x.Codes
+	}
+	return nil
+}
+
+type ResetMFARequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccessToken   string                 `protobuf:"bytes,1,opt,name=access_token,json=accessToken,proto3" json:"access_token,omitempty"`
+	UserName      string                 `protobuf:"bytes,2,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"`
+	RecoveryCodes bool                   `protobuf:"varint,3,opt,name=recovery_codes,json=recoveryCodes,proto3" json:"recovery_codes,omitempty"`
+	Devices       bool                   `protobuf:"varint,4,opt,name=devices,proto3" json:"devices,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResetMFARequest) Reset() {
+	*x = ResetMFARequest{}
+	mi := &file_sessions_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResetMFARequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetMFARequest) ProtoMessage() {}
+
+func (x *ResetMFARequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetMFARequest.ProtoReflect.Descriptor instead.
+func (*ResetMFARequest) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ResetMFARequest) GetAccessToken() string {
+	if x != nil {
+		return x.AccessToken
+	}
+	return ""
+}
+
+func (x *ResetMFARequest) GetUserName() string {
+	if x != nil {
+		return x.UserName
+	}
+	return ""
+}
+
+func (x *ResetMFARequest) GetRecoveryCodes() bool {
+	if x != nil {
+		return x.RecoveryCodes
+	}
+	return false
+}
+
+func (x *ResetMFARequest) GetDevices() bool {
+	if x != nil {
+		return x.Devices
+	}
+	return false
+}
+
+type UnlockLoginRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccessToken   string                 `protobuf:"bytes,1,opt,name=access_token,json=accessToken,proto3" json:"access_token,omitempty"`
+	UserName      string                 `protobuf:"bytes,2,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"`
+	Ip            string                 `protobuf:"bytes,3,opt,name=ip,proto3" json:"ip,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnlockLoginRequest) Reset() {
+	*x = UnlockLoginRequest{}
+	mi := &file_sessions_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnlockLoginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnlockLoginRequest) ProtoMessage() {}
+
+func (x *UnlockLoginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnlockLoginRequest.ProtoReflect.Descriptor instead.
+func (*UnlockLoginRequest) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *UnlockLoginRequest) GetAccessToken() string {
+	if x != nil {
+		return x.AccessToken
+	}
+	return ""
+}
+
+func (x *UnlockLoginRequest) GetUserName() string {
+	if x != nil {
+		return x.UserName
+	}
+	return ""
+}
+
+func (x *UnlockLoginRequest) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+type WebAuthnRegistrationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccessToken   string                 `protobuf:"bytes,1,opt,name=access_token,json=accessToken,proto3" json:"access_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WebAuthnRegistrationRequest) Reset() {
+	*x = WebAuthnRegistrationRequest{}
+	mi := &file_sessions_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WebAuthnRegistrationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WebAuthnRegistrationRequest) ProtoMessage() {}
+
+func (x *WebAuthnRegistrationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WebAuthnRegistrationRequest.ProtoReflect.Descriptor instead.
+func (*WebAuthnRegistrationRequest) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *WebAuthnRegistrationRequest) GetAccessToken() string {
+	if x != nil {
+		return x.AccessToken
+	}
+	return ""
+}
+
+type WebAuthnRegistrationFinishRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccessToken   string                 `protobuf:"bytes,1,opt,name=access_token,json=accessToken,proto3" json:"access_token,omitempty"`
+	Credential    *WebAuthnCredential    `protobuf:"bytes,2,opt,name=credential,proto3" json:"credential,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WebAuthnRegistrationFinishRequest) Reset() {
+	*x = WebAuthnRegistrationFinishRequest{}
+	mi := &file_sessions_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WebAuthnRegistrationFinishRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WebAuthnRegistrationFinishRequest) ProtoMessage() {}
+
+func (x *WebAuthnRegistrationFinishRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WebAuthnRegistrationFinishRequest.ProtoReflect.Descriptor instead.
+func (*WebAuthnRegistrationFinishRequest) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *WebAuthnRegistrationFinishRequest) GetAccessToken() string {
+	if x != nil {
+		return x.AccessToken
+	}
+	return ""
+}
+
+func (x *WebAuthnRegistrationFinishRequest) GetCredential() *WebAuthnCredential {
+	if x != nil {
+		return x.Credential
+	}
+	return nil
+}
+
+type WebAuthnLoginRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// user_name may be empty to let the authenticator offer any discoverable credential.
+	UserName      string `protobuf:"bytes,1,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WebAuthnLoginRequest) Reset() {
+	*x = WebAuthnLoginRequest{}
+	mi := &file_sessions_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WebAuthnLoginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WebAuthnLoginRequest) ProtoMessage() {}
+
+func (x *WebAuthnLoginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WebAuthnLoginRequest.ProtoReflect.Descriptor instead.
+func (*WebAuthnLoginRequest) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *WebAuthnLoginRequest) GetUserName() string {
+	if x != nil {
+		return x.UserName
+	}
+	return ""
+}
+
+type WebAuthnLoginFinishRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Credential    *WebAuthnCredential    `protobuf:"bytes,1,opt,name=credential,proto3" json:"credential,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WebAuthnLoginFinishRequest) Reset() {
+	*x = WebAuthnLoginFinishRequest{}
+	mi := &file_sessions_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WebAuthnLoginFinishRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WebAuthnLoginFinishRequest) ProtoMessage() {}
+
+func (x *WebAuthnLoginFinishRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WebAuthnLoginFinishRequest.ProtoReflect.Descriptor instead.
+func (*WebAuthnLoginFinishRequest) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *WebAuthnLoginFinishRequest) GetCredential() *WebAuthnCredential {
+	if x != nil {
+		return x.Credential
+	}
+	return nil
+}
+
+type WebAuthnCredential struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Response      *WebAuthnResponse      `protobuf:"bytes,3,opt,name=response,proto3" json:"response,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WebAuthnCredential) Reset() {
+	*x = WebAuthnCredential{}
+	mi := &file_sessions_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WebAuthnCredential) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WebAuthnCredential) ProtoMessage() {}
+
+func (x *WebAuthnCredential) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WebAuthnCredential.ProtoReflect.Descriptor instead.
+func (*WebAuthnCredential) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *WebAuthnCredential) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *WebAuthnCredential) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *WebAuthnCredential) GetResponse() *WebAuthnResponse {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+type WebAuthnResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	ClientDataJson    string                 `protobuf:"bytes,1,opt,name=client_data_json,json=clientDataJson,proto3" json:"client_data_json,omitempty"`
+	AttestationObject string                 `protobuf:"bytes,2,opt,name=attestation_object,json=attestationObject,proto3" json:"attestation_object,omitempty"`
+	AuthenticatorData string                 `protobuf:"bytes,3,opt,name=authenticator_data,json=authenticatorData,proto3" json:"authenticator_data,omitempty"`
+	Signature         string                 `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`
+	UserHandle        string                 `protobuf:"bytes,5,opt,name=user_handle,json=userHandle,proto3" json:"user_handle,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *WebAuthnResponse) Reset() {
+	*x = WebAuthnResponse{}
+	mi := &file_sessions_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WebAuthnResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WebAuthnResponse) ProtoMessage() {}
+
+func (x *WebAuthnResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WebAuthnResponse.ProtoReflect.Descriptor instead.
+func (*WebAuthnResponse) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *WebAuthnResponse) GetClientDataJson() string {
+	if x != nil {
+		return x.ClientDataJson
+	}
+	return ""
+}
+
+func (x *WebAuthnResponse) GetAttestationObject() string {
+	if x != nil {
+		return x.AttestationObject
+	}
+	return ""
+}
+
+func (x *WebAuthnResponse) GetAuthenticatorData() string {
+	if x != nil {
+		return x.AuthenticatorData
+	}
+	return ""
+}
+
+func (x *WebAuthnResponse) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *WebAuthnResponse) GetUserHandle() string {
+	if x != nil {
+		return x.UserHandle
+	}
+	return ""
+}
+
+type WebAuthnCreationOptions struct {
+	state                  protoimpl.MessageState          `protogen:"open.v1"`
+	Challenge              string                          `protobuf:"bytes,1,opt,name=challenge,proto3" json:"challenge,omitempty"`
+	Rp                     *WebAuthnEntity                 `protobuf:"bytes,2,opt,name=rp,proto3" json:"rp,omitempty"`
+	User                   *WebAuthnUser                   `protobuf:"bytes,3,opt,name=user,proto3" json:"user,omitempty"`
+	PubKeyCredParams       []*WebAuthnCredentialParameter  `protobuf:"bytes,4,rep,name=pub_key_cred_params,json=pubKeyCredParams,proto3" json:"pub_key_cred_params,omitempty"`
+	Timeout                int64                           `protobuf:"varint,5,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	Attestation            string                          `protobuf:"bytes,6,opt,name=attestation,proto3" json:"attestation,omitempty"`
+	ExcludeCredentials     []*WebAuthnCredentialDescriptor `protobuf:"bytes,7,rep,name=exclude_credentials,json=excludeCredentials,proto3" json:"exclude_credentials,omitempty"`
+	AuthenticatorSelection *WebAuthnAuthenticatorSelection `protobuf:"bytes,8,opt,name=authenticator_selection,json=authenticatorSelection,proto3" json:"authenticator_selection,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *WebAuthnCreationOptions) Reset() {
+	*x = WebAuthnCreationOptions{}
+	mi := &file_sessions_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WebAuthnCreationOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WebAuthnCreationOptions) ProtoMessage() {}
+
+func (x *WebAuthnCreationOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WebAuthnCreationOptions.ProtoReflect.Descriptor instead.
+func (*WebAuthnCreationOptions) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *WebAuthnCreationOptions) GetChallenge() string {
+	if x != nil {
+		return x.Challenge
+	}
+	return ""
+}
+
+func (x *WebAuthnCreationOptions) GetRp() *WebAuthnEntity {
+	if x != nil {
+		return x.Rp
+	}
+	return nil
+}
+
+func (x *WebAuthnCreationOptions) GetUser() *WebAuthnUser {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *WebAuthnCreationOptions) GetPubKeyCredParams() []*WebAuthnCredentialParameter {
+	if x != nil {
+		return x.PubKeyCredParams
+	}
+	return nil
+}
+
+func (x *WebAuthnCreationOptions) GetTimeout() int64 {
+	if x != nil {
+		return x.Timeout
+	}
+	return 0
+}
+
+func (x *WebAuthnCreationOptions) GetAttestation() string {
+	if x != nil {
+		return x.Attestation
+	}
+	return ""
+}
+
+func (x *WebAuthnCreationOptions) GetExcludeCredentials() []*WebAuthnCredentialDescriptor {
+	if x != nil {
+		return x.ExcludeCredentials
+	}
+	return nil
+}
+
+func (x *WebAuthnCreationOptions) GetAuthenticatorSelection() *WebAuthnAuthenticatorSelection {
+	if x != nil {
+		return x.AuthenticatorSelection
+	}
+	return nil
+}
+
+type WebAuthnRequestOptions struct {
+	state            protoimpl.MessageState          `protogen:"open.v1"`
+	Challenge        string                          `protobuf:"bytes,1,opt,name=challenge,proto3" json:"challenge,omitempty"`
+	Timeout          int64                           `protobuf:"varint,2,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	RpId             string                          `protobuf:"bytes,3,opt,name=rp_id,json=rpId,proto3" json:"rp_id,omitempty"`
+	AllowCredentials []*WebAuthnCredentialDescriptor `protobuf:"bytes,4,rep,name=allow_credentials,json=allowCredentials,proto3" json:"allow_credentials,omitempty"`
+	UserVerification string                          `protobuf:"bytes,5,opt,name=user_verification,json=userVerification,proto3" json:"user_verification,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *WebAuthnRequestOptions) Reset() {
+	*x = WebAuthnRequestOptions{}
+	mi := &file_sessions_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WebAuthnRequestOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WebAuthnRequestOptions) ProtoMessage() {}
+
+func (x *WebAuthnRequestOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WebAuthnRequestOptions.ProtoReflect.Descriptor instead.
+func (*WebAuthnRequestOptions) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *WebAuthnRequestOptions) GetChallenge() string {
+	if x != nil {
+		return x.Challenge
+	}
+	return ""
+}
+
+func (x *WebAuthnRequestOptions) GetTimeout() int64 {
+	if x != nil {
+		return x.Timeout
+	}
+	return 0
+}
+
+func (x *WebAuthnRequestOptions) GetRpId() string {
+	if x != nil {
+		return x.RpId
+	}
+	return ""
+}
+
+func (x *WebAuthnRequestOptions) GetAllowCredentials() []*WebAuthnCredentialDescriptor {
+	if x != nil {
+		return x.AllowCredentials
+	}
+	return nil
+}
+
+func (x *WebAuthnRequestOptions) GetUserVerification() string {
+	if x != nil {
+		return x.UserVerification
+	}
+	return ""
+}
+
+type WebAuthnEntity struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WebAuthnEntity) Reset() {
+	*x = WebAuthnEntity{}
+	mi := &file_sessions_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WebAuthnEntity) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WebAuthnEntity) ProtoMessage() {}
+
+func (x *WebAuthnEntity) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WebAuthnEntity.ProtoReflect.Descriptor instead.
+func (*WebAuthnEntity) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *WebAuthnEntity) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *WebAuthnEntity) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type WebAuthnUser struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	DisplayName   string                 `protobuf:"bytes,3,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WebAuthnUser) Reset() {
+	*x = WebAuthnUser{}
+	mi := &file_sessions_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WebAuthnUser) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WebAuthnUser) ProtoMessage() {}
+
+func (x *WebAuthnUser) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WebAuthnUser.ProtoReflect.Descriptor instead.
+func (*WebAuthnUser) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *WebAuthnUser) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *WebAuthnUser) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *WebAuthnUser) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
+}
+
+type WebAuthnCredentialParameter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Alg           int64                  `protobuf:"varint,2,opt,name=alg,proto3" json:"alg,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WebAuthnCredentialParameter) Reset() {
+	*x = WebAuthnCredentialParameter{}
+	mi := &file_sessions_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WebAuthnCredentialParameter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WebAuthnCredentialParameter) ProtoMessage() {}
+
+func (x *WebAuthnCredentialParameter) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WebAuthnCredentialParameter.ProtoReflect.Descriptor instead.
+func (*WebAuthnCredentialParameter) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *WebAuthnCredentialParameter) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *WebAuthnCredentialParameter) GetAlg() int64 {
+	if x != nil {
+		return x.Alg
+	}
+	return 0
+}
+
+type WebAuthnCredentialDescriptor struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WebAuthnCredentialDescriptor) Reset() {
+	*x = WebAuthnCredentialDescriptor{}
+	mi := &file_sessions_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WebAuthnCredentialDescriptor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WebAuthnCredentialDescriptor) ProtoMessage() {}
+
+func (x *WebAuthnCredentialDescriptor) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WebAuthnCredentialDescriptor.ProtoReflect.Descriptor instead.
+func (*WebAuthnCredentialDescriptor) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *WebAuthnCredentialDescriptor) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *WebAuthnCredentialDescriptor) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type WebAuthnAuthenticatorSelection struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	ResidentKey      string                 `protobuf:"bytes,1,opt,name=resident_key,json=residentKey,proto3" json:"resident_key,omitempty"`
+	UserVerification string                 `protobuf:"bytes,2,opt,name=user_verification,json=userVerification,proto3" json:"user_verification,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *WebAuthnAuthenticatorSelection) Reset() {
+	*x = WebAuthnAuthenticatorSelection{}
+	mi := &file_sessions_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WebAuthnAuthenticatorSelection) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WebAuthnAuthenticatorSelection) ProtoMessage() {}
+
+func (x *WebAuthnAuthenticatorSelection) ProtoReflect() protoreflect.Message {
+	mi := &file_sessions_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WebAuthnAuthenticatorSelection.ProtoReflect.Descriptor instead.
+func (*WebAuthnAuthenticatorSelection) Descriptor() ([]byte, []int) {
+	return file_sessions_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *WebAuthnAuthenticatorSelection) GetResidentKey() string {
+	if x != nil {
+		return x.ResidentKey
+	}
+	return ""
+}
+
+func (x *WebAuthnAuthenticatorSelection) GetUserVerification() string {
+	if x != nil {
+		return x.UserVerification
+	}
+	return ""
+}
+
+var File_sessions_proto protoreflect.FileDescriptor
+
+const file_sessions_proto_rawDesc = "" +
+	"\n" +
+	"\x0esessions.proto\x12\vsessions.v1\"\a\n" +
+	"\x05Empty\"\x87\x01\n" +
+	"\fLoginRequest\x12\x1b\n" +
+	"\tuser_name\x18\x01 \x01(\tR\buserName\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\x12\x1b\n" +
+	"\tclient_id\x18\x03 \x01(\tR\bclientId\x12!\n" +
+	"\fdevice_token\x18\x04 \x01(\tR\vdeviceToken\"\x83\x01\n" +
+	"\rLoginResponse\x12-\n" +
+	"\x06tokens\x18\x01 \x01(\v2\x13.sessions.v1.TokensH\x00R\x06tokens\x129\n" +
+	"\tchallenge\x18\x02 \x01(\v2\x19.sessions.v1.MFAChallengeH\x00R\tchallengeB\b\n" +
+	"\x06result\"t\n" +
+	"\fMFAChallenge\x12'\n" +
+	"\x0fchallenge_token\x18\x01 \x01(\tR\x0echallengeToken\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12'\n" +
+	"\x0fexpiration_date\x18\x03 \x01(\x03R\x0eexpirationDate\"w\n" +
+	"\x0fLoginMFARequest\x12'\n" +
+	"\x0fchallenge_token\x18\x01 \x01(\tR\x0echallengeToken\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\tR\x04code\x12'\n" +
+	"\x0fremember_device\x18\x03 \x01(\bR\x0erememberDevice\"\x9e\x02\n" +
+	"\x06Tokens\x12!\n" +
+	"\faccess_token\x18\x01 \x01(\tR\vaccessToken\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12'\n" +
+	"\x0fexpiration_date\x18\x03 \x01(\x03R\x0eexpirationDate\x12#\n" +
+	"\rrefresh_token\x18\x04 \x01(\tR\frefreshToken\x126\n" +
+	"\x17refresh_expiration_date\x18\x05 \x01(\x03R\x15refreshExpirationDate\x12!\n" +
+	"\fdevice_token\x18\x06 \x01(\tR\vdeviceToken\x124\n" +
+	"\x16device_expiration_date\x18\a \x01(\x03R\x14deviceExpirationDate\"5\n" +
+	"\x0eRefreshRequest\x12#\n" +
+	"\rrefresh_token\x18\x01 \x01(\tR\frefreshToken\"4\n" +
+	"\rLogoutRequest\x12#\n" +
+	"\rrefresh_token\x18\x01 \x01(\tR\frefreshToken\"K\n" +
+	"\x11CheckTokenRequest\x12!\n" +
+	"\faccess_token\x18\x01 \x01(\tR\vaccessTokenJ\x04\b\x02\x10\x03R\rrefresh_token\"\xd1\x01\n" +
+	"\vTokenClaims\x12!\n" +
+	"\faccess_token\x18\x01 \x01(\tR\vaccessToken\x12\x10\n" +
+	"\x03sub\x18\x02 \x01(\tR\x03sub\x12\x12\n" +
+	"\x04mask\x18\x03 \x01(\x03R\x04mask\x12 \n" +
+	"\vpermissions\x18\x04 \x03(\tR\vpermissions\x12'\n" +
+	"\x0fexpiration_date\x18\x05 \x01(\x03R\x0eexpirationDate\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x06 \x01(\tR\tsessionIdJ\x04\b\a\x10\bR\trefreshed\"9\n" +
+	"\x12CheckTokensRequest\x12#\n" +
+	"\raccess_tokens\x18\x01 \x03(\tR\faccessTokens\"\x86\x01\n" +
+	"\fTokenVerdict\x12\x14\n" +
+	"\x05valid\x18\x01 \x01(\bR\x05valid\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\x120\n" +
+	"\x06claims\x18\x03 \x01(\v2\x18.sessions.v1.TokenClaimsR\x06claims\x12\x16\n" +
+	"\x06detail\x18\x04 \x01(\tR\x06detail\"L\n" +
+	"\x13CheckTokensResponse\x125\n" +
+	"\bverdicts\x18\x01 \x03(\v2\x19.sessions.v1.TokenVerdictR\bverdicts\"k\n" +
+	"\x10AuthorizeRequest\x12!\n" +
+	"\faccess_token\x18\x01 \x01(\tR\vaccessToken\x12 \n" +
+	"\vpermissions\x18\x02 \x03(\tR\vpermissions\x12\x12\n" +
+	"\x04mode\x18\x03 \x01(\tR\x04mode\"\xf5\x01\n" +
+	"\rDecideRequest\x12!\n" +
+	"\faccess_token\x18\x01 \x01(\tR\vaccessToken\x12\x16\n" +
+	"\x06action\x18\x02 \x01(\tR\x06action\x12\x1a\n" +
+	"\bresource\x18\x03 \x01(\tR\bresource\x12\x0e\n" +
+	"\x02ip\x18\x04 \x01(\tR\x02ip\x12A\n" +
+	"\acontext\x18\x05 \x03(\v2'.sessions.v1.DecideRequest.ContextEntryR\acontext\x1a:\n" +
+	"\fContextEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"<\n" +
+	"\bDecision\x12\x18\n" +
+	"\aallowed\x18\x01 \x01(\bR\aallowed\x12\x16\n" +
+	"\x06policy\x18\x02 \x01(\tR\x06policy\")\n" +
+	"\x11EmailLoginRequest\x12\x14\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\"f\n" +
+	"\x17EmailLoginRedeemRequest\x12\x14\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\tR\x04code\x12!\n" +
+	"\fdevice_token\x18\x03 \x01(\tR\vdeviceToken\"6\n" +
+	"\x11EnrollTOTPRequest\x12!\n" +
+	"\faccess_token\x18\x01 \x01(\tR\vaccessToken\":\n" +
+	"\x0eTOTPEnrollment\x12\x16\n" +
+	"\x06secret\x18\x01 \x01(\tR\x06secret\x12\x10\n" +
+	"\x03uri\x18\x02 \x01(\tR\x03uri\"K\n" +
+	"\x12ConfirmTOTPRequest\x12!\n" +
+	"\faccess_token\x18\x01 \x01(\tR\vaccessToken\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\tR\x04code\"9\n" +
+	"\x14RecoveryCodesRequest\x12!\n" +
+	"\faccess_token\x18\x01 \x01(\tR\vaccessToken\"%\n" +
+	"\rRecoveryCodes\x12\x14\n" +
+	"\x05codes\x18\x01 \x03(\tR\x05codes\"\x92\x01\n" +
+	"\x0fResetMFARequest\x12!\n" +
+	"\faccess_token\x18\x01 \x01(\tR\vaccessToken\x12\x1b\n" +
+	"\tuser_name\x18\x02 \x01(\tR\buserName\x12%\n" +
+	"\x0erecovery_codes\x18\x03 \x01(\bR\rrecoveryCodes\x12\x18\n" +
+	"\adevices\x18\x04 \x01(\bR\adevices\"d\n" +
+	"\x12UnlockLoginRequest\x12!\n" +
+	"\faccess_token\x18\x01 \x01(\tR\vaccessToken\x12\x1b\n" +
+	"\tuser_name\x18\x02 \x01(\tR\buserName\x12\x0e\n" +
+	"\x02ip\x18\x03 \x01(\tR\x02ip\"@\n" +
+	"\x1bWebAuthnRegistrationRequest\x12!\n" +
+	"\faccess_token\x18\x01 \x01(\tR\vaccessToken\"\x87\x01\n" +
+	"!WebAuthnRegistrationFinishRequest\x12!\n" +
+	"\faccess_token\x18\x01 \x01(\tR\vaccessToken\x12?\n" +
+	"\n" +
+	"credential\x18\x02 \x01(\v2\x1f.sessions.v1.WebAuthnCredentialR\n" +
+	"credential\"3\n" +
+	"\x14WebAuthnLoginRequest\x12\x1b\n" +
+	"\tuser_name\x18\x01 \x01(\tR\buserName\"]\n" +
+	"\x1aWebAuthnLoginFinishRequest\x12?\n" +
+	"\n" +
+	"credential\x18\x01 \x01(\v2\x1f.sessions.v1.WebAuthnCredentialR\n" +
+	"credential\"s\n" +
+	"\x12WebAuthnCredential\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x129\n" +
+	"\bresponse\x18\x03 \x01(\v2\x1d.sessions.v1.WebAuthnResponseR\bresponse\"\xd9\x01\n" +
+	"\x10WebAuthnResponse\x12(\n" +
+	"\x10client_data_json\x18\x01 \x01(\tR\x0eclientDataJson\x12-\n" +
+	"\x12attestation_object\x18\x02 \x01(\tR\x11attestationObject\x12-\n" +
+	"\x12authenticator_data\x18\x03 \x01(\tR\x11authenticatorData\x12\x1c\n" +
+	"\tsignature\x18\x04 \x01(\tR\tsignature\x12\x1f\n" +
+	"\vuser_handle\x18\x05 \x01(\tR\n" +
+	"userHandle\"\xea\x03\n" +
+	"\x17WebAuthnCreationOptions\x12\x1c\n" +
+	"\tchallenge\x18\x01 \x01(\tR\tchallenge\x12+\n" +
+	"\x02rp\x18\x02 \x01(\v2\x1b.sessions.v1.WebAuthnEntityR\x02rp\x12-\n" +
+	"\x04user\x18\x03 \x01(\v2\x19.sessions.v1.WebAuthnUserR\x04user\x12W\n" +
+	"\x13pub_key_cred_params\x18\x04 \x03(\v2(.sessions.v1.WebAuthnCredentialParameterR\x10pubKeyCredParams\x12\x18\n" +
+	"\atimeout\x18\x05 \x01(\x03R\atimeout\x12 \n" +
+	"\vattestation\x18\x06 \x01(\tR\vattestation\x12Z\n" +
+	"\x13exclude_credentials\x18\a \x03(\v2).sessions.v1.WebAuthnCredentialDescriptorR\x12excludeCredentials\x12d\n" +
+	"\x17authenticator_selection\x18\b \x01(\v2+.sessions.v1.WebAuthnAuthenticatorSelectionR\x16authenticatorSelection\"\xea\x01\n" +
+	"\x16WebAuthnRequestOptions\x12\x1c\n" +
+	"\tchallenge\x18\x01 \x01(\tR\tchallenge\x12\x18\n" +
+	"\atimeout\x18\x02 \x01(\x03R\atimeout\x12\x13\n" +
+	"\x05rp_id\x18\x03 \x01(\tR\x04rpId\x12V\n" +
+	"\x11allow_credentials\x18\x04 \x03(\v2).sessions.v1.WebAuthnCredentialDescriptorR\x10allowCredentials\x12+\n" +
+	"\x11user_verification\x18\x05 \x01(\tR\x10userVerification\"4\n" +
+	"\x0eWebAuthnEntity\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\"U\n" +
+	"\fWebAuthnUser\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12!\n" +
+	"\fdisplay_name\x18\x03 \x01(\tR\vdisplayName\"C\n" +
+	"\x1bWebAuthnCredentialParameter\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x10\n" +
+	"\x03alg\x18\x02 \x01(\x03R\x03alg\"B\n" +
+	"\x1cWebAuthnCredentialDescriptor\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\"p\n" +
+	"\x1eWebAuthnAuthenticatorSelection\x12!\n" +
+	"\fresident_key\x18\x01 \x01(\tR\vresidentKey\x12+\n" +
+	"\x11user_verification\x18\x02 \x01(\tR\x10userVerification2\xa9\v\n" +
+	"\bSessions\x12>\n" +
+	"\x05Login\x12\x19.sessions.v1.LoginRequest\x1a\x1a.sessions.v1.LoginResponse\x12=\n" +
+	"\bLoginMFA\x12\x1c.sessions.v1.LoginMFARequest\x1a\x13.sessions.v1.Tokens\x12;\n" +
+	"\aRefresh\x12\x1b.sessions.v1.RefreshRequest\x1a\x13.sessions.v1.Tokens\x128\n" +
+	"\x06Logout\x12\x1a.sessions.v1.LogoutRequest\x1a\x12.sessions.v1.Empty\x12F\n" +
+	"\n" +
+	"CheckToken\x12\x1e.sessions.v1.CheckTokenRequest\x1a\x18.sessions.v1.TokenClaims\x12P\n" +
+	"\vCheckTokens\x12\x1f.sessions.v1.CheckTokensRequest\x1a .sessions.v1.CheckTokensResponse\x12D\n" +
+	"\tAuthorize\x12\x1d.sessions.v1.AuthorizeRequest\x1a\x18.sessions.v1.TokenClaims\x12;\n" +
+	"\x06Decide\x12\x1a.sessions.v1.DecideRequest\x1a\x15.sessions.v1.Decision\x12G\n" +
+	"\x11RequestEmailLogin\x12\x1e.sessions.v1.EmailLoginRequest\x1a\x12.sessions.v1.Empty\x12T\n" +
+	"\x10RedeemEmailLogin\x12$.sessions.v1.EmailLoginRedeemRequest\x1a\x1a.sessions.v1.LoginResponse\x12I\n" +
+	"\n" +
+	"EnrollTOTP\x12\x1e.sessions.v1.EnrollTOTPRequest\x1a\x1b.sessions.v1.TOTPEnrollment\x12B\n" +
+	"\vConfirmTOTP\x12\x1f.sessions.v1.ConfirmTOTPRequest\x1a\x12.sessions.v1.Empty\x12X\n" +
+	"\x17RegenerateRecoveryCodes\x12!.sessions.v1.RecoveryCodesRequest\x1a\x1a.sessions.v1.RecoveryCodes\x12k\n" +
+	"\x19BeginWebAuthnRegistration\x12(.sessions.v1.WebAuthnRegistrationRequest\x1a$.sessions.v1.WebAuthnCreationOptions\x12`\n" +
+	"\x1aFinishWebAuthnRegistration\x12..sessions.v1.WebAuthnRegistrationFinishRequest\x1a\x12.sessions.v1.Empty\x12\\\n" +
+	"\x12BeginWebAuthnLogin\x12!.sessions.v1.WebAuthnLoginRequest\x1a#.sessions.v1.WebAuthnRequestOptions\x12S\n" +
+	"\x13FinishWebAuthnLogin\x12'.sessions.v1.WebAuthnLoginFinishRequest\x1a\x13.sessions.v1.Tokens\x12<\n" +
+	"\bResetMFA\x12\x1c.sessions.v1.ResetMFARequest\x1a\x12.sessions.v1.Empty\x12B\n" +
+	"\vUnlockLogin\x12\x1f.sessions.v1.UnlockLoginRequest\x1a\x12.sessions.v1.EmptyB-Z+github.com/Soroka-EDMS/svc/sessions/pkgs/pbb\x06proto3"
+
+var (
+	file_sessions_proto_rawDescOnce sync.Once
+	file_sessions_proto_rawDescData []byte
+)
+
+func file_sessions_proto_rawDescGZIP() []byte {
+	file_sessions_proto_rawDescOnce.Do(func() {
+		file_sessions_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_sessions_proto_rawDesc), len(file_sessions_proto_rawDesc)))
+	})
+	return file_sessions_proto_rawDescData
+}
+
+var file_sessions_proto_msgTypes = make([]protoimpl.MessageInfo, 39)
+var file_sessions_proto_goTypes = []any{
+	(*Empty)(nil),                             // 0: sessions.v1.Empty
+	(*LoginRequest)(nil),                      // 1: sessions.v1.LoginRequest
+	(*LoginResponse)(nil),                     // 2: sessions.v1.LoginResponse
+	(*MFAChallenge)(nil),                      // 3: sessions.v1.MFAChallenge
+	(*LoginMFARequest)(nil),                   // 4: sessions.v1.LoginMFARequest
+	(*Tokens)(nil),                            // 5: sessions.v1.Tokens
+	(*RefreshRequest)(nil),                    // 6: sessions.v1.RefreshRequest
+	(*LogoutRequest)(nil),                     // 7: sessions.v1.LogoutRequest
+	(*CheckTokenRequest)(nil),                 // 8: sessions.v1.CheckTokenRequest
+	(*TokenClaims)(nil),                       // 9: sessions.v1.TokenClaims
+	(*CheckTokensRequest)(nil),                // 10: sessions.v1.CheckTokensRequest
+	(*TokenVerdict)(nil),                      // 11: sessions.v1.TokenVerdict
+	(*CheckTokensResponse)(nil),               // 12: sessions.v1.CheckTokensResponse
+	(*AuthorizeRequest)(nil),                  // 13: sessions.v1.AuthorizeRequest
+	(*DecideRequest)(nil),                     // 14: sessions.v1.DecideRequest
+	(*Decision)(nil),                          // 15: sessions.v1.Decision
+	(*EmailLoginRequest)(nil),                 // 16: sessions.v1.EmailLoginRequest
+	(*EmailLoginRedeemRequest)(nil),           // 17: sessions.v1.EmailLoginRedeemRequest
+	(*EnrollTOTPRequest)(nil),                 // 18: sessions.v1.EnrollTOTPRequest
+	(*TOTPEnrollment)(nil),                    // 19: sessions.v1.TOTPEnrollment
+	(*ConfirmTOTPRequest)(nil),                // 20: sessions.v1.ConfirmTOTPRequest
+	(*RecoveryCodesRequest)(nil),              // 21: sessions.v1.RecoveryCodesRequest
+	(*RecoveryCodes)(nil),                     // 22: sessions.v1.RecoveryCodes
+	(*ResetMFARequest)(nil),                   // 23: sessions.v1.ResetMFARequest
+	(*UnlockLoginRequest)(nil),                // 24: sessions.v1.UnlockLoginRequest
+	(*WebAuthnRegistrationRequest)(nil),       // 25: sessions.v1.WebAuthnRegistrationRequest
+	(*WebAuthnRegistrationFinishRequest)(nil), // 26: sessions.v1.WebAuthnRegistrationFinishRequest
+	(*WebAuthnLoginRequest)(nil),              // 27: sessions.v1.WebAuthnLoginRequest
+	(*WebAuthnLoginFinishRequest)(nil),        // 28: sessions.v1.WebAuthnLoginFinishRequest
+	(*WebAuthnCredential)(nil),                // 29: sessions.v1.WebAuthnCredential
+	(*WebAuthnResponse)(nil),                  // 30: sessions.v1.WebAuthnResponse
+	(*WebAuthnCreationOptions)(nil),           // 31: sessions.v1.WebAuthnCreationOptions
+	(*WebAuthnRequestOptions)(nil),            // 32: sessions.v1.WebAuthnRequestOptions
+	(*WebAuthnEntity)(nil),                    // 33: sessions.v1.WebAuthnEntity
+	(*WebAuthnUser)(nil),                      // 34: sessions.v1.WebAuthnUser
+	(*WebAuthnCredentialParameter)(nil),       // 35: sessions.v1.WebAuthnCredentialParameter
+	(*WebAuthnCredentialDescriptor)(nil),      // 36: sessions.v1.WebAuthnCredentialDescriptor
+	(*WebAuthnAuthenticatorSelection)(nil),    // 37: sessions.v1.WebAuthnAuthenticatorSelection
+	nil,                                       // 38: sessions.v1.DecideRequest.ContextEntry
+}
+var file_sessions_proto_depIdxs = []int32{
+	5,  // 0: sessions.v1.LoginResponse.tokens:type_name -> sessions.v1.Tokens
+	3,  // 1: sessions.v1.LoginResponse.challenge:type_name -> sessions.v1.MFAChallenge
+	9,  // 2: sessions.v1.TokenVerdict.claims:type_name -> sessions.v1.TokenClaims
+	11, // 3: sessions.v1.CheckTokensResponse.verdicts:type_name -> sessions.v1.TokenVerdict
+	38, // 4: sessions.v1.DecideRequest.context:type_name -> sessions.v1.DecideRequest.ContextEntry
+	29, // 5: sessions.v1.WebAuthnRegistrationFinishRequest.credential:type_name -> sessions.v1.WebAuthnCredential
+	29, // 6: sessions.v1.WebAuthnLoginFinishRequest.credential:type_name -> sessions.v1.WebAuthnCredential
+	30, // 7: sessions.v1.WebAuthnCredential.response:type_name -> sessions.v1.WebAuthnResponse
+	33, // 8: sessions.v1.WebAuthnCreationOptions.rp:type_name -> sessions.v1.WebAuthnEntity
+	34, // 9: sessions.v1.WebAuthnCreationOptions.user:type_name -> sessions.v1.WebAuthnUser
+	35, // 10: sessions.v1.WebAuthnCreationOptions.pub_key_cred_params:type_name -> sessions.v1.WebAuthnCredentialParameter
+	36, // 11: sessions.v1.WebAuthnCreationOptions.exclude_credentials:type_name -> sessions.v1.WebAuthnCredentialDescriptor
+	37, // 12: sessions.v1.WebAuthnCreationOptions.authenticator_selection:type_name -> sessions.v1.WebAuthnAuthenticatorSelection
+	36, // 13: sessions.v1.WebAuthnRequestOptions.allow_credentials:type_name -> sessions.v1.WebAuthnCredentialDescriptor
+	1,  // 14: sessions.v1.Sessions.Login:input_type -> sessions.v1.LoginRequest
+	4,  // 15: sessions.v1.Sessions.LoginMFA:input_type -> sessions.v1.LoginMFARequest
+	6,  // 16: sessions.v1.Sessions.Refresh:input_type -> sessions.v1.RefreshRequest
+	7,  // 17: sessions.v1.Sessions.Logout:input_type -> sessions.v1.LogoutRequest
+	8,  // 18: sessions.v1.Sessions.CheckToken:input_type -> sessions.v1.CheckTokenRequest
+	10, // 19: sessions.v1.Sessions.CheckTokens:input_type -> sessions.v1.CheckTokensRequest
+	13, // 20: sessions.v1.Sessions.Authorize:input_type -> sessions.v1.AuthorizeRequest
+	14, // 21: sessions.v1.Sessions.Decide:input_type -> sessions.v1.DecideRequest
+	16, // 22: sessions.v1.Sessions.RequestEmailLogin:input_type -> sessions.v1.EmailLoginRequest
+	17, // 23: sessions.v1.Sessions.RedeemEmailLogin:input_type -> sessions.v1.EmailLoginRedeemRequest
+	18, // 24: sessions.v1.Sessions.EnrollTOTP:input_type -> sessions.v1.EnrollTOTPRequest
+	20, // 25: sessions.v1.Sessions.ConfirmTOTP:input_type -> sessions.v1.ConfirmTOTPRequest
+	21, // 26: sessions.v1.Sessions.RegenerateRecoveryCodes:input_type -> sessions.v1.RecoveryCodesRequest
+	25, // 27: sessions.v1.Sessions.BeginWebAuthnRegistration:input_type -> sessions.v1.WebAuthnRegistrationRequest
+	26, // 28: sessions.v1.Sessions.FinishWebAuthnRegistration:input_type -> sessions.v1.WebAuthnRegistrationFinishRequest
+	27, // 29: sessions.v1.Sessions.BeginWebAuthnLogin:input_type -> sessions.v1.WebAuthnLoginRequest
+	28, // 30: sessions.v1.Sessions.FinishWebAuthnLogin:input_type -> sessions.v1.WebAuthnLoginFinishRequest
+	23, // 31: sessions.v1.Sessions.ResetMFA:input_type -> sessions.v1.ResetMFARequest
+	24, // 32: sessions.v1.Sessions.UnlockLogin:input_type -> sessions.v1.UnlockLoginRequest
+	2,  // 33: sessions.v1.Sessions.Login:output_type -> sessions.v1.LoginResponse
+	5,  // 34: sessions.v1.Sessions.LoginMFA:output_type -> sessions.v1.Tokens
+	5,  // 35: sessions.v1.Sessions.Refresh:output_type -> sessions.v1.Tokens
+	0,  // 36: sessions.v1.Sessions.Logout:output_type -> sessions.v1.Empty
+	9,  // 37: sessions.v1.Sessions.CheckToken:output_type -> sessions.v1.TokenClaims
+	12, // 38: sessions.v1.Sessions.CheckTokens:output_type -> sessions.v1.CheckTokensResponse
+	9,  // 39: sessions.v1.Sessions.Authorize:output_type -> sessions.v1.TokenClaims
+	15, // 40: sessions.v1.Sessions.Decide:output_type -> sessions.v1.Decision
+	0,  // 41: sessions.v1.Sessions.RequestEmailLogin:output_type -> sessions.v1.Empty
+	2,  // 42: sessions.v1.Sessions.RedeemEmailLogin:output_type -> sessions.v1.LoginResponse
+	19, // 43: sessions.v1.Sessions.EnrollTOTP:output_type -> sessions.v1.TOTPEnrollment
+	0,  // 44: sessions.v1.Sessions.ConfirmTOTP:output_type -> sessions.v1.Empty
+	22, // 45: sessions.v1.Sessions.RegenerateRecoveryCodes:output_type -> sessions.v1.RecoveryCodes
+	31, // 46: sessions.v1.Sessions.BeginWebAuthnRegistration:output_type -> sessions.v1.WebAuthnCreationOptions
+	0,  // 47: sessions.v1.Sessions.FinishWebAuthnRegistration:output_type -> sessions.v1.Empty
+	32, // 48: sessions.v1.Sessions.BeginWebAuthnLogin:output_type -> sessions.v1.WebAuthnRequestOptions
+	5,  // 49: sessions.v1.Sessions.FinishWebAuthnLogin:output_type -> sessions.v1.Tokens
+	0,  // 50: sessions.v1.Sessions.ResetMFA:output_type -> sessions.v1.Empty
+	0,  // 51: sessions.v1.Sessions.UnlockLogin:output_type -> sessions.v1.Empty
+	33, // [33:52] is the sub-list for method output_type
+	14, // [14:33] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
+}
+
+func init() { file_sessions_proto_init() }
+func file_sessions_proto_init() {
+	if File_sessions_proto != nil {
+		return
+	}
+	file_sessions_proto_msgTypes[2].OneofWrappers = []any{
+		(*LoginResponse_Tokens)(nil),
+		(*LoginResponse_Challenge)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sessions_proto_rawDesc), len(file_sessions_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   39,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_sessions_proto_goTypes,
+		DependencyIndexes: file_sessions_proto_depIdxs,
+		MessageInfos:      file_sessions_proto_msgTypes,
+	}.Build()
+	File_sessions_proto = out.File
+	file_sessions_proto_goTypes = nil
+	file_sessions_proto_depIdxs = nil
+}
@@ -0,0 +1,855 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: sessions.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Sessions_Login_FullMethodName                      = "/sessions.v1.Sessions/Login"
+	Sessions_LoginMFA_FullMethodName                   = "/sessions.v1.Sessions/LoginMFA"
+	Sessions_Refresh_FullMethodName                    = "/sessions.v1.Sessions/Refresh"
+	Sessions_Logout_FullMethodName                     = "/sessions.v1.Sessions/Logout"
+	Sessions_CheckToken_FullMethodName                 = "/sessions.v1.Sessions/CheckToken"
+	Sessions_CheckTokens_FullMethodName                = "/sessions.v1.Sessions/CheckTokens"
+	Sessions_Authorize_FullMethodName                  = "/sessions.v1.Sessions/Authorize"
+	Sessions_Decide_FullMethodName                     = "/sessions.v1.Sessions/Decide"
+	Sessions_RequestEmailLogin_FullMethodName          = "/sessions.v1.Sessions/RequestEmailLogin"
+	Sessions_RedeemEmailLogin_FullMethodName           = "/sessions.v1.Sessions/RedeemEmailLogin"
+	Sessions_EnrollTOTP_FullMethodName                 = "/sessions.v1.Sessions/EnrollTOTP"
+	Sessions_ConfirmTOTP_FullMethodName                = "/sessions.v1.Sessions/ConfirmTOTP"
+	Sessions_RegenerateRecoveryCodes_FullMethodName    = "/sessions.v1.Sessions/RegenerateRecoveryCodes"
+	Sessions_BeginWebAuthnRegistration_FullMethodName  = "/sessions.v1.Sessions/BeginWebAuthnRegistration"
+	Sessions_FinishWebAuthnRegistration_FullMethodName = "/sessions.v1.Sessions/FinishWebAuthnRegistration"
+	Sessions_BeginWebAuthnLogin_FullMethodName         = "/sessions.v1.Sessions/BeginWebAuthnLogin"
+	Sessions_FinishWebAuthnLogin_FullMethodName        = "/sessions.v1.Sessions/FinishWebAuthnLogin"
+	Sessions_ResetMFA_FullMethodName                   = "/sessions.v1.Sessions/ResetMFA"
+	Sessions_UnlockLogin_FullMethodName                = "/sessions.v1.Sessions/UnlockLogin"
+)
+
+// SessionsClient is the client API for Sessions service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Sessions exposes session operations of the service to other services over gRPC.
+// Tokens may be passed in fields of requests or as "authorization: Bearer <token>" metadata;
+// if both are given they must be equal.
+type SessionsClient interface {
+	// Login checks credentials and opens a session. If MFA is enabled for the user,
+	// a challenge is returned instead of tokens.
+	Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error)
+	// LoginMFA completes login with the code of MFA challenge.
+	LoginMFA(ctx context.Context, in *LoginMFARequest, opts ...grpc.CallOption) (*Tokens, error)
+	// Refresh rotates refresh token of a session and issues a new access token.
+	Refresh(ctx context.Context, in *RefreshRequest, opts ...grpc.CallOption) (*Tokens, error)
+	// Logout closes the session of refresh token.
+	Logout(ctx context.Context, in *LogoutRequest, opts ...grpc.CallOption) (*Empty, error)
+	// CheckToken validates access token and describes it. Expired access tokens are refused, Refresh issues new ones.
+	CheckToken(ctx context.Context, in *CheckTokenRequest, opts ...grpc.CallOption) (*TokenClaims, error)
+	// CheckTokens validates a batch of access tokens.
+	CheckTokens(ctx context.Context, in *CheckTokensRequest, opts ...grpc.CallOption) (*CheckTokensResponse, error)
+	// Authorize tells whether access token grants all or any of the named permissions.
+	Authorize(ctx context.Context, in *AuthorizeRequest, opts ...grpc.CallOption) (*TokenClaims, error)
+	// Decide evaluates policies for an action of the user of access token.
+	Decide(ctx context.Context, in *DecideRequest, opts ...grpc.CallOption) (*Decision, error)
+	// RequestEmailLogin sends a one-time code and a magic link to the email. The answer is the same whether
+	// the email is known or not.
+	RequestEmailLogin(ctx context.Context, in *EmailLoginRequest, opts ...grpc.CallOption) (*Empty, error)
+	// RedeemEmailLogin exchanges the code or the token of the link for tokens, or for an MFA challenge.
+	RedeemEmailLogin(ctx context.Context, in *EmailLoginRedeemRequest, opts ...grpc.CallOption) (*LoginResponse, error)
+	// EnrollTOTP starts TOTP enrollment of the user of access token.
+	EnrollTOTP(ctx context.Context, in *EnrollTOTPRequest, opts ...grpc.CallOption) (*TOTPEnrollment, error)
+	// ConfirmTOTP completes TOTP enrollment with a code. Since then login requires MFA.
+	ConfirmTOTP(ctx context.Context, in *ConfirmTOTPRequest, opts ...grpc.CallOption) (*Empty, error)
+	// RegenerateRecoveryCodes replaces recovery codes of the user of access token.
+	RegenerateRecoveryCodes(ctx context.Context, in *RecoveryCodesRequest, opts ...grpc.CallOption) (*RecoveryCodes, error)
+	// BeginWebAuthnRegistration starts registration of a passkey of the user of access token.
+	BeginWebAuthnRegistration(ctx context.Context, in *WebAuthnRegistrationRequest, opts ...grpc.CallOption) (*WebAuthnCreationOptions, error)
+	// FinishWebAuthnRegistration verifies and stores the new passkey.
+	FinishWebAuthnRegistration(ctx context.Context, in *WebAuthnRegistrationFinishRequest, opts ...grpc.CallOption) (*Empty, error)
+	// BeginWebAuthnLogin starts passwordless login.
+	BeginWebAuthnLogin(ctx context.Context, in *WebAuthnLoginRequest, opts ...grpc.CallOption) (*WebAuthnRequestOptions, error)
+	// FinishWebAuthnLogin verifies the assertion of a passkey and opens a session.
+	FinishWebAuthnLogin(ctx context.Context, in *WebAuthnLoginFinishRequest, opts ...grpc.CallOption) (*Tokens, error)
+	// ResetMFA removes recovery codes and/or remembered devices of a user. It requires admin permission.
+	ResetMFA(ctx context.Context, in *ResetMFARequest, opts ...grpc.CallOption) (*Empty, error)
+	// UnlockLogin lifts back-off and lockout of a user name and/or a client IP. It requires admin permission.
+	UnlockLogin(ctx context.Context, in *UnlockLoginRequest, opts ...grpc.CallOption) (*Empty, error)
+}
+
+type sessionsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSessionsClient(cc grpc.ClientConnInterface) SessionsClient {
+	return &sessionsClient{cc}
+}
+
+func (c *sessionsClient) Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LoginResponse)
+	err := c.cc.Invoke(ctx, Sessions_Login_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sessionsClient) LoginMFA(ctx context.Context, in *LoginMFARequest, opts ...grpc.CallOption) (*Tokens, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Tokens)
+	err := c.cc.Invoke(ctx, Sessions_LoginMFA_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sessionsClient) Refresh(ctx context.Context, in *RefreshRequest, opts ...grpc.CallOption) (*Tokens, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Tokens)
+	err := c.cc.Invoke(ctx, Sessions_Refresh_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sessionsClient) Logout(ctx context.Context, in *LogoutRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Sessions_Logout_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sessionsClient) CheckToken(ctx context.Context, in *CheckTokenRequest, opts ...grpc.CallOption) (*TokenClaims, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TokenClaims)
+	err := c.cc.Invoke(ctx, Sessions_CheckToken_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sessionsClient) CheckTokens(ctx context.Context, in *CheckTokensRequest, opts ...grpc.CallOption) (*CheckTokensResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckTokensResponse)
+	err := c.cc.Invoke(ctx, Sessions_CheckTokens_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sessionsClient) Authorize(ctx context.Context, in *AuthorizeRequest, opts ...grpc.CallOption) (*TokenClaims, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TokenClaims)
+	err := c.cc.Invoke(ctx, Sessions_Authorize_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sessionsClient) Decide(ctx context.Context, in *DecideRequest, opts ...grpc.CallOption) (*Decision, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Decision)
+	err := c.cc.Invoke(ctx, Sessions_Decide_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sessionsClient) RequestEmailLogin(ctx context.Context, in *EmailLoginRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Sessions_RequestEmailLogin_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sessionsClient) RedeemEmailLogin(ctx context.Context, in *EmailLoginRedeemRequest, opts ...grpc.CallOption) (*LoginResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LoginResponse)
+	err := c.cc.Invoke(ctx, Sessions_RedeemEmailLogin_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sessionsClient) EnrollTOTP(ctx context.Context, in *EnrollTOTPRequest, opts ...grpc.CallOption) (*TOTPEnrollment, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TOTPEnrollment)
+	err := c.cc.Invoke(ctx, Sessions_EnrollTOTP_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sessionsClient) ConfirmTOTP(ctx context.Context, in *ConfirmTOTPRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Sessions_ConfirmTOTP_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sessionsClient) RegenerateRecoveryCodes(ctx context.Context, in *RecoveryCodesRequest, opts ...grpc.CallOption) (*RecoveryCodes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RecoveryCodes)
+	err := c.cc.Invoke(ctx, Sessions_RegenerateRecoveryCodes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sessionsClient) BeginWebAuthnRegistration(ctx context.Context, in *WebAuthnRegistrationRequest, opts ...grpc.CallOption) (*WebAuthnCreationOptions, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WebAuthnCreationOptions)
+	err := c.cc.Invoke(ctx, Sessions_BeginWebAuthnRegistration_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sessionsClient) FinishWebAuthnRegistration(ctx context.Context, in *WebAuthnRegistrationFinishRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Sessions_FinishWebAuthnRegistration_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sessionsClient) BeginWebAuthnLogin(ctx context.Context, in *WebAuthnLoginRequest, opts ...grpc.CallOption) (*WebAuthnRequestOptions, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WebAuthnRequestOptions)
+	err := c.cc.Invoke(ctx, Sessions_BeginWebAuthnLogin_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sessionsClient) FinishWebAuthnLogin(ctx context.Context, in *WebAuthnLoginFinishRequest, opts ...grpc.CallOption) (*Tokens, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Tokens)
+	err := c.cc.Invoke(ctx, Sessions_FinishWebAuthnLogin_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sessionsClient) ResetMFA(ctx context.Context, in *ResetMFARequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Sessions_ResetMFA_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sessionsClient) UnlockLogin(ctx context.Context, in *UnlockLoginRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Sessions_UnlockLogin_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SessionsServer is the server API for Sessions service.
+// All implementations must embed UnimplementedSessionsServer
+// for forward compatibility.
+//
+// Sessions exposes session operations of the service to other services over gRPC.
+// Tokens may be passed in fields of requests or as "authorization: Bearer <token>" metadata;
+// if both are given they must be equal.
+type SessionsServer interface {
+	// Login checks credentials and opens a session. If MFA is enabled for the user,
+	// a challenge is returned instead of tokens.
+	Login(context.Context, *LoginRequest) (*LoginResponse, error)
+	// LoginMFA completes login with the code of MFA challenge.
+	LoginMFA(context.Context, *LoginMFARequest) (*Tokens, error)
+	// Refresh rotates refresh token of a session and issues a new access token.
+	Refresh(context.Context, *RefreshRequest) (*Tokens, error)
+	// Logout closes the session of refresh token.
+	Logout(context.Context, *LogoutRequest) (*Empty, error)
+	// CheckToken validates access token and describes it. Expired access tokens are refused, Refresh issues new ones.
+	CheckToken(context.Context, *CheckTokenRequest) (*TokenClaims, error)
+	// CheckTokens validates a batch of access tokens.
+	CheckTokens(context.Context, *CheckTokensRequest) (*CheckTokensResponse, error)
+	// Authorize tells whether access token grants all or any of the named permissions.
+	Authorize(context.Context, *AuthorizeRequest) (*TokenClaims, error)
+	// Decide evaluates policies for an action of the user of access token.
+	Decide(context.Context, *DecideRequest) (*Decision, error)
+	// RequestEmailLogin sends a one-time code and a magic link to the email. The answer is the same whether
+	// the email is known or not.
+	RequestEmailLogin(context.Context, *EmailLoginRequest) (*Empty, error)
+	// RedeemEmailLogin exchanges the code or the token of the link for tokens, or for an MFA challenge.
+	RedeemEmailLogin(context.Context, *EmailLoginRedeemRequest) (*LoginResponse, error)
+	// EnrollTOTP starts TOTP enrollment of the user of access token.
+	EnrollTOTP(context.Context, *EnrollTOTPRequest) (*TOTPEnrollment, error)
+	// ConfirmTOTP completes TOTP enrollment with a code. Since then login requires MFA.
+	ConfirmTOTP(context.Context, *ConfirmTOTPRequest) (*Empty, error)
+	// RegenerateRecoveryCodes replaces recovery codes of the user of access token.
+	RegenerateRecoveryCodes(context.Context, *RecoveryCodesRequest) (*RecoveryCodes, error)
+	// BeginWebAuthnRegistration starts registration of a passkey of the user of access token.
+	BeginWebAuthnRegistration(context.Context, *WebAuthnRegistrationRequest) (*WebAuthnCreationOptions, error)
+	// FinishWebAuthnRegistration verifies and stores the new passkey.
+	FinishWebAuthnRegistration(context.Context, *WebAuthnRegistrationFinishRequest) (*Empty, error)
+	// BeginWebAuthnLogin starts passwordless login.
+	BeginWebAuthnLogin(context.Context, *WebAuthnLoginRequest) (*WebAuthnRequestOptions, error)
+	// FinishWebAuthnLogin verifies the assertion of a passkey and opens a session.
+	FinishWebAuthnLogin(context.Context, *WebAuthnLoginFinishRequest) (*Tokens, error)
+	// ResetMFA removes recovery codes and/or remembered devices of a user. It requires admin permission.
+	ResetMFA(context.Context, *ResetMFARequest) (*Empty, error)
+	// UnlockLogin lifts back-off and lockout of a user name and/or a client IP. It requires admin permission.
+	UnlockLogin(context.Context, *UnlockLoginRequest) (*Empty, error)
+	mustEmbedUnimplementedSessionsServer()
+}
+
+// UnimplementedSessionsServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSessionsServer struct{}
+
+func (UnimplementedSessionsServer) Login(context.Context, *LoginRequest) (*LoginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
+}
+func (UnimplementedSessionsServer) LoginMFA(context.Context, *LoginMFARequest) (*Tokens, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LoginMFA not implemented")
+}
+func (UnimplementedSessionsServer) Refresh(context.Context, *RefreshRequest) (*Tokens, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Refresh not implemented")
+}
+func (UnimplementedSessionsServer) Logout(context.Context, *LogoutRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Logout not implemented")
+}
+func (UnimplementedSessionsServer) CheckToken(context.Context, *CheckTokenRequest) (*TokenClaims, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckToken not implemented")
+}
+func (UnimplementedSessionsServer) CheckTokens(context.Context, *CheckTokensRequest) (*CheckTokensResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckTokens not implemented")
+}
+func (UnimplementedSessionsServer) Authorize(context.Context, *AuthorizeRequest) (*TokenClaims, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Authorize not implemented")
+}
+func (UnimplementedSessionsServer) Decide(context.Context, *DecideRequest) (*Decision, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Decide not implemented")
+}
+func (UnimplementedSessionsServer) RequestEmailLogin(context.Context, *EmailLoginRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestEmailLogin not implemented")
+}
+func (UnimplementedSessionsServer) RedeemEmailLogin(context.Context, *EmailLoginRedeemRequest) (*LoginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RedeemEmailLogin not implemented")
+}
+func (UnimplementedSessionsServer) EnrollTOTP(context.Context, *EnrollTOTPRequest) (*TOTPEnrollment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EnrollTOTP not implemented")
+}
+func (UnimplementedSessionsServer) ConfirmTOTP(context.Context, *ConfirmTOTPRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmTOTP not implemented")
+}
+func (UnimplementedSessionsServer) RegenerateRecoveryCodes(context.Context, *RecoveryCodesRequest) (*RecoveryCodes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegenerateRecoveryCodes not implemented")
+}
+func (UnimplementedSessionsServer) BeginWebAuthnRegistration(context.Context, *WebAuthnRegistrationRequest) (*WebAuthnCreationOptions, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BeginWebAuthnRegistration not implemented")
+}
+func (UnimplementedSessionsServer) FinishWebAuthnRegistration(context.Context, *WebAuthnRegistrationFinishRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FinishWebAuthnRegistration not implemented")
+}
+func (UnimplementedSessionsServer) BeginWebAuthnLogin(context.Context, *WebAuthnLoginRequest) (*WebAuthnRequestOptions, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BeginWebAuthnLogin not implemented")
+}
+func (UnimplementedSessionsServer) FinishWebAuthnLogin(context.Context, *WebAuthnLoginFinishRequest) (*Tokens, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FinishWebAuthnLogin not implemented")
+}
+func (UnimplementedSessionsServer) ResetMFA(context.Context, *ResetMFARequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetMFA not implemented")
+}
+func (UnimplementedSessionsServer) UnlockLogin(context.Context, *UnlockLoginRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnlockLogin not implemented")
+}
+func (UnimplementedSessionsServer) mustEmbedUnimplementedSessionsServer() {}
+func (UnimplementedSessionsServer) testEmbeddedByValue()                  {}
+
+// UnsafeSessionsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SessionsServer will
+// result in compilation errors.
+type UnsafeSessionsServer interface {
+	mustEmbedUnimplementedSessionsServer()
+}
+
+func RegisterSessionsServer(s grpc.ServiceRegistrar, srv SessionsServer) {
+	// If the following call pancis, it indicates UnimplementedSessionsServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Sessions_ServiceDesc, srv)
+}
+
+func _Sessions_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SessionsServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sessions_Login_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SessionsServer).Login(ctx, req.(*LoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sessions_LoginMFA_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginMFARequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SessionsServer).LoginMFA(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sessions_LoginMFA_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SessionsServer).LoginMFA(ctx, req.(*LoginMFARequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sessions_Refresh_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefreshRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SessionsServer).Refresh(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sessions_Refresh_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SessionsServer).Refresh(ctx, req.(*RefreshRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sessions_Logout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogoutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SessionsServer).Logout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sessions_Logout_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SessionsServer).Logout(ctx, req.(*LogoutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sessions_CheckToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SessionsServer).CheckToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sessions_CheckToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SessionsServer).CheckToken(ctx, req.(*CheckTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sessions_CheckTokens_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckTokensRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SessionsServer).CheckTokens(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sessions_CheckTokens_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SessionsServer).CheckTokens(ctx, req.(*CheckTokensRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sessions_Authorize_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthorizeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SessionsServer).Authorize(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sessions_Authorize_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SessionsServer).Authorize(ctx, req.(*AuthorizeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sessions_Decide_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DecideRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SessionsServer).Decide(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sessions_Decide_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SessionsServer).Decide(ctx, req.(*DecideRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sessions_RequestEmailLogin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmailLoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SessionsServer).RequestEmailLogin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sessions_RequestEmailLogin_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SessionsServer).RequestEmailLogin(ctx, req.(*EmailLoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sessions_RedeemEmailLogin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmailLoginRedeemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SessionsServer).RedeemEmailLogin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sessions_RedeemEmailLogin_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SessionsServer).RedeemEmailLogin(ctx, req.(*EmailLoginRedeemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sessions_EnrollTOTP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EnrollTOTPRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SessionsServer).EnrollTOTP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sessions_EnrollTOTP_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SessionsServer).EnrollTOTP(ctx, req.(*EnrollTOTPRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sessions_ConfirmTOTP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfirmTOTPRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SessionsServer).ConfirmTOTP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sessions_ConfirmTOTP_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SessionsServer).ConfirmTOTP(ctx, req.(*ConfirmTOTPRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sessions_RegenerateRecoveryCodes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecoveryCodesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SessionsServer).RegenerateRecoveryCodes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sessions_RegenerateRecoveryCodes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SessionsServer).RegenerateRecoveryCodes(ctx, req.(*RecoveryCodesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sessions_BeginWebAuthnRegistration_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WebAuthnRegistrationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SessionsServer).BeginWebAuthnRegistration(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sessions_BeginWebAuthnRegistration_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SessionsServer).BeginWebAuthnRegistration(ctx, req.(*WebAuthnRegistrationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sessions_FinishWebAuthnRegistration_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WebAuthnRegistrationFinishRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SessionsServer).FinishWebAuthnRegistration(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sessions_FinishWebAuthnRegistration_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SessionsServer).FinishWebAuthnRegistration(ctx, req.(*WebAuthnRegistrationFinishRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sessions_BeginWebAuthnLogin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WebAuthnLoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SessionsServer).BeginWebAuthnLogin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sessions_BeginWebAuthnLogin_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SessionsServer).BeginWebAuthnLogin(ctx, req.(*WebAuthnLoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sessions_FinishWebAuthnLogin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WebAuthnLoginFinishRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SessionsServer).FinishWebAuthnLogin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sessions_FinishWebAuthnLogin_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SessionsServer).FinishWebAuthnLogin(ctx, req.(*WebAuthnLoginFinishRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sessions_ResetMFA_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResetMFARequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SessionsServer).ResetMFA(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sessions_ResetMFA_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SessionsServer).ResetMFA(ctx, req.(*ResetMFARequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sessions_UnlockLogin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnlockLoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SessionsServer).UnlockLogin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sessions_UnlockLogin_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SessionsServer).UnlockLogin(ctx, req.(*UnlockLoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Sessions_ServiceDesc is the grpc.ServiceDesc for Sessions service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Sessions_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "sessions.v1.Sessions",
+	HandlerType: (*SessionsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Login",
+			Handler:    _Sessions_Login_Handler,
+		},
+		{
+			MethodName: "LoginMFA",
+			Handler:    _Sessions_LoginMFA_Handler,
+		},
+		{
+			MethodName: "Refresh",
+			Handler:    _Sessions_Refresh_Handler,
+		},
+		{
+			MethodName: "Logout",
+			Handler:    _Sessions_Logout_Handler,
+		},
+		{
+			MethodName: "CheckToken",
+			Handler:    _Sessions_CheckToken_Handler,
+		},
+		{
+			MethodName: "CheckTokens",
+			Handler:    _Sessions_CheckTokens_Handler,
+		},
+		{
+			MethodName: "Authorize",
+			Handler:    _Sessions_Authorize_Handler,
+		},
+		{
+			MethodName: "Decide",
+			Handler:    _Sessions_Decide_Handler,
+		},
+		{
+			MethodName: "RequestEmailLogin",
+			Handler:    _Sessions_RequestEmailLogin_Handler,
+		},
+		{
+			MethodName: "RedeemEmailLogin",
+			Handler:    _Sessions_RedeemEmailLogin_Handler,
+		},
+		{
+			MethodName: "EnrollTOTP",
+			Handler:    _Sessions_EnrollTOTP_Handler,
+		},
+		{
+			MethodName: "ConfirmTOTP",
+			Handler:    _Sessions_ConfirmTOTP_Handler,
+		},
+		{
+			MethodName: "RegenerateRecoveryCodes",
+			Handler:    _Sessions_RegenerateRecoveryCodes_Handler,
+		},
+		{
+			MethodName: "BeginWebAuthnRegistration",
+			Handler:    _Sessions_BeginWebAuthnRegistration_Handler,
+		},
+		{
+			MethodName: "FinishWebAuthnRegistration",
+			Handler:    _Sessions_FinishWebAuthnRegistration_Handler,
+		},
+		{
+			MethodName: "BeginWebAuthnLogin",
+			Handler:    _Sessions_BeginWebAuthnLogin_Handler,
+		},
+		{
+			MethodName: "FinishWebAuthnLogin",
+			Handler:    _Sessions_FinishWebAuthnLogin_Handler,
+		},
+		{
+			MethodName: "ResetMFA",
+			Handler:    _Sessions_ResetMFA_Handler,
+		},
+		{
+			MethodName: "UnlockLogin",
+			Handler:    _Sessions_UnlockLogin_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "sessions.proto",
+}
@@ -0,0 +1,132 @@
+package permissions
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/constants"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+)
+
+const (
+	//All requires every listed permission
+	All = "all"
+	//Any requires at least one of listed permissions
+	Any = "any"
+	//Admin is the name of permission admin operations of the service require
+	Admin = "admin"
+)
+
+//maxBit is the highest bit of a mask that fits int64 without making it negative
+const maxBit = 62
+
+//Registry maps bits of role mask to permission names
+type Registry struct {
+	names map[uint]string
+	bits  map[string]uint
+}
+
+//NewRegistry creates registry from bit numbers and their names
+func NewRegistry(names map[uint]string) *Registry {
+	r := &Registry{names: make(map[uint]string, len(names)), bits: make(map[string]uint, len(names))}
+	for bit, name := range names {
+		r.names[bit] = name
+		r.bits[name] = bit
+	}
+
+	return r
+}
+
+//DefaultRegistry returns permissions known to the service itself
+func DefaultRegistry() *Registry {
+	return NewRegistry(map[uint]string{
+		bitOf(constants.AdminPermission): Admin,
+	})
+}
+
+//ParseRegistry reads JSON object that maps permission names to bit numbers, e.g. {"documents.read": 0, "admin": 14}.
+//Configured permissions replace the default ones, so they must name the admin bit: otherwise nobody could perform admin
+//operations
+func ParseRegistry(data []byte) (*Registry, error) {
+	var bits map[string]uint
+	if err := json.Unmarshal(data, &bits); err != nil {
+		return nil, err
+	}
+
+	names := make(map[uint]string, len(bits))
+	for name, bit := range bits {
+		if bit > maxBit {
+			return nil, errors.ErrInvalidPermissionBit
+		}
+		if _, ok := names[bit]; ok {
+			return nil, errors.ErrDuplicatePermissionBit
+		}
+		names[bit] = name
+	}
+
+	if _, ok := bits[Admin]; !ok {
+		return nil, errors.ErrMissingAdminPermission
+	}
+
+	return NewRegistry(names), nil
+}
+
+//Names returns names of permissions set in the mask ordered by bit. Bits without a name are skipped
+func (r *Registry) Names(mask int64) []string {
+	bits := make([]int, 0, len(r.names))
+	for bit := range r.names {
+		if mask&(1<<bit) != 0 {
+			bits = append(bits, int(bit))
+		}
+	}
+	sort.Ints(bits)
+
+	names := make([]string, 0, len(bits))
+	for _, bit := range bits {
+		names = append(names, r.names[uint(bit)])
+	}
+
+	return names
+}
+
+//Mask returns mask with bits of the named permissions set
+func (r *Registry) Mask(names []string) (int64, error) {
+	var mask int64
+	for _, name := range names {
+		bit, ok := r.bits[name]
+		if !ok {
+			return 0, errors.ErrUnknownPermission
+		}
+		mask |= 1 << bit
+	}
+
+	return mask, nil
+}
+
+//Allows tells whether the mask grants all or any of the named permissions according to mode. Empty mode means all
+func (r *Registry) Allows(mask int64, names []string, mode string) (bool, error) {
+	required, err := r.Mask(names)
+	if err != nil {
+		return false, err
+	}
+
+	switch mode {
+	case All, "":
+		return mask&required == required, nil
+	case Any:
+		return mask&required != 0, nil
+	default:
+		return false, errors.ErrInvalidAuthorizationMode
+	}
+}
+
+//bitOf returns number of the bit a single bit permission is made of
+func bitOf(permission int64) uint {
+	var bit uint
+	for permission > 1 {
+		permission >>= 1
+		bit++
+	}
+
+	return bit
+}
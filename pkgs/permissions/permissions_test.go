@@ -0,0 +1,65 @@
+package permissions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+)
+
+func TestRegistry_Names(t *testing.T) {
+	r := NewRegistry(map[uint]string{0: "read", 1: "write", 14: "admin"})
+
+	assert.Equal(t, []string{"read", "write", "admin"}, r.Names(32767))
+	assert.Equal(t, []string{"write"}, r.Names(2|4|8))
+	assert.Empty(t, r.Names(0))
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	assert.Equal(t, []string{"admin"}, DefaultRegistry().Names(32767))
+	assert.Empty(t, DefaultRegistry().Names(2048))
+}
+
+func TestParseRegistry(t *testing.T) {
+	r, err := ParseRegistry([]byte(`{"documents.read": 0, "documents.write": 1, "admin": 14}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"documents.read", "admin"}, r.Names(1|1<<14))
+
+	_, err = ParseRegistry([]byte(`{"a": 1, "b": 1}`))
+	assert.Equal(t, errors.ErrDuplicatePermissionBit, err)
+	_, err = ParseRegistry([]byte(`{"a": 63}`))
+	assert.Equal(t, errors.ErrInvalidPermissionBit, err)
+	_, err = ParseRegistry([]byte(`["a"]`))
+	assert.Error(t, err)
+
+	//Admin operations would be refused to everyone
+	_, err = ParseRegistry([]byte(`{"documents.read": 0}`))
+	assert.Equal(t, errors.ErrMissingAdminPermission, err)
+}
+
+func TestRegistry_Allows(t *testing.T) {
+	r := NewRegistry(map[uint]string{0: "read", 1: "write", 14: "admin"})
+
+	for _, tc := range []struct {
+		mask    int64
+		names   []string
+		mode    string
+		allowed bool
+	}{
+		{3, []string{"read", "write"}, All, true},
+		{1, []string{"read", "write"}, All, false},
+		{1, []string{"read", "write"}, "", false},
+		{1, []string{"read", "write"}, Any, true},
+		{4, []string{"read", "write"}, Any, false},
+	} {
+		allowed, err := r.Allows(tc.mask, tc.names, tc.mode)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.allowed, allowed, tc)
+	}
+
+	_, err := r.Allows(3, []string{"delete"}, All)
+	assert.Equal(t, errors.ErrUnknownPermission, err)
+	_, err = r.Allows(3, []string{"read"}, "some")
+	assert.Equal(t, errors.ErrInvalidAuthorizationMode, err)
+}
@@ -0,0 +1,153 @@
+package policy
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+)
+
+//Input holds everything a decision is made over
+type Input struct {
+	Subject     string
+	Mask        int64
+	Permissions []string
+	SessionID   string
+	Profile     models.UserProfile
+	Action      string
+	Resource    string
+	IP          string
+	Context     map[string]string
+	Time        time.Time
+}
+
+//attribute returns value of a named attribute. Strings and lists of strings are returned as is, numbers as float64
+func (in Input) attribute(name string) (interface{}, bool) {
+	switch name {
+	case "subject":
+		return in.Subject, true
+	case "mask":
+		return float64(in.Mask), true
+	case "permissions":
+		return in.Permissions, true
+	case "session_id":
+		return in.SessionID, true
+	case "profile.location":
+		return in.Profile.Location, true
+	case "profile.position":
+		return in.Profile.Position, true
+	case "profile.role":
+		return in.Profile.Role.Name, true
+	case "action":
+		return in.Action, true
+	case "resource":
+		return in.Resource, true
+	case "request.ip":
+		return in.IP, true
+	case "time.hour":
+		return float64(in.Time.Hour()), true
+	case "time.weekday":
+		return strings.ToLower(in.Time.Weekday().String()), true
+	}
+
+	if strings.HasPrefix(name, "context.") {
+		v, ok := in.Context[strings.TrimPrefix(name, "context.")]
+		return v, ok
+	}
+
+	return nil, false
+}
+
+//number returns numeric attribute. Context values are strings, so they are parsed
+func (in Input) number(name string) (float64, bool) {
+	v, ok := in.attribute(name)
+	if !ok {
+		return 0, false
+	}
+
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+
+	return 0, false
+}
+
+//Decide evaluates the set. Any matching deny policy refuses the action, otherwise any matching allow policy grants it.
+//If nothing matches, the action is refused
+func (s *Set) Decide(in Input) models.Decision {
+	in.Time = in.Time.In(s.location)
+
+	var allowedBy string
+	for _, r := range s.rules {
+		if !r.applies(in.Action) || !r.when(in) {
+			continue
+		}
+		if r.deny {
+			return models.Decision{Allowed: false, Policy: r.name}
+		}
+		if len(allowedBy) == 0 {
+			allowedBy = r.name
+		}
+	}
+
+	if len(allowedBy) == 0 {
+		return models.Decision{Allowed: false}
+	}
+
+	return models.Decision{Allowed: true, Policy: allowedBy}
+}
+
+func (r rule) applies(action string) bool {
+	for _, a := range r.actions {
+		if a == "*" || a == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+//Engine holds the current policy set. The set is replaced as a whole on reload, so that a decision never sees a half
+//loaded document
+type Engine struct {
+	mtx sync.RWMutex
+	set *Set
+}
+
+//NewEngine creates engine without policies, i.e. one that refuses everything
+func NewEngine() *Engine {
+	return &Engine{set: &Set{location: time.UTC}}
+}
+
+//Load replaces policies with ones from JSON document. Empty document removes all policies. If the document is
+//invalid, current policies are kept
+func (e *Engine) Load(data []byte) error {
+	set := &Set{location: time.UTC}
+	if len(data) != 0 {
+		var err error
+		if set, err = Parse(data); err != nil {
+			return err
+		}
+	}
+
+	e.mtx.Lock()
+	e.set = set
+	e.mtx.Unlock()
+
+	return nil
+}
+
+//Decide evaluates current policies
+func (e *Engine) Decide(in Input) models.Decision {
+	e.mtx.RLock()
+	set := e.set
+	e.mtx.RUnlock()
+
+	return set.Decide(in)
+}
@@ -0,0 +1,337 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	//Allow grants an action if the policy matches, unless another matching policy denies it
+	Allow = "allow"
+	//Deny refuses an action if the policy matches. Deny overrides allow
+	Deny = "deny"
+)
+
+//Document is a set of policies as stored in configuration:
+//
+//	{
+//	  "timezone": "Europe/Kiev",
+//	  "policies": [{
+//	    "name": "editors approve in business hours from corporate network",
+//	    "effect": "allow",
+//	    "actions": ["documents.approve"],
+//	    "when": {"all": [
+//	      {"attr": "profile.position", "op": "eq", "value": "Editor"},
+//	      {"attr": "time.hour", "op": "between", "value": [9, 18]},
+//	      {"attr": "time.weekday", "op": "in", "value": ["monday", "tuesday", "wednesday", "thursday", "friday"]},
+//	      {"attr": "request.ip", "op": "cidr", "value": ["10.0.0.0/8"]}
+//	    ]}
+//	  }]
+//	}
+//
+//Timezone applies to time attributes and defaults to UTC
+type Document struct {
+	Timezone string   `json:"timezone"`
+	Policies []Policy `json:"policies"`
+}
+
+//Policy applies its effect to the actions if its condition holds. Action "*" matches any action, missing condition
+//always holds
+type Policy struct {
+	Name    string     `json:"name"`
+	Effect  string     `json:"effect"`
+	Actions []string   `json:"actions"`
+	When    *Condition `json:"when"`
+}
+
+//Condition is either a combination of other conditions (all, any, not) or a comparison of an attribute with a value.
+//
+//Attributes are:
+//
+//	subject, mask, permissions, session_id   claims of access token
+//	profile.location, profile.position,      profile of the user
+//	profile.role
+//	action, resource, request.ip             the request being decided
+//	context.<name>                           any other attribute given by the caller
+//	time.hour, time.weekday                  time of the decision, e.g. 14 and "monday"
+//
+//Operators are eq, ne, in (value is a list), contains (attribute is a list, e.g. permissions), lt, lte, gt, gte,
+//between (value is [from, to), e.g. hours) and cidr (value is a list of networks the address must belong to)
+type Condition struct {
+	All   []Condition     `json:"all,omitempty"`
+	Any   []Condition     `json:"any,omitempty"`
+	Not   *Condition      `json:"not,omitempty"`
+	Attr  string          `json:"attr,omitempty"`
+	Op    string          `json:"op,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+//predicate is a compiled condition
+type predicate func(in Input) bool
+
+type rule struct {
+	name    string
+	deny    bool
+	actions []string
+	when    predicate
+}
+
+//Set is a compiled and validated document
+type Set struct {
+	location *time.Location
+	rules    []rule
+}
+
+//Parse reads JSON document and compiles it. Any unknown attribute, operator or malformed value fails the whole document,
+//so that a typo does not silently change decisions
+func Parse(data []byte) (*Set, error) {
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	return Compile(doc)
+}
+
+//Compile validates document and turns its conditions into predicates
+func Compile(doc Document) (*Set, error) {
+	set := &Set{location: time.UTC}
+	if len(doc.Timezone) != 0 {
+		location, err := time.LoadLocation(doc.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		set.location = location
+	}
+
+	for i, p := range doc.Policies {
+		name := p.Name
+		if len(name) == 0 {
+			name = fmt.Sprintf("#%d", i)
+		}
+
+		r := rule{name: name, actions: p.Actions, when: func(Input) bool { return true }}
+		switch p.Effect {
+		case Allow:
+		case Deny:
+			r.deny = true
+		default:
+			return nil, fmt.Errorf("policy %s: unknown effect %q", name, p.Effect)
+		}
+		if len(p.Actions) == 0 {
+			return nil, fmt.Errorf("policy %s: no actions", name)
+		}
+		if p.When != nil {
+			when, err := compile(*p.When)
+			if err != nil {
+				return nil, fmt.Errorf("policy %s: %v", name, err)
+			}
+			r.when = when
+		}
+
+		set.rules = append(set.rules, r)
+	}
+
+	return set, nil
+}
+
+func compile(c Condition) (predicate, error) {
+	switch {
+	case len(c.All) != 0:
+		preds, err := compileList(c.All)
+		if err != nil {
+			return nil, err
+		}
+		return func(in Input) bool {
+			for _, p := range preds {
+				if !p(in) {
+					return false
+				}
+			}
+			return true
+		}, nil
+	case len(c.Any) != 0:
+		preds, err := compileList(c.Any)
+		if err != nil {
+			return nil, err
+		}
+		return func(in Input) bool {
+			for _, p := range preds {
+				if p(in) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case c.Not != nil:
+		pred, err := compile(*c.Not)
+		if err != nil {
+			return nil, err
+		}
+		return func(in Input) bool { return !pred(in) }, nil
+	case len(c.Attr) != 0:
+		return compileComparison(c)
+	default:
+		return nil, fmt.Errorf("empty condition")
+	}
+}
+
+func compileList(conditions []Condition) ([]predicate, error) {
+	preds := make([]predicate, 0, len(conditions))
+	for _, c := range conditions {
+		pred, err := compile(c)
+		if err != nil {
+			return nil, err
+		}
+		preds = append(preds, pred)
+	}
+
+	return preds, nil
+}
+
+func compileComparison(c Condition) (predicate, error) {
+	if !knownAttribute(c.Attr) {
+		return nil, fmt.Errorf("unknown attribute %q", c.Attr)
+	}
+	attr := c.Attr
+
+	switch c.Op {
+	case "eq", "ne":
+		var value interface{}
+		if err := json.Unmarshal(c.Value, &value); err != nil || !scalar(value) {
+			return nil, fmt.Errorf("%s %s: value must be a string or a number", attr, c.Op)
+		}
+		negate := c.Op == "ne"
+		return func(in Input) bool {
+			v, _ := in.attribute(attr)
+			return equal(v, value) != negate
+		}, nil
+	case "in":
+		var values []interface{}
+		if err := json.Unmarshal(c.Value, &values); err != nil {
+			return nil, fmt.Errorf("%s in: value must be a list", attr)
+		}
+		return func(in Input) bool {
+			v, _ := in.attribute(attr)
+			for _, value := range values {
+				if equal(v, value) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case "contains":
+		var value string
+		if err := json.Unmarshal(c.Value, &value); err != nil {
+			return nil, fmt.Errorf("%s contains: value must be a string", attr)
+		}
+		return func(in Input) bool {
+			v, _ := in.attribute(attr)
+			list, _ := v.([]string)
+			for _, item := range list {
+				if item == value {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case "lt", "lte", "gt", "gte":
+		var value float64
+		if err := json.Unmarshal(c.Value, &value); err != nil {
+			return nil, fmt.Errorf("%s %s: value must be a number", attr, c.Op)
+		}
+		op := c.Op
+		return func(in Input) bool {
+			v, ok := in.number(attr)
+			if !ok {
+				return false
+			}
+			switch op {
+			case "lt":
+				return v < value
+			case "lte":
+				return v <= value
+			case "gt":
+				return v > value
+			default:
+				return v >= value
+			}
+		}, nil
+	case "between":
+		var bounds []float64
+		if err := json.Unmarshal(c.Value, &bounds); err != nil || len(bounds) != 2 {
+			return nil, fmt.Errorf("%s between: value must be [from, to]", attr)
+		}
+		return func(in Input) bool {
+			v, ok := in.number(attr)
+			return ok && v >= bounds[0] && v < bounds[1]
+		}, nil
+	case "cidr":
+		var raw []string
+		if err := json.Unmarshal(c.Value, &raw); err != nil {
+			return nil, fmt.Errorf("%s cidr: value must be a list of networks", attr)
+		}
+		networks := make([]*net.IPNet, 0, len(raw))
+		for _, cidr := range raw {
+			_, network, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("%s cidr: %v", attr, err)
+			}
+			networks = append(networks, network)
+		}
+		return func(in Input) bool {
+			v, _ := in.attribute(attr)
+			s, _ := v.(string)
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return false
+			}
+			for _, network := range networks {
+				if network.Contains(ip) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	default:
+		return nil, fmt.Errorf("%s: unknown operator %q", attr, c.Op)
+	}
+}
+
+func knownAttribute(attr string) bool {
+	switch attr {
+	case "subject", "mask", "permissions", "session_id",
+		"profile.location", "profile.position", "profile.role",
+		"action", "resource", "request.ip",
+		"time.hour", "time.weekday":
+		return true
+	}
+
+	return strings.HasPrefix(attr, "context.") && len(attr) > len("context.")
+}
+
+func scalar(v interface{}) bool {
+	switch v.(type) {
+	case string, float64:
+		return true
+	}
+
+	return false
+}
+
+//equal compares attribute with a value from JSON. Numbers of JSON are float64
+func equal(attr, value interface{}) bool {
+	switch a := attr.(type) {
+	case string:
+		v, ok := value.(string)
+		return ok && a == v
+	case float64:
+		v, ok := value.(float64)
+		return ok && a == v
+	}
+
+	return false
+}
@@ -0,0 +1,122 @@
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+)
+
+const businessHours = `{
+	"timezone": "UTC",
+	"policies": [{
+		"name": "editors approve in business hours",
+		"effect": "allow",
+		"actions": ["documents.approve"],
+		"when": {"all": [
+			{"attr": "profile.position", "op": "eq", "value": "Editor"},
+			{"attr": "time.hour", "op": "between", "value": [9, 18]},
+			{"attr": "time.weekday", "op": "in", "value": ["monday", "tuesday", "wednesday", "thursday", "friday"]},
+			{"attr": "request.ip", "op": "cidr", "value": ["10.0.0.0/8"]}
+		]}
+	}, {
+		"name": "no approval of own documents",
+		"effect": "deny",
+		"actions": ["*"],
+		"when": {"attr": "context.owner", "op": "eq", "value": "editor@edms.com"}
+	}, {
+		"name": "admins read anything",
+		"effect": "allow",
+		"actions": ["documents.read"],
+		"when": {"any": [
+			{"attr": "permissions", "op": "contains", "value": "admin"},
+			{"not": {"attr": "mask", "op": "lt", "value": 1}}
+		]}
+	}]
+}`
+
+func editorInput() Input {
+	return Input{
+		Subject: "editor@edms.com",
+		Profile: models.UserProfile{Position: "Editor"},
+		Action:  "documents.approve",
+		IP:      "10.1.2.3",
+		Time:    time.Date(2019, 9, 2, 10, 30, 0, 0, time.UTC), //Monday
+	}
+}
+
+func TestSet_Decide(t *testing.T) {
+	set, err := Parse([]byte(businessHours))
+	assert.NoError(t, err)
+
+	assert.Equal(t, models.Decision{Allowed: true, Policy: "editors approve in business hours"}, set.Decide(editorInput()))
+
+	in := editorInput()
+	in.Time = time.Date(2019, 9, 2, 18, 0, 0, 0, time.UTC)
+	assert.Equal(t, models.Decision{}, set.Decide(in))
+
+	in = editorInput()
+	in.Time = time.Date(2019, 9, 1, 10, 30, 0, 0, time.UTC) //Sunday
+	assert.False(t, set.Decide(in).Allowed)
+
+	in = editorInput()
+	in.IP = "192.0.2.1"
+	assert.False(t, set.Decide(in).Allowed)
+
+	in = editorInput()
+	in.Action = "documents.delete"
+	assert.False(t, set.Decide(in).Allowed)
+
+	//Deny overrides allow
+	in = editorInput()
+	in.Context = map[string]string{"owner": "editor@edms.com"}
+	assert.Equal(t, models.Decision{Allowed: false, Policy: "no approval of own documents"}, set.Decide(in))
+
+	in = Input{Action: "documents.read", Permissions: []string{"admin"}}
+	assert.True(t, set.Decide(in).Allowed)
+	in = Input{Action: "documents.read", Mask: 2}
+	assert.True(t, set.Decide(in).Allowed)
+	in = Input{Action: "documents.read"}
+	assert.False(t, set.Decide(in).Allowed)
+}
+
+func TestSet_Timezone(t *testing.T) {
+	set, err := Parse([]byte(`{"timezone": "Asia/Tokyo", "policies": [{"effect": "allow", "actions": ["*"], "when": {"attr": "time.hour", "op": "eq", "value": 9}}]}`))
+	assert.NoError(t, err)
+	assert.True(t, set.Decide(Input{Time: time.Date(2019, 9, 2, 0, 0, 0, 0, time.UTC)}).Allowed)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, doc := range []string{
+		`{"policies": [{"effect": "permit", "actions": ["*"]}]}`,
+		`{"policies": [{"effect": "allow"}]}`,
+		`{"policies": [{"effect": "allow", "actions": ["*"], "when": {"attr": "profile.salary", "op": "gt", "value": 1}}]}`,
+		`{"policies": [{"effect": "allow", "actions": ["*"], "when": {"attr": "mask", "op": "like", "value": 1}}]}`,
+		`{"policies": [{"effect": "allow", "actions": ["*"], "when": {"attr": "time.hour", "op": "between", "value": [9]}}]}`,
+		`{"policies": [{"effect": "allow", "actions": ["*"], "when": {"attr": "request.ip", "op": "cidr", "value": ["10.0.0.0"]}}]}`,
+		`{"policies": [{"effect": "allow", "actions": ["*"], "when": {"all": [{}]}}]}`,
+		`{"timezone": "Mars/Olympus", "policies": []}`,
+		`[]`,
+	} {
+		_, err := Parse([]byte(doc))
+		assert.Error(t, err, doc)
+	}
+}
+
+func TestEngine_Load(t *testing.T) {
+	e := NewEngine()
+	assert.False(t, e.Decide(editorInput()).Allowed)
+
+	assert.NoError(t, e.Load([]byte(businessHours)))
+	assert.True(t, e.Decide(editorInput()).Allowed)
+
+	//Invalid document keeps policies in force
+	assert.Error(t, e.Load([]byte(`{"policies": [{"effect": "permit"}]}`)))
+	assert.True(t, e.Decide(editorInput()).Allowed)
+
+	//Removed document removes policies
+	assert.NoError(t, e.Load(nil))
+	assert.False(t, e.Decide(editorInput()).Allowed)
+}
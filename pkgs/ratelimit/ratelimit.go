@@ -0,0 +1,292 @@
+package ratelimit
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"golang.org/x/time/rate"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+)
+
+//Limit is a token bucket: Rate tokens per second are added to the bucket holding at most Burst tokens.
+//Every request takes one token. Zero limit means there is no limit
+type Limit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+//Limits of an endpoint per client IP, per user and per calling service
+type Limits struct {
+	IP      Limit `json:"ip"`
+	User    Limit `json:"user"`
+	Service Limit `json:"service"`
+}
+
+//Config maps endpoint names to their limits. Endpoints that are not listed are not limited
+type Config struct {
+	Endpoints map[string]Limits `json:"endpoints"`
+	MaxKeys   int               `json:"max_keys"` //number of tracked buckets, the least recently used one makes room for a new one
+}
+
+//DefaultConfig returns limits used by the service unless overridden in Consul
+func DefaultConfig() Config {
+	return Config{
+		Endpoints: map[string]Limits{
+			"login": {
+				IP:   Limit{Rate: 1, Burst: 20},
+				User: Limit{Rate: 0.2, Burst: 5},
+			},
+			"login_mfa": {
+				IP: Limit{Rate: 1, Burst: 20},
+			},
+			"webauthn_login_begin": {
+				IP: Limit{Rate: 1, Burst: 20},
+			},
+			"webauthn_login_finish": {
+				IP: Limit{Rate: 1, Burst: 20},
+			},
+			"logout": {
+				IP: Limit{Rate: 5, Burst: 50},
+			},
+			"login_email": {
+				IP: Limit{Rate: 0.1, Burst: 10},
+			},
+			"login_email_redeem": {
+				IP: Limit{Rate: 0.5, Burst: 10},
+			},
+			"refresh": {
+				IP: Limit{Rate: 5, Burst: 50},
+			},
+			"check_token": {
+				IP:      Limit{Rate: 50, Burst: 100},
+				Service: Limit{Rate: 100, Burst: 200},
+			},
+			"auth": {
+				IP:      Limit{Rate: 50, Burst: 100},
+				Service: Limit{Rate: 100, Burst: 200},
+			},
+			"authorize": {
+				IP:      Limit{Rate: 50, Burst: 100},
+				Service: Limit{Rate: 100, Burst: 200},
+			},
+			"decide": {
+				IP:      Limit{Rate: 50, Burst: 100},
+				Service: Limit{Rate: 100, Burst: 200},
+			},
+			"check_tokens": {
+				IP:      Limit{Rate: 10, Burst: 20},
+				Service: Limit{Rate: 20, Burst: 40},
+			},
+		},
+		MaxKeys: 100000,
+	}
+}
+
+//ParseConfig reads JSON configuration. Endpoints listed in data replace default limits, others keep them
+func ParseConfig(data []byte) (Config, error) {
+	var parsed Config
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return Config{}, err
+	}
+
+	cfg := DefaultConfig()
+	for name, limits := range parsed.Endpoints {
+		cfg.Endpoints[name] = limits
+	}
+	if parsed.MaxKeys > 0 {
+		cfg.MaxKeys = parsed.MaxKeys
+	}
+
+	return cfg, nil
+}
+
+//State describes the most restrictive bucket that was checked for a request
+type State struct {
+	Limit     int
+	Remaining int
+	Reset     time.Duration
+}
+
+type contextKey int
+
+const (
+	keysContextKey contextKey = iota
+	stateContextKey
+)
+
+type keys struct {
+	ip      string
+	user    string
+	service string
+}
+
+//WithKeys returns context carrying keys a request is limited by. Empty keys are not limited
+func WithKeys(ctx context.Context, ip, user, service string) context.Context {
+	ctx = context.WithValue(ctx, keysContextKey, keys{ip: ip, user: user, service: service})
+	return context.WithValue(ctx, stateContextKey, &State{})
+}
+
+//WithUser returns context carrying the user key, unless the context already has one. It is used when the user is known
+//only from the request body
+func WithUser(ctx context.Context, user string) context.Context {
+	k, _ := ctx.Value(keysContextKey).(keys)
+	if len(k.user) != 0 {
+		return ctx
+	}
+
+	k.user = user
+	return context.WithValue(ctx, keysContextKey, k)
+}
+
+//StateFrom returns state of limits checked for the request, if any
+func StateFrom(ctx context.Context) (State, bool) {
+	state, ok := ctx.Value(stateContextKey).(*State)
+	if !ok || state.Limit == 0 {
+		return State{}, false
+	}
+
+	return *state, true
+}
+
+type bucket struct {
+	key     string
+	limiter *rate.Limiter
+	limit   Limit
+}
+
+//Limiter keeps token buckets of all endpoints and keys in memory
+type Limiter struct {
+	cfg Config
+	now func() time.Time
+
+	mtx     sync.Mutex
+	buckets map[string]*list.Element
+	lru     *list.List //buckets from the most recently used to the least recently used one
+}
+
+//NewLimiter creates limiter with the configuration. Default number of keys is used unless MaxKeys is set
+func NewLimiter(cfg Config) *Limiter {
+	if cfg.MaxKeys <= 0 {
+		cfg.MaxKeys = DefaultConfig().MaxKeys
+	}
+
+	return &Limiter{
+		cfg:     cfg,
+		now:     time.Now,
+		buckets: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+//Middleware limits requests to the named endpoint by keys found in request context. Rejected requests fail with
+//RetryError wrapping ErrRateLimited and do not take tokens from any bucket
+func (l *Limiter) Middleware(name string) endpoint.Middleware {
+	limits, ok := l.cfg.Endpoints[name]
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		if !ok {
+			return next
+		}
+
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			k, _ := ctx.Value(keysContextKey).(keys)
+			state, _ := ctx.Value(stateContextKey).(*State)
+
+			if retryAfter, allowed := l.take(name, limits, k, state); !allowed {
+				return nil, &errors.RetryError{Err: errors.ErrRateLimited, RetryAfter: retryAfter}
+			}
+
+			return next(ctx, request)
+		}
+	}
+}
+
+//take takes a token from every bucket of the request if all of them have one
+func (l *Limiter) take(name string, limits Limits, k keys, state *State) (time.Duration, bool) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	now := l.now()
+	var checked []*bucket
+	for _, kind := range []struct {
+		name  string
+		key   string
+		limit Limit
+	}{
+		{"ip", k.ip, limits.IP},
+		{"user", k.user, limits.User},
+		{"service", k.service, limits.Service},
+	} {
+		if len(kind.key) == 0 || kind.limit.Rate <= 0 {
+			continue
+		}
+		checked = append(checked, l.bucket(name+"|"+kind.name+"|"+kind.key, kind.limit))
+	}
+
+	var retryAfter time.Duration
+	for _, b := range checked {
+		if tokens := b.limiter.TokensAt(now); tokens < 1 {
+			if wait := seconds((1 - tokens) / b.limit.Rate); wait > retryAfter {
+				retryAfter = wait
+			}
+		}
+	}
+
+	allowed := retryAfter == 0
+	for _, b := range checked {
+		if allowed {
+			b.limiter.AllowN(now, 1)
+		}
+		report(state, b, now)
+	}
+
+	return retryAfter, allowed
+}
+
+//bucket returns bucket of the key, creating it if needed. The number of buckets is capped: at the cap the least recently
+//used bucket is dropped, so that memory is bounded and every request does constant work whatever keys come in
+func (l *Limiter) bucket(key string, limit Limit) *bucket {
+	if e, ok := l.buckets[key]; ok {
+		l.lru.MoveToFront(e)
+		return e.Value.(*bucket)
+	}
+
+	if l.lru.Len() >= l.cfg.MaxKeys {
+		oldest := l.lru.Back()
+		l.lru.Remove(oldest)
+		delete(l.buckets, oldest.Value.(*bucket).key)
+	}
+
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	b := &bucket{key: key, limiter: rate.NewLimiter(rate.Limit(limit.Rate), limit.Burst), limit: limit}
+	l.buckets[key] = l.lru.PushFront(b)
+	return b
+}
+
+//report saves bucket in state if it has fewer tokens left than the one saved before
+func report(state *State, b *bucket, now time.Time) {
+	if state == nil {
+		return
+	}
+
+	tokens := math.Max(b.limiter.TokensAt(now), 0)
+	remaining := int(math.Floor(tokens))
+	if state.Limit != 0 && remaining >= state.Remaining {
+		return
+	}
+
+	state.Limit = b.limit.Burst
+	state.Remaining = remaining
+	state.Reset = seconds((float64(b.limit.Burst) - tokens) / b.limit.Rate)
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,140 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+)
+
+func nop(ctx context.Context, request interface{}) (interface{}, error) {
+	return "ok", nil
+}
+
+func newTestLimiter() (*Limiter, *time.Time) {
+	now := time.Unix(1600000000, 0)
+	l := NewLimiter(Config{
+		Endpoints: map[string]Limits{
+			"login": {
+				IP:   Limit{Rate: 1, Burst: 3},
+				User: Limit{Rate: 0.5, Burst: 2},
+			},
+		},
+		MaxKeys: 10,
+	})
+	l.now = func() time.Time { return now }
+
+	return l, &now
+}
+
+func TestMiddleware_TokenBucket(t *testing.T) {
+	l, now := newTestLimiter()
+	login := l.Middleware("login")(nop)
+
+	ctx := WithKeys(context.Background(), "192.0.2.10", "user@email.com", "")
+	for i := 0; i < 2; i++ {
+		_, err := login(ctx, nil)
+		assert.NoError(t, err)
+	}
+
+	//The user bucket is empty while the IP one still has a token
+	ctx = WithKeys(context.Background(), "192.0.2.10", "user@email.com", "")
+	_, err := login(ctx, nil)
+	retry, ok := err.(*errors.RetryError)
+	assert.True(t, ok)
+	assert.Equal(t, errors.ErrRateLimited, retry.Err)
+	assert.Equal(t, 2*time.Second, retry.RetryAfter)
+
+	state, ok := StateFrom(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, State{Limit: 2, Remaining: 0, Reset: 4 * time.Second}, state)
+
+	//Rejected request took no token from the IP bucket
+	ctx = WithKeys(context.Background(), "192.0.2.10", "other@email.com", "")
+	_, err = login(ctx, nil)
+	assert.NoError(t, err)
+	state, _ = StateFrom(ctx)
+	assert.Equal(t, 0, state.Remaining)
+
+	//Buckets are refilled over time
+	*now = now.Add(2 * time.Second)
+	_, err = login(WithKeys(context.Background(), "192.0.2.10", "user@email.com", ""), nil)
+	assert.NoError(t, err)
+}
+
+func TestMiddleware_NotConfigured(t *testing.T) {
+	l, _ := newTestLimiter()
+	logout := l.Middleware("logout")(nop)
+
+	ctx := WithKeys(context.Background(), "192.0.2.10", "", "")
+	for i := 0; i < 100; i++ {
+		_, err := logout(ctx, nil)
+		assert.NoError(t, err)
+	}
+
+	_, ok := StateFrom(ctx)
+	assert.False(t, ok)
+}
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`{"endpoints": {"check_token": {"service": {"rate": 10, "burst": 20}}}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, Limits{Service: Limit{Rate: 10, Burst: 20}}, cfg.Endpoints["check_token"])
+	assert.Equal(t, DefaultConfig().Endpoints["login"], cfg.Endpoints["login"])
+
+	_, err = ParseConfig([]byte(`{"endpoints": [`))
+	assert.Error(t, err)
+}
+
+func TestWithUser_LimitsByBodyUser(t *testing.T) {
+	l, _ := newTestLimiter()
+	login := l.Middleware("login")(nop)
+
+	//User from Basic auth takes precedence over the one from the body
+	ctx := WithUser(WithKeys(context.Background(), "192.0.2.10", "user@email.com", ""), "other@email.com")
+	for i := 0; i < 2; i++ {
+		_, err := login(ctx, nil)
+		assert.NoError(t, err)
+	}
+
+	ctx = WithUser(WithKeys(context.Background(), "192.0.2.11", "", ""), "user@email.com")
+	_, err := login(ctx, nil)
+	assert.Error(t, err)
+
+	state, ok := StateFrom(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, 2, state.Limit)
+}
+
+func TestLimiter_MaxKeys(t *testing.T) {
+	l, _ := newTestLimiter()
+	login := l.Middleware("login")(nop)
+
+	//Every request brings a new key, the number of buckets stays at the cap
+	for i := 0; i < 100; i++ {
+		_, err := login(WithKeys(context.Background(), fmt.Sprintf("192.0.2.%d", i), "", ""), nil)
+		assert.NoError(t, err)
+	}
+	assert.Len(t, l.buckets, 10)
+	assert.Equal(t, 10, l.lru.Len())
+
+	//Recently used buckets are kept, the least recently used ones make room
+	_, ok := l.buckets["login|ip|192.0.2.99"]
+	assert.True(t, ok)
+	_, ok = l.buckets["login|ip|192.0.2.0"]
+	assert.False(t, ok)
+}
+
+func TestDefaultConfig_UnauthenticatedEndpoints(t *testing.T) {
+	//Endpoints reachable without access token are limited by default
+	for _, name := range []string{"login", "login_mfa", "login_email", "login_email_redeem", "webauthn_login_begin",
+		"webauthn_login_finish", "refresh", "logout", "check_token", "check_tokens", "auth", "authorize", "decide"} {
+		limits, ok := DefaultConfig().Endpoints[name]
+		assert.True(t, ok, name)
+		assert.True(t, limits.IP.Rate > 0, name)
+	}
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/permissions"
+)
+
+//WithPermissions names bits of role masks. Only admin permission is known by default
+func WithPermissions(r *permissions.Registry) Option {
+	return func(svc *SessionsService) {
+		svc.permissions = r
+	}
+}
+
+//Authorize checks that access token is valid and grants all or any of the named permissions. Claims of the token are
+//returned, so that the caller does not need to check it once again
+func (svc *SessionsService) Authorize(ctx context.Context, ad models.AuthorizeData) (res models.CheckTokenServiceOutput, err error) {
+	claims, err := svc.describeToken(ad.AccessToken)
+	if err != nil {
+		return res, err
+	}
+
+	allowed, err := svc.permissions.Allows(claims.Mask, ad.Permissions, ad.Mode)
+	if err != nil {
+		return res, err
+	}
+	if !allowed {
+		return res, errors.ErrNoPermissions
+	}
+
+	return claims, nil
+}
+
+//requireAdmin checks that the mask grants admin permission. The bit of admin is looked up in the registry, so that admin
+//operations follow configured permissions
+func (svc *SessionsService) requireAdmin(mask int64) error {
+	allowed, err := svc.permissions.Allows(mask, []string{permissions.Admin}, permissions.All)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errors.ErrNoPermissions
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/constants"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+)
+
+//CheckTokens validates a batch of access tokens, so that a gateway needs one round trip for many requests. Tokens are
+//only validated, never refreshed, and an invalid token does not fail the batch: its verdict holds the error instead.
+//Verdicts are in the order of tokens
+func (svc *SessionsService) CheckTokens(ctx context.Context, cd models.CheckTokensData) (res []models.TokenVerdict, err error) {
+	if len(cd.AccessTokens) > constants.MaxTokensPerBatch {
+		return nil, errors.ErrBatchTooLarge
+	}
+
+	res = make([]models.TokenVerdict, len(cd.AccessTokens))
+	indexes := make(chan int)
+
+	workers := runtime.NumCPU()
+	if workers > len(cd.AccessTokens) {
+		workers = len(cd.AccessTokens)
+	}
+
+	//Each worker writes verdicts of its own tokens only, and validation reads nothing but the secret and permissions
+	//registry, so that tokens are evaluated concurrently without locking
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				res[i] = svc.verdictOf(cd.AccessTokens[i])
+			}
+		}()
+	}
+
+	for i := range cd.AccessTokens {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (svc *SessionsService) verdictOf(token string) models.TokenVerdict {
+	claims, err := svc.describeToken(token)
+	if err != nil {
+		return models.TokenVerdict{Err: err}
+	}
+
+	return models.TokenVerdict{Valid: true, Claims: &claims}
+}
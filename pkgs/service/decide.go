@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/policy"
+)
+
+//WithPolicies makes decisions with the policy engine. Without policies every action is refused
+func WithPolicies(e *policy.Engine) Option {
+	return func(svc *SessionsService) {
+		svc.policies = e
+	}
+}
+
+//Decide evaluates attribute based policies for an action of the user of access token. Claims of the token, profile of
+//the user, attributes of the request and current time are available to policies
+func (svc *SessionsService) Decide(ctx context.Context, dd models.DecideData) (res models.Decision, err error) {
+	claims, err := svc.describeToken(dd.AccessToken)
+	if err != nil {
+		return res, err
+	}
+
+	profile, err := svc.Users.GetUserProfile(ctx, claims.Subject)
+	if err != nil {
+		svc.Logger.Log("method", "GetUserProfile", "action", "retrieving user profile", "error", err)
+		return res, err
+	}
+
+	return svc.policies.Decide(policy.Input{
+		Subject:     claims.Subject,
+		Mask:        claims.Mask,
+		Permissions: claims.Permissions,
+		SessionID:   claims.SessionID,
+		Profile:     profile,
+		Action:      dd.Action,
+		Resource:    dd.Resource,
+		IP:          dd.IP,
+		Context:     dd.Context,
+		Time:        time.Now(),
+	}), nil
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/constants"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+)
+
+//notificationTimeout bounds delivery of a security event, so that a slow sink does not pile up goroutines
+const notificationTimeout = 10 * time.Second
+
+//WithNotifier sends security events, e.g. logins from new devices, to the notifier
+func WithNotifier(n models.INotifier) Option {
+	return func(svc *SessionsService) {
+		svc.notifier = n
+	}
+}
+
+//DeviceID identifies the combination of client software and address a user logs in from
+func DeviceID(userAgent, ip string) string {
+	sum := sha256.Sum256([]byte(userAgent + "\n" + ip))
+	return hex.EncodeToString(sum[:])
+}
+
+//trackDevice remembers the device of a successful login. If the user has logged in before, but never from this device,
+//the user is notified. The very first login is not reported, as every device is new then
+func (svc *SessionsService) trackDevice(sub, clientIP, userAgent string) {
+	//User names are case insensitive
+	sub = strings.ToLower(sub)
+	devices, err := svc.Db.KnownDevices(sub)
+	if err != nil {
+		svc.Logger.Log("method", "Login", "action", "retrieving known devices", "error", err)
+		return
+	}
+
+	now := time.Now()
+	deviceID := DeviceID(userAgent, clientIP)
+	if err = svc.Db.SaveDevice(sub, deviceID, now.Unix()); err != nil {
+		svc.Logger.Log("method", "Login", "action", "saving device", "error", err)
+		return
+	}
+
+	if _, known := devices[deviceID]; known || len(devices) == 0 || svc.notifier == nil {
+		return
+	}
+
+	event := models.SecurityEvent{
+		Type:      constants.NewDeviceEvent,
+		UserID:    sub,
+		IP:        clientIP,
+		UserAgent: userAgent,
+		Time:      now.Unix(),
+	}
+	svc.Logger.Log("method", "Login", "action", "login from new device", "user", sub, "ip", clientIP)
+
+	//Login does not wait for delivery
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), notificationTimeout)
+		defer cancel()
+
+		if err := svc.notifier.Notify(ctx, event); err != nil {
+			svc.Logger.Log("method", "Login", "action", "notifying about new device", "user", sub, "error", err)
+		}
+	}()
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/constants"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+)
+
+const (
+	//emailLoginTTL is how long a code and a magic link stay valid
+	emailLoginTTL = 10 * time.Minute
+	//emailLoginInterval is how often a new code may be requested for the same email
+	emailLoginInterval = time.Minute
+	//emailLoginAttempts is how many wrong codes make the pending login invalid
+	emailLoginAttempts = 5
+	//emailThrottlingPrefix distinguishes failed email codes from failed passwords of the same user
+	emailThrottlingPrefix = "email:"
+	//emailSendTimeout bounds delivery of a login code, so that a slow mail server does not pile up goroutines
+	emailSendTimeout = 30 * time.Second
+)
+
+//WithMailer enables passwordless login with codes sent by email
+func WithMailer(m models.IMailer) Option {
+	return func(svc *SessionsService) {
+		svc.mailer = m
+	}
+}
+
+//RequestEmailLogin sends a one-time code and a magic link to the email. Unknown emails get no mail but are answered and
+//throttled the same way, so that the response does not reveal which accounts exist. For the same reason mail is sent in
+//the background: the answer does not wait for the mail server, and a failed delivery is logged only. A new code
+//replaces the previous one
+func (svc *SessionsService) RequestEmailLogin(ctx context.Context, ld models.EmailLoginData) error {
+	if svc.mailer == nil {
+		return errors.ErrMailUnavailable
+	}
+
+	email := normalizeUser(ld.Email)
+	if len(email) == 0 {
+		return errors.ErrMalformedBody
+	}
+
+	now := time.Now()
+	svc.sweepEmailLogins(now)
+	if record, err := svc.Db.GetEmailLogin(email); err == nil {
+		if wait := time.Unix(record.CreatedAt, 0).Add(emailLoginInterval).Sub(now); wait > 0 {
+			return &errors.RetryError{Err: errors.ErrRateLimited, RetryAfter: wait}
+		}
+	}
+
+	profile, err := svc.Users.GetUserProfile(ctx, email)
+	if err == errors.ErrClientUnknown {
+		svc.Logger.Log("method", "RequestEmailLogin", "action", "retrieving user profile", "user", email, "error", err)
+		//Pending login without hashes only holds back the next request, no code matches it. It is not needed after that
+		return svc.Db.SaveEmailLogin(models.EmailLogin{
+			Email:          email,
+			CreatedAt:      now.Unix(),
+			ExpirationDate: now.Add(emailLoginInterval).Unix(),
+		})
+	}
+	if err != nil {
+		svc.Logger.Log("method", "GetUserProfile", "action", "retrieving user profile", "error", err)
+		return err
+	}
+
+	code, err := generateEmailCode()
+	if err != nil {
+		return err
+	}
+	token, err := GenerateID()
+	if err != nil {
+		return err
+	}
+
+	err = svc.Db.SaveEmailLogin(models.EmailLogin{
+		Email:          email,
+		CodeHash:       hashEmailCode(code),
+		LinkHash:       hashEmailCode(token),
+		CreatedAt:      now.Unix(),
+		ExpirationDate: now.Add(emailLoginTTL).Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := constants.MagicLinkURL + "?" + url.Values{"email": {email}, "code": {token}}.Encode()
+	body := fmt.Sprintf("Your EDMS login code is %s\n\nOr follow the link to log in:\n%s\n\n"+
+		"The code and the link expire in %d minutes and can be used once. If you did not ask for them, ignore this email.\n",
+		code, link, int(emailLoginTTL/time.Minute))
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), emailSendTimeout)
+		defer cancel()
+
+		if err := svc.mailer.Send(ctx, profile.Email, "Your EDMS login code", body); err != nil {
+			svc.Logger.Log("method", "RequestEmailLogin", "action", "sending login code", "user", email, "error", err)
+			svc.Db.DeleteEmailLogin(email)
+		}
+	}()
+
+	return nil
+}
+
+//sweepEmailLogins removes expired pending logins, so that logins that are never redeemed, e.g. of unknown emails, do
+//not pile up. It runs at most once per emailLoginInterval
+func (svc *SessionsService) sweepEmailLogins(now time.Time) {
+	last := atomic.LoadInt64(&svc.emailSweptAt)
+	if now.Sub(time.Unix(last, 0)) < emailLoginInterval || !atomic.CompareAndSwapInt64(&svc.emailSweptAt, last, now.Unix()) {
+		return
+	}
+
+	if err := svc.Db.DeleteExpiredEmailLogins(now.Unix()); err != nil {
+		svc.Logger.Log("method", "RequestEmailLogin", "action", "removing expired logins", "error", err)
+	}
+}
+
+//RedeemEmailLogin exchanges a one-time code or magic link token for a pair of tokens. Both are single-use: the pending
+//login is removed once redeemed or after too many wrong codes. Failures are throttled as failed passwords. The code proves
+//the email only, so users with MFA enabled get a challenge instead of tokens, as on login with password
+func (svc *SessionsService) RedeemEmailLogin(ctx context.Context, ld models.EmailLoginRedeemData) (resAccess, resRefresh models.TokenData, err error) {
+	email := normalizeUser(ld.Email)
+	user := emailThrottlingPrefix + email
+	err = svc.attempt(user, ld.ClientIP, func() error {
+		err := svc.consumeEmailCode(email, ld.Code)
+		if err != nil {
+			svc.Logger.Log("method", "RedeemEmailLogin", "action", "checking email code", "user", email, "error", err)
+		}
+		return err
+	}, errors.ErrInvalidEmailCode)
+	if err != nil {
+		return resAccess, resRefresh, err
+	}
+
+	profile, err := svc.Users.GetUserProfile(ctx, email)
+	if err != nil {
+		svc.Logger.Log("method", "GetUserProfile", "action", "retrieving user profile", "error", err)
+		return resAccess, resRefresh, err
+	}
+
+	if resAccess, required, err := svc.mfaChallenge(email, ld.DeviceToken, profile.Role.Mask); err != nil || required {
+		return resAccess, models.TokenData{}, err
+	}
+
+	return svc.issueTokens(email, profile.Role, ld.ClientIP, ld.UserAgent)
+}
+
+//consumeEmailCode checks the code against pending login of the email and removes the login if the code matches,
+//expired or was guessed wrong too many times
+func (svc *SessionsService) consumeEmailCode(email, code string) error {
+	record, err := svc.Db.GetEmailLogin(email)
+	if err != nil {
+		return errors.ErrInvalidEmailCode
+	}
+
+	if record.ExpirationDate <= time.Now().Unix() {
+		svc.Db.DeleteEmailLogin(email)
+		return errors.ErrInvalidEmailCode
+	}
+
+	hash := []byte(hashEmailCode(code))
+	if subtle.ConstantTimeCompare(hash, []byte(record.CodeHash)) == 1 || subtle.ConstantTimeCompare(hash, []byte(record.LinkHash)) == 1 {
+		return svc.Db.DeleteEmailLogin(email)
+	}
+
+	record.Attempts++
+	if record.Attempts >= emailLoginAttempts {
+		svc.Db.DeleteEmailLogin(email)
+	} else {
+		svc.Db.SaveEmailLogin(record)
+	}
+
+	return errors.ErrInvalidEmailCode
+}
+
+//generateEmailCode returns random 6 digit code
+func generateEmailCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+//hashEmailCode returns a hash under which a code or a link token is stored. Spaces are ignored
+func hashEmailCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.Replace(code, " ", "", -1)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+)
+
+const (
+	//RejectPolicy rejects login if user already has the maximum number of sessions
+	RejectPolicy = "reject"
+	//EvictOldestPolicy ends the oldest sessions of user to make room for a new one. Eviction revokes refresh token of
+	//the session only: access tokens already issued stay valid until they expire, which bounds how long it lasts
+	EvictOldestPolicy = "evict_oldest"
+)
+
+//SessionLimits caps the number of simultaneous sessions of a user. Max applies to all users unless Roles holds a cap
+//for the role of the user. Zero cap means no limit
+type SessionLimits struct {
+	Max    int            `json:"max"`
+	Roles  map[string]int `json:"roles"`
+	Policy string         `json:"policy"`
+}
+
+//ParseSessionLimits reads JSON configuration of session limits. Policy defaults to reject
+func ParseSessionLimits(data []byte) (SessionLimits, error) {
+	var limits SessionLimits
+	if err := json.Unmarshal(data, &limits); err != nil {
+		return SessionLimits{}, err
+	}
+
+	switch limits.Policy {
+	case "":
+		limits.Policy = RejectPolicy
+	case RejectPolicy, EvictOldestPolicy:
+	default:
+		return SessionLimits{}, fmt.Errorf("unknown session limit policy %q", limits.Policy)
+	}
+
+	return limits, nil
+}
+
+//For returns the cap of a role
+func (l SessionLimits) For(role string) int {
+	for name, max := range l.Roles {
+		if strings.EqualFold(name, role) {
+			return max
+		}
+	}
+
+	return l.Max
+}
+
+//Option configures session service
+type Option func(*SessionsService)
+
+//WithSessionLimits caps the number of simultaneous sessions per user
+func WithSessionLimits(limits SessionLimits) Option {
+	return func(svc *SessionsService) {
+		svc.limits = limits
+	}
+}
+
+//saveSession saves refresh token of a new session once the session limit of the user allows it. The limit is checked and
+//the token is saved under the lock of the user, so that concurrent logins cannot exceed the cap. The lock belongs to
+//this instance, so instances sharing the database are serialised by the database only
+func (svc *SessionsService) saveSession(sub, role, token string) error {
+	lock := svc.sessionLocks.of(sub)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := svc.enforceSessionLimit(sub, role); err != nil {
+		svc.Logger.Log("method", "Login", "action", "checking session limit", "user", sub, "error", err)
+		return err
+	}
+
+	return svc.Db.Save(sub, token)
+}
+
+//enforceSessionLimit makes room for a new session of the user according to the policy. Expired sessions are removed
+//first, as they do not count
+func (svc *SessionsService) enforceSessionLimit(sub, role string) error {
+	max := svc.limits.For(role)
+	if max <= 0 {
+		return nil
+	}
+
+	tokens, err := svc.Db.List(sub)
+	if err != nil {
+		return err
+	}
+
+	active := tokens[:0:0]
+	for _, token := range tokens {
+		claims, err := svc.CheckTokenValidness(token)
+		if err == nil {
+			var expired bool
+			if expired, err = IsExpired(claims); err == nil && !expired {
+				active = append(active, token)
+				continue
+			}
+		}
+		svc.Db.Delete(sub, token)
+	}
+
+	if len(active) < max {
+		return nil
+	}
+
+	if svc.limits.Policy != EvictOldestPolicy {
+		return errors.ErrTooManySessions
+	}
+
+	for _, token := range active[:len(active)-max+1] {
+		svc.Logger.Log("method", "Login", "action", "evict oldest session", "user", sub)
+		if _, err = svc.Db.Delete(sub, token); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,19 @@
+package service
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+//lockStripes is the number of locks keys of one kind are serialised with
+const lockStripes = 64
+
+//keyLocks serialises operations on the same key. Keys share a fixed number of locks, so that there is nothing to clean up
+type keyLocks [lockStripes]sync.Mutex
+
+//of returns the lock of the key
+func (l *keyLocks) of(key string) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return &l[h.Sum32()%lockStripes]
+}
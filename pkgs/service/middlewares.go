@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/go-kit/kit/log"
@@ -28,7 +29,7 @@ func LoggingMiddleware(logger log.Logger) Middleware {
 
 func (lmw loggingMiddleware) Login(ctx context.Context, ld models.LoginData) (resA models.TokenData, resR models.TokenData, err error) {
 	defer func(begin time.Time) {
-		lmw.logger.Log("method", "Login", "took", time.Since(begin), "err", err)
+		lmw.logger.Log("method", "Login", "client_id", ld.ClientID, "took", time.Since(begin), "err", err)
 	}(time.Now())
 	return lmw.next.Login(ctx, ld)
 }
@@ -46,3 +47,115 @@ func (lmw loggingMiddleware) CheckToken(ctx context.Context, ctd models.CheckTok
 	}(time.Now())
 	return lmw.next.CheckToken(ctx, ctd)
 }
+
+func (lmw loggingMiddleware) CheckTokens(ctx context.Context, cd models.CheckTokensData) (res []models.TokenVerdict, err error) {
+	defer func(begin time.Time) {
+		lmw.logger.Log("method", "CheckTokens", "tokens", len(cd.AccessTokens), "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return lmw.next.CheckTokens(ctx, cd)
+}
+
+func (lmw loggingMiddleware) Authorize(ctx context.Context, ad models.AuthorizeData) (res models.CheckTokenServiceOutput, err error) {
+	defer func(begin time.Time) {
+		lmw.logger.Log("method", "Authorize", "permissions", strings.Join(ad.Permissions, ","), "mode", ad.Mode, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return lmw.next.Authorize(ctx, ad)
+}
+
+func (lmw loggingMiddleware) Decide(ctx context.Context, dd models.DecideData) (res models.Decision, err error) {
+	defer func(begin time.Time) {
+		lmw.logger.Log("method", "Decide", "action", dd.Action, "resource", dd.Resource, "allowed", res.Allowed, "policy", res.Policy, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return lmw.next.Decide(ctx, dd)
+}
+
+func (lmw loggingMiddleware) LoginMFA(ctx context.Context, ld models.LoginMFAData) (resA models.TokenData, resR models.TokenData, resD models.TokenData, err error) {
+	defer func(begin time.Time) {
+		lmw.logger.Log("method", "LoginMFA", "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return lmw.next.LoginMFA(ctx, ld)
+}
+
+func (lmw loggingMiddleware) EnrollTOTP(ctx context.Context, ed models.EnrollTOTPData) (res models.TOTPEnrollment, err error) {
+	defer func(begin time.Time) {
+		lmw.logger.Log("method", "EnrollTOTP", "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return lmw.next.EnrollTOTP(ctx, ed)
+}
+
+func (lmw loggingMiddleware) ConfirmTOTP(ctx context.Context, cd models.ConfirmTOTPData) (err error) {
+	defer func(begin time.Time) {
+		lmw.logger.Log("method", "ConfirmTOTP", "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return lmw.next.ConfirmTOTP(ctx, cd)
+}
+
+func (lmw loggingMiddleware) RegenerateRecoveryCodes(ctx context.Context, rd models.RecoveryCodesData) (res models.RecoveryCodes, err error) {
+	defer func(begin time.Time) {
+		lmw.logger.Log("method", "RegenerateRecoveryCodes", "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return lmw.next.RegenerateRecoveryCodes(ctx, rd)
+}
+
+func (lmw loggingMiddleware) ResetMFA(ctx context.Context, rd models.ResetMFAData) (err error) {
+	defer func(begin time.Time) {
+		lmw.logger.Log("method", "ResetMFA", "took", time.Since(begin), "user", rd.UserName, "err", err)
+	}(time.Now())
+	return lmw.next.ResetMFA(ctx, rd)
+}
+
+func (lmw loggingMiddleware) BeginWebAuthnRegistration(ctx context.Context, rd models.WebAuthnRegistrationData) (res models.WebAuthnCreationOptions, err error) {
+	defer func(begin time.Time) {
+		lmw.logger.Log("method", "BeginWebAuthnRegistration", "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return lmw.next.BeginWebAuthnRegistration(ctx, rd)
+}
+
+func (lmw loggingMiddleware) FinishWebAuthnRegistration(ctx context.Context, rd models.WebAuthnRegistrationFinishData) (err error) {
+	defer func(begin time.Time) {
+		lmw.logger.Log("method", "FinishWebAuthnRegistration", "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return lmw.next.FinishWebAuthnRegistration(ctx, rd)
+}
+
+func (lmw loggingMiddleware) BeginWebAuthnLogin(ctx context.Context, ld models.WebAuthnLoginData) (res models.WebAuthnRequestOptions, err error) {
+	defer func(begin time.Time) {
+		lmw.logger.Log("method", "BeginWebAuthnLogin", "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return lmw.next.BeginWebAuthnLogin(ctx, ld)
+}
+
+func (lmw loggingMiddleware) FinishWebAuthnLogin(ctx context.Context, ld models.WebAuthnLoginFinishData) (resA models.TokenData, resR models.TokenData, err error) {
+	defer func(begin time.Time) {
+		lmw.logger.Log("method", "FinishWebAuthnLogin", "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return lmw.next.FinishWebAuthnLogin(ctx, ld)
+}
+
+func (lmw loggingMiddleware) UnlockLogin(ctx context.Context, ud models.UnlockLoginData) (err error) {
+	defer func(begin time.Time) {
+		lmw.logger.Log("method", "UnlockLogin", "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return lmw.next.UnlockLogin(ctx, ud)
+}
+
+func (lmw loggingMiddleware) RequestEmailLogin(ctx context.Context, ld models.EmailLoginData) (err error) {
+	defer func(begin time.Time) {
+		lmw.logger.Log("method", "RequestEmailLogin", "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return lmw.next.RequestEmailLogin(ctx, ld)
+}
+
+func (lmw loggingMiddleware) RedeemEmailLogin(ctx context.Context, ld models.EmailLoginRedeemData) (resA models.TokenData, resR models.TokenData, err error) {
+	defer func(begin time.Time) {
+		lmw.logger.Log("method", "RedeemEmailLogin", "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return lmw.next.RedeemEmailLogin(ctx, ld)
+}
+
+func (lmw loggingMiddleware) Refresh(ctx context.Context, rd models.RefreshData) (resA models.TokenData, resR models.TokenData, err error) {
+	defer func(begin time.Time) {
+		lmw.logger.Log("method", "Refresh", "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return lmw.next.Refresh(ctx, rd)
+}
@@ -2,14 +2,22 @@ package service
 
 import (
 	"context"
-	"net/http"
+	"crypto/subtle"
+	"strings"
+	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/go-kit/kit/log"
 
 	"github.com/Soroka-EDMS/svc/sessions/pkgs/config"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/constants"
 	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/lockout"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/mfa"
 	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/permissions"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/policy"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/webauthn"
 )
 
 type TokenType int
@@ -17,73 +25,407 @@ type TokenType int
 const (
 	access TokenType = iota
 	refresh
+	service
+	challenge
+	device
 )
 
+//mfaThrottlingPrefix distinguishes failed MFA codes from failed passwords of the same user
+const mfaThrottlingPrefix = "mfa:"
+
 type SessionsService struct {
 	Db     models.ISessionDatabase
-	client *http.Client
+	Users  models.IUsersClient
 	secret []byte
-	Logger log.Logger
+	cipher *mfa.Cipher
+	rp     *webauthn.RelyingParty
+	guard  *lockout.Guard
+	limits SessionLimits
+	//sessionLocks serialise session limits, see saveSession
+	sessionLocks keyLocks
+	//mfaLocks serialise changes of MFA settings, see updateMFA
+	mfaLocks    keyLocks
+	permissions *permissions.Registry
+	policies    *policy.Engine
+	notifier    models.INotifier
+	mailer      models.IMailer
+	//emailSweptAt is when expired pending email logins were removed last, see sweepEmailLogins
+	emailSweptAt int64
+	Logger       log.Logger
 }
 
-//NewSessionsService creates session service
-func NewSessionsService(db models.ISessionDatabase, s, key []byte) models.ISessionService {
-	cl, _ := MakeHTTPClient(key)
+//NewSessionsService creates session service. It fails if there is no secret to sign tokens with or no key to encrypt MFA secrets with
+func NewSessionsService(db models.ISessionDatabase, s, mfaKey []byte, users models.IUsersClient, opts ...Option) (models.ISessionService, error) {
+	if len(s) == 0 {
+		return nil, errors.ErrSigningSecretMissing
+	}
 
-	return &SessionsService{
-		Db:     db,
-		client: cl,
-		secret: s,
-		Logger: config.GetLogger().Logger,
+	c, err := mfa.NewCipher(mfaKey)
+	if err != nil {
+		return nil, err
 	}
+
+	svc := &SessionsService{
+		Db:          db,
+		Users:       users,
+		secret:      s,
+		cipher:      c,
+		rp:          webauthn.NewRelyingParty(constants.WebAuthnRPID, constants.WebAuthnRPName, constants.WebAuthnOrigin),
+		guard:       lockout.NewGuard(lockout.DefaultConfig()),
+		permissions: permissions.DefaultRegistry(),
+		policies:    policy.NewEngine(),
+		Logger:      config.GetLogger().Logger,
+	}
+	for _, opt := range opts {
+		opt(svc)
+	}
+
+	return svc, nil
 }
 
 //Build creates session service with middleware
-func Build(logger log.Logger, db models.ISessionDatabase, secret, pKey []byte) models.ISessionService {
+func Build(logger log.Logger, db models.ISessionDatabase, secret, mfaKey []byte, users models.IUsersClient, opts ...Option) (models.ISessionService, error) {
 	var svc models.ISessionService
 	{
-		svc = NewSessionsService(db, secret, pKey)
+		s, err := NewSessionsService(db, secret, mfaKey, users, opts...)
+		if err != nil {
+			return nil, err
+		}
+		svc = s
 		svc = LoggingMiddleware(logger)(svc)
 	}
 
-	return svc
+	return svc, nil
 }
 
 //Login handles login requets
 func (svc *SessionsService) Login(ctx context.Context, ld models.LoginData) (resAccess, resRefresh models.TokenData, err error) {
-	//1. Authentificate user
-	err = svc.EnsureUserCreds(ld.UserName, ld.Password)
+	//1. Authentificate user unless the user name or the client is throttled after failed attempts. User names are case
+	//insensitive, so the normalised one keys everything from here on: MFA settings, tokens and sessions
+	user := normalizeUser(ld.UserName)
+	err = svc.attempt(user, ld.ClientIP, func() error {
+		err := svc.Users.EnsureUserCreds(ctx, ld.UserName, ld.Password)
+		if err != nil {
+			svc.Logger.Log("method", "EnsureUserCreds", "action", "checking user credentials", "error", err)
+		}
+		return err
+	}, errors.ErrNonAuthorized, errors.ErrClientUnknown)
 	if err != nil {
-		svc.Logger.Log("method", "EnsureUserCreds", "action", "checking user credentials", "error", err)
 		return resAccess, resRefresh, err
 	}
 
 	//2. Get user profile
-	profile, err := svc.GetUserProfile(ld.UserName, string(svc.secret))
+	profile, err := svc.Users.GetUserProfile(ctx, user)
+	if err != nil {
+		svc.Logger.Log("method", "GetUserProfile", "action", "retrieving user profile", "error", err)
+		return resAccess, resRefresh, err
+	}
+
+	//3. Users with MFA enabled get a short-lived challenge instead of tokens unless they log in from a remembered device.
+	//The challenge is exchanged for tokens in LoginMFA
+	if resAccess, required, err := svc.mfaChallenge(user, ld.DeviceToken, profile.Role.Mask); err != nil || required {
+		return resAccess, models.TokenData{}, err
+	}
+
+	//4. Create tokens, save refresh token in db and tell the user about login from a new device
+	return svc.issueTokens(user, profile.Role, ld.ClientIP, ld.UserAgent)
+}
+
+//normalizeUser returns the form of user name sessions are kept under. Users service compares names case-insensitively,
+//so differently typed names of one user must not end up with separate MFA settings or sessions
+func normalizeUser(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+//mfaChallenge returns a challenge token if the user has to pass MFA before getting tokens. Only ErrMFANotEnrolled means
+//there is no second factor: any other failure to read MFA settings refuses login, so that the second factor is never
+//skipped
+func (svc *SessionsService) mfaChallenge(user, deviceToken string, mask int64) (res models.TokenData, required bool, err error) {
+	record, err := svc.Db.GetMFA(user)
+	switch {
+	case err == errors.ErrMFANotEnrolled:
+		return res, false, nil
+	case err != nil:
+		svc.Logger.Log("method", "mfaChallenge", "action", "reading MFA settings", "error", err)
+		return res, false, err
+	case !record.Confirmed || svc.isTrustedDevice(user, deviceToken, record):
+		return res, false, nil
+	}
+
+	res, err = svc.GenerateToken(challenge, user, mask)
+	if err != nil {
+		svc.Logger.Log("method", "GetToken", "action", "generate challenge token", "error", err)
+	}
+	return res, true, err
+}
+
+//LoginMFA exchanges a challenge token and TOTP or recovery code for a pair of tokens. If asked, the device is remembered
+//and a device token is returned as well
+func (svc *SessionsService) LoginMFA(ctx context.Context, ld models.LoginMFAData) (resAccess, resRefresh, resDevice models.TokenData, err error) {
+	sub, _, err := svc.ParseChallenge(ld.ChallengeToken)
+	if err != nil {
+		svc.Logger.Log("method", "LoginMFA", "action", "checking challenge token", "error", err)
+		return resAccess, resRefresh, resDevice, err
+	}
+
+	//MFA codes are short, so guessing them is throttled the same way as guessing passwords. The key differs from
+	//the one of passwords, so that a correct password does not reset failures of MFA codes
+	user := mfaThrottlingPrefix + normalizeUser(sub)
+	err = svc.attempt(user, "", func() error {
+		err := svc.verifyTOTP(sub, ld.Code, true)
+		if err == errors.ErrInvalidMFACode {
+			err = svc.consumeRecoveryCode(sub, ld.Code)
+		}
+		if err != nil {
+			svc.Logger.Log("method", "LoginMFA", "action", "checking MFA code", "error", err)
+		}
+		return err
+	}, errors.ErrInvalidMFACode)
+	if err != nil {
+		return resAccess, resRefresh, resDevice, err
+	}
+
+	profile, err := svc.Users.GetUserProfile(ctx, sub)
 	if err != nil {
 		svc.Logger.Log("method", "GetUserProfile", "action", "retrieving user profile", "error", err)
+		return resAccess, resRefresh, resDevice, err
+	}
+
+	if ld.RememberDevice {
+		if resDevice, err = svc.rememberDevice(sub); err != nil {
+			svc.Logger.Log("method", "LoginMFA", "action", "remember device", "error", err)
+			return resAccess, resRefresh, resDevice, err
+		}
+	}
+
+	resAccess, resRefresh, err = svc.issueTokens(sub, profile.Role, ld.ClientIP, ld.UserAgent)
+	return resAccess, resRefresh, resDevice, err
+}
+
+//EnrollTOTP starts TOTP enrollment: generates a secret and stores it encrypted until it is confirmed with a code
+func (svc *SessionsService) EnrollTOTP(ctx context.Context, ed models.EnrollTOTPData) (res models.TOTPEnrollment, err error) {
+	sub, _, err := svc.Authenticate(ed.AccessToken)
+	if err != nil {
+		return res, err
+	}
+
+	secret, err := mfa.GenerateSecret()
+	if err != nil {
+		return res, err
+	}
+
+	encrypted, err := svc.cipher.Encrypt([]byte(secret))
+	if err != nil {
+		return res, err
+	}
+
+	//Enrollment must not overwrite settings confirmed meanwhile, so the check and the save share the lock
+	lock := svc.mfaLocks.of(sub)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if record, err := svc.Db.GetMFA(sub); err == nil && record.Confirmed {
+		return res, errors.ErrMFAAlreadyEnabled
+	}
+
+	if err = svc.Db.SaveMFA(sub, models.MFARecord{Secret: encrypted}); err != nil {
+		return res, err
+	}
+
+	res.Secret = secret
+	res.URI = mfa.KeyURI(constants.TOTPIssuer, sub, secret)
+	return res, nil
+}
+
+//ConfirmTOTP completes TOTP enrollment. Since then login requires a TOTP code
+func (svc *SessionsService) ConfirmTOTP(ctx context.Context, cd models.ConfirmTOTPData) error {
+	sub, _, err := svc.Authenticate(cd.AccessToken)
+	if err != nil {
+		return err
+	}
+
+	return svc.verifyTOTP(sub, cd.Code, false)
+}
+
+//RegenerateRecoveryCodes replaces recovery codes of a user with new ones. Codes are returned once, only their hashes are stored
+func (svc *SessionsService) RegenerateRecoveryCodes(ctx context.Context, rd models.RecoveryCodesData) (res models.RecoveryCodes, err error) {
+	sub, _, err := svc.Authenticate(rd.AccessToken)
+	if err != nil {
+		return res, err
+	}
+
+	codes, err := mfa.GenerateRecoveryCodes(constants.RecoveryCodesCount)
+	if err != nil {
+		return res, err
+	}
+
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, mfa.HashRecoveryCode(code))
+	}
+
+	err = svc.updateMFA(sub, func(record *models.MFARecord) error {
+		if !record.Confirmed {
+			return errors.ErrMFANotEnrolled
+		}
+		record.RecoveryCodes = hashes
+		return nil
+	})
+	if err != nil {
+		return res, err
+	}
+
+	res.Codes = codes
+	return res, nil
+}
+
+//ResetMFA removes recovery codes and/or remembered devices of a user. It requires admin permission
+func (svc *SessionsService) ResetMFA(ctx context.Context, rd models.ResetMFAData) error {
+	_, mask, err := svc.Authenticate(rd.AccessToken)
+	if err != nil {
+		return err
+	}
+
+	if err = svc.requireAdmin(mask); err != nil {
+		return err
+	}
+
+	return svc.updateMFA(normalizeUser(rd.UserName), func(record *models.MFARecord) error {
+		if rd.RecoveryCodes {
+			record.RecoveryCodes = nil
+		}
+		if rd.Devices {
+			record.TrustedDevices = nil
+		}
+		return nil
+	})
+}
+
+//UnlockLogin lifts back-off and lockout of a user name and/or a client IP. It requires admin permission
+func (svc *SessionsService) UnlockLogin(ctx context.Context, ud models.UnlockLoginData) error {
+	_, mask, err := svc.Authenticate(ud.AccessToken)
+	if err != nil {
+		return err
+	}
+
+	if err = svc.requireAdmin(mask); err != nil {
+		return err
+	}
+
+	user := normalizeUser(ud.UserName)
+	svc.guard.Unlock(user, ud.IP)
+	svc.guard.Unlock(mfaThrottlingPrefix+user, "")
+	svc.guard.Unlock(emailThrottlingPrefix+user, "")
+	return nil
+}
+
+//attempt runs verify unless attempts of the user or from the IP are throttled. Errors listed in failures are recorded as
+//failed attempts, success forgets failures of the user. Attempts of the user are serialised from the check to recording
+//the result, so that concurrent guesses cannot all pass the check before any of them is recorded
+func (svc *SessionsService) attempt(user, ip string, verify func() error, failures ...error) error {
+	release := svc.guard.Acquire(user)
+	defer release()
+
+	if err := svc.checkThrottling(user, ip); err != nil {
+		svc.Logger.Log("method", "Login", "action", "checking failed attempts", "user", user, "ip", ip, "error", err)
+		return err
+	}
+
+	err := verify()
+	if err == nil {
+		svc.guard.Succeed(user)
+		return nil
+	}
+
+	for _, failure := range failures {
+		if err == failure {
+			svc.guard.Fail(user, ip)
+			break
+		}
+	}
+	return err
+}
+
+//checkThrottling returns RetryError if login attempts of the user or from the IP are throttled
+func (svc *SessionsService) checkThrottling(user, ip string) error {
+	retryAfter, locked := svc.guard.Check(user, ip)
+	if retryAfter <= 0 {
+		return nil
+	}
+
+	if locked {
+		return &errors.RetryError{Err: errors.ErrAccountLocked, RetryAfter: retryAfter}
+	}
+	return &errors.RetryError{Err: errors.ErrTooManyAttempts, RetryAfter: retryAfter}
+}
+
+//issueTokens starts a new session: creates a pair of tokens and saves refresh token in db. The number of sessions of
+//a user is capped according to session limits of the user role. Every way to log in ends here, so the device is tracked here too.
+//Sessions are kept under the normalised user name, so that the cap counts sessions of the user however the name was typed
+func (svc *SessionsService) issueTokens(sub string, role models.UserRole, clientIP, userAgent string) (resAccess, resRefresh models.TokenData, err error) {
+	sub = normalizeUser(sub)
+	sid, err := GenerateID()
+	if err != nil {
 		return resAccess, resRefresh, err
 	}
 
-	//3. Create an access token
-	resAccess, err = svc.GenerateToken(access, ld.UserName, profile.Role.Mask)
+	resAccess, resRefresh, err = svc.generatePair(sub, role.Mask, sid)
+	if err != nil {
+		return resAccess, resRefresh, err
+	}
+
+	//Tokens of a session that is not saved cannot be refreshed, so they are not handed out
+	if err = svc.saveSession(sub, role.Name, resRefresh.Token); err != nil {
+		return models.TokenData{}, models.TokenData{}, err
+	}
+	svc.trackDevice(sub, clientIP, userAgent)
+
+	return resAccess, resRefresh, nil
+}
+
+//generatePair creates access and refresh tokens of the session
+func (svc *SessionsService) generatePair(sub string, mask int64, sid string) (resAccess, resRefresh models.TokenData, err error) {
+	resAccess, err = svc.GenerateSessionToken(access, sub, mask, sid)
 	if err != nil {
 		svc.Logger.Log("method", "GetToken", "action", "generate access token", "error", err)
 		return resAccess, resRefresh, err
 	}
 
-	resRefresh, err = svc.GenerateToken(refresh, ld.UserName, profile.Role.Mask)
+	resRefresh, err = svc.GenerateSessionToken(refresh, sub, mask, sid)
 	if err != nil {
 		svc.Logger.Log("method", "GetToken", "action", "generate refresh token", "error", err)
 		return resAccess, resRefresh, err
 	}
 
-	//4. Save token in db
-	svc.Db.Save(ld.UserName, resRefresh.Token)
-
 	return resAccess, resRefresh, nil
 }
 
+//verifyTOTP checks TOTP code of a user. Code of an already used time step is rejected. Successful check confirms pending enrollment
+func (svc *SessionsService) verifyTOTP(sub, code string, confirmed bool) error {
+	return svc.updateMFA(sub, func(record *models.MFARecord) error {
+		if record.Confirmed != confirmed {
+			if confirmed {
+				return errors.ErrMFANotEnrolled
+			}
+			return errors.ErrMFAAlreadyEnabled
+		}
+
+		secret, err := svc.cipher.Decrypt(record.Secret)
+		if err != nil {
+			return err
+		}
+
+		step, ok := mfa.ValidateCode(string(secret), code, time.Now())
+		if !ok || step <= record.LastStep {
+			return errors.ErrInvalidMFACode
+		}
+
+		record.LastStep = step
+		record.Confirmed = true
+		return nil
+	})
+}
+
 //Logout handles logout requets
 func (svc *SessionsService) Logout(ctx context.Context, lod models.LogoutData) error {
 	var (
@@ -99,6 +441,11 @@ func (svc *SessionsService) Logout(ctx context.Context, lod models.LogoutData) e
 		return err
 	}
 
+	if err = EnsureSessionToken(refreshClaims); err != nil {
+		svc.Logger.Log("method", "Logout", "err", err)
+		return err
+	}
+
 	if sub, ok = refreshClaims["sub"].(string); !ok {
 		svc.Logger.Log("method", "Logout", "err", errors.ErrClientUnknown)
 		return errors.ErrClientUnknown
@@ -107,63 +454,178 @@ func (svc *SessionsService) Logout(ctx context.Context, lod models.LogoutData) e
 	return nil
 }
 
-//CheckToken checks whether an access token is valid and regenerates it if so
+//CheckToken checks whether an access token is valid and describes it. Expired access tokens are refused, new ones are
+//issued by Refresh only
 func (svc *SessionsService) CheckToken(ctx context.Context, td models.CheckTokenServiceInput) (res models.CheckTokenServiceOutput, err error) {
-	//1. Check whether tokens are valid (signed with HMAC method and our service secret)
-	accessTokenClaims, err := svc.CheckTokenValidness(td.AccessToken)
+	return svc.describeToken(td.AccessToken)
+}
+
+//describeToken returns valid access token along with its claims, so that callers do not need to decode it
+func (svc *SessionsService) describeToken(token string) (res models.CheckTokenServiceOutput, err error) {
+	claims, err := svc.AccessTokenClaims(token)
 	if err != nil {
 		return res, err
 	}
-	refreshTokenClaims, err := svc.CheckTokenValidness(td.RefreshToken)
+
+	sub, mask, err := subjectAndMask(claims)
 	if err != nil {
 		return res, err
 	}
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return res, errors.ErrInvalidClaimInToken
+	}
+	sid, _ := claims["sid"].(string)
 
-	//2. Check expiration claim
-	atexp, err := IsExpired(accessTokenClaims)
+	return models.CheckTokenServiceOutput{
+		AccessToken:    token,
+		Subject:        sub,
+		Mask:           mask,
+		Permissions:    svc.permissions.Names(mask),
+		ExpirationDate: int64(exp),
+		SessionID:      sid,
+	}, nil
+}
 
+//Refresh exchanges a refresh token for a new access token and a new refresh token. The old refresh token is revoked,
+//so a stolen one stops working as soon as the session is refreshed. A refresh token that was already rotated or revoked
+//ends the whole session: either the thief or the owner holds a newer one. The mask is taken from the current profile of the user
+func (svc *SessionsService) Refresh(ctx context.Context, rd models.RefreshData) (resAccess, resRefresh models.TokenData, err error) {
+	sub, sid, err := svc.ParseRefreshToken(rd.RefreshToken)
 	if err != nil {
-		return res, err
+		return resAccess, resRefresh, err
 	}
-	rtexp, err := IsExpired(refreshTokenClaims)
 
+	profile, err := svc.Users.GetUserProfile(ctx, sub)
 	if err != nil {
-		return res, err
+		svc.Logger.Log("method", "GetUserProfile", "action", "retrieving user profile", "error", err)
+		return resAccess, resRefresh, err
 	}
 
-	if atexp && !rtexp {
-		//Access token is expired, refresh token is not expired. Regenerate an access token if such token exist for current user
-		var (
-			ok   bool
-			sub  string
-			mask float64
-		)
-		if sub, ok = accessTokenClaims["sub"].(string); !ok {
-			return res, errors.ErrInvalidClaimInToken
+	//Rotated tokens stay in the same session. Sessions started before tokens got IDs get one now
+	session := sid
+	if len(session) == 0 {
+		if session, err = GenerateID(); err != nil {
+			return resAccess, resRefresh, err
 		}
+	}
+
+	resAccess, resRefresh, err = svc.generatePair(sub, profile.Role.Mask, session)
+	if err != nil {
+		return resAccess, resRefresh, err
+	}
+
+	//Refresh token is revoked on logout and on rotation. Deleting it is the check, so that of concurrent requests
+	//with the same token only one rotates it
+	deleted, err := svc.Db.Delete(sub, rd.RefreshToken)
+	if err != nil {
+		return models.TokenData{}, models.TokenData{}, err
+	}
+	if !deleted {
+		svc.Logger.Log("method", "Refresh", "action", "checking refresh token", "user", sub, "error", errors.ErrNonAuthorized)
+		svc.revokeSession(sub, sid)
+		return models.TokenData{}, models.TokenData{}, errors.ErrNonAuthorized
+	}
+	if err = svc.Db.Save(sub, resRefresh.Token); err != nil {
+		return models.TokenData{}, models.TokenData{}, err
+	}
+
+	return resAccess, resRefresh, nil
+}
+
+//revokeSession removes refresh tokens of a session of the user. Sessions started before tokens got IDs cannot be told
+//apart, so nothing is removed for them
+func (svc *SessionsService) revokeSession(sub, sid string) {
+	if len(sid) == 0 {
+		return
+	}
+
+	tokens, err := svc.Db.List(sub)
+	if err != nil {
+		svc.Logger.Log("method", "Refresh", "action", "revoking session", "user", sub, "error", err)
+		return
+	}
 
-		if mask, ok = accessTokenClaims["mask"].(float64); !ok {
-			return res, errors.ErrInvalidClaimInToken
+	for _, token := range tokens {
+		if _, tokenSid, err := svc.ParseRefreshToken(token); err == nil && tokenSid == sid {
+			svc.Logger.Log("method", "Refresh", "action", "revoke reused session", "user", sub, "sid", sid)
+			svc.Db.Delete(sub, token)
 		}
+	}
+}
 
-		//Check refresh token existance
-		if ok = svc.Db.Exist(sub, td.RefreshToken); !ok {
-			return res, errors.ErrNonAuthorized
+//consumeRecoveryCode checks recovery code of a user and removes it, so that it cannot be used again
+func (svc *SessionsService) consumeRecoveryCode(sub, code string) error {
+	hash := mfa.HashRecoveryCode(code)
+	return svc.updateMFA(sub, func(record *models.MFARecord) error {
+		for i, stored := range record.RecoveryCodes {
+			if subtle.ConstantTimeCompare([]byte(stored), []byte(hash)) == 1 {
+				record.RecoveryCodes = append(record.RecoveryCodes[:i:i], record.RecoveryCodes[i+1:]...)
+				return nil
+			}
 		}
+		return errors.ErrInvalidMFACode
+	})
+}
 
-		tokenData, err := svc.GenerateToken(access, sub, int64(mask))
-		if err != nil {
-			return res, err
+//rememberDevice registers a new trusted device of a user and returns a token that identifies it
+func (svc *SessionsService) rememberDevice(sub string) (models.TokenData, error) {
+	deviceID, err := GenerateID()
+	if err != nil {
+		return models.TokenData{}, err
+	}
+
+	deviceToken, err := svc.GenerateDeviceToken(sub, deviceID)
+	if err != nil {
+		return models.TokenData{}, err
+	}
+
+	return deviceToken, svc.updateMFA(sub, func(record *models.MFARecord) error {
+		//Copy devices to keep record immutable for concurrent readers, dropping expired ones
+		devices := make(map[string]int64, len(record.TrustedDevices)+1)
+		now := time.Now().Unix()
+		for id, exp := range record.TrustedDevices {
+			if exp > now {
+				devices[id] = exp
+			}
 		}
-		res.AccessToken = tokenData.Token //a new token
-	} else if !atexp && !rtexp {
-		//Access token is not expired, refresh token is not expired. Return an old access token
-		res.AccessToken = td.AccessToken
-	} else {
-		//Refresh token is expired
-		res.AccessToken = ""
-		err = errors.ErrNonAuthorized
+		devices[deviceID] = deviceToken.ExpirationDate
+		record.TrustedDevices = devices
+		return nil
+	})
+}
+
+//updateMFA changes MFA settings of a user under the lock of the user, so that concurrent changes are not lost: a stale
+//save could bring back a consumed recovery code or a used TOTP step. The record is saved only if update succeeds.
+//The lock belongs to this instance, so instances sharing the database are serialised by the database only
+func (svc *SessionsService) updateMFA(sub string, update func(record *models.MFARecord) error) error {
+	lock := svc.mfaLocks.of(sub)
+	lock.Lock()
+	defer lock.Unlock()
+
+	record, err := svc.Db.GetMFA(sub)
+	if err != nil {
+		return err
+	}
+
+	if err = update(&record); err != nil {
+		return err
+	}
+
+	return svc.Db.SaveMFA(sub, record)
+}
+
+//isTrustedDevice checks that device token is valid, belongs to the user and the device was not revoked
+func (svc *SessionsService) isTrustedDevice(sub, deviceToken string, record models.MFARecord) bool {
+	if len(deviceToken) == 0 {
+		return false
+	}
+
+	deviceSub, deviceID, err := svc.ParseDeviceToken(deviceToken)
+	if err != nil || deviceSub != sub {
+		return false
 	}
 
-	return res, err
+	exp, ok := record.TrustedDevices[deviceID]
+	return ok && exp > time.Now().Unix()
 }
@@ -2,14 +2,27 @@ package service
 
 import (
 	"context"
+	stderrors "errors"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/Soroka-EDMS/svc/sessions/pkgs/config"
 	"github.com/Soroka-EDMS/svc/sessions/pkgs/constants"
 	"github.com/Soroka-EDMS/svc/sessions/pkgs/db"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/mfa"
 	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/notify"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/notify/notifytest"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/permissions"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/policy"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/webauthn/webauthntest"
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/go-kit/kit/log"
 	"github.com/stretchr/testify/assert"
@@ -18,12 +31,49 @@ import (
 type InputType int
 
 const (
-	AccessExpiredRefreshNotExpired InputType = iota
-	AccessNotExpiredRefreshNotExpired
-	RefreshExpired
+	AccessExpired InputType = iota
+	AccessNotExpired
 	InvalidToken
 )
 
+//usersStub replaces Users service client in tests. Like Users service, it compares user names case-insensitively
+type usersStub struct {
+	profiles map[string]models.UserProfile
+	err      error
+}
+
+func (u *usersStub) EnsureUserCreds(ctx context.Context, username, password string) error {
+	if u.err != nil {
+		return u.err
+	}
+	if _, ok := u.profiles[strings.ToLower(username)]; !ok {
+		return errors.ErrClientUnknown
+	}
+	return nil
+}
+
+func (u *usersStub) GetUserProfile(ctx context.Context, email string) (models.UserProfile, error) {
+	if u.err != nil {
+		return models.UserProfile{}, u.err
+	}
+	profile, ok := u.profiles[strings.ToLower(email)]
+	if !ok {
+		return models.UserProfile{}, errors.ErrClientUnknown
+	}
+	return profile, nil
+}
+
+func newUsersStub() *usersStub {
+	return &usersStub{
+		profiles: map[string]models.UserProfile{
+			"gladys.champl@edms.com": {
+				Email: "gladys.champl@edms.com",
+				Role:  models.UserRole{Name: "admin", Mask: 32767},
+			},
+		},
+	}
+}
+
 func PrepareCookie(t string) (*http.Cookie, error) {
 	loc, err := time.LoadLocation("UTC")
 	if err != nil {
@@ -56,7 +106,8 @@ func PrepareServiceAndDb(id, token string) models.ISessionService {
 		token,
 	}
 	db.Save(testData.sub, testData.token)
-	return Build(log.NewNopLogger(), db, []byte("secret"), make([]byte, 0))
+	svc, _ := Build(log.NewNopLogger(), db, []byte("secret"), []byte("mfa"), newUsersStub())
+	return svc
 }
 
 func PrepareLogoutRequest(c *http.Cookie) *models.LogoutData {
@@ -122,33 +173,15 @@ func CreateRefreshToken(iss, cid string, expired bool) (tokenString string, err
 
 func PrepareCheckTokenInput(t InputType) (input models.CheckTokenServiceInput, err error) {
 	switch t {
-	case AccessExpiredRefreshNotExpired:
-		if input.AccessToken, err = CreateAccessToken(constants.TokenIssuer, "gladys.champl@edms.com", 32767, true); err != nil {
-			return input, err
-		}
-		if input.RefreshToken, err = CreateRefreshToken(constants.TokenIssuer, "gladys.champl@edms.com", false); err != nil {
-			return input, err
-		}
-	case AccessNotExpiredRefreshNotExpired:
-		if input.AccessToken, err = CreateAccessToken(constants.TokenIssuer, "gladys.champl@edms.com", 32767, false); err != nil {
-			return input, err
-		}
-		if input.RefreshToken, err = CreateRefreshToken(constants.TokenIssuer, "gladys.champl@edms.com", false); err != nil {
-			return input, err
-		}
-	case RefreshExpired:
-		if input.AccessToken, err = CreateAccessToken(constants.TokenIssuer, "gladys.champl@edms.com", 32767, true); err != nil {
-			return input, err
-		}
-		if input.RefreshToken, err = CreateRefreshToken(constants.TokenIssuer, "gladys.champl@edms.com", true); err != nil {
-			return input, err
-		}
+	case AccessExpired:
+		input.AccessToken, err = CreateAccessToken(constants.TokenIssuer, "gladys.champl@edms.com", 32767, true)
+	case AccessNotExpired:
+		input.AccessToken, err = CreateAccessToken(constants.TokenIssuer, "gladys.champl@edms.com", 32767, false)
 	case InvalidToken:
 		input.AccessToken = "Z2xhZHlzLmNoYW1wbEBlZG1zLmNvbTphQG0xbg"
-		input.RefreshToken = "Td5MZHlzHc4PYW1wbsdsZG1zLmNvbTphQG0d6f"
 	}
 
-	return input, nil
+	return input, err
 }
 
 func TestLogout_ValidUser(t *testing.T) {
@@ -176,55 +209,942 @@ func TestLogout_InvalidUser(t *testing.T) {
 }
 
 func TestCheckToken_ExpiredAccessToken(t *testing.T) {
-	input, err := PrepareCheckTokenInput(AccessExpiredRefreshNotExpired)
+	input, err := PrepareCheckTokenInput(AccessExpired)
 	assert.NoError(t, err)
-	svc := PrepareServiceAndDb("gladys.champl@edms.com", input.RefreshToken)
+	svc := PrepareServiceAndDb("gladys.champl@edms.com", "")
 	//Wait until the token expiration date
 	time.Sleep(1 * time.Second)
 	output, err := svc.CheckToken(context.Background(), input)
-	assert.NoError(t, err)
-	//New access token expected
-	assert.True(t, input.AccessToken != output.AccessToken)
+	//Expired access token is refused, never regenerated
+	assert.Equal(t, errors.ErrExpiredAccessToken, err)
+	assert.Empty(t, output.AccessToken)
 }
 
 func TestCheckToken_NotExpiredAccessToken(t *testing.T) {
-	input, err := PrepareCheckTokenInput(AccessNotExpiredRefreshNotExpired)
+	input, err := PrepareCheckTokenInput(AccessNotExpired)
 	assert.NoError(t, err)
-	svc := PrepareServiceAndDb("gladys.champl@edms.com", input.RefreshToken)
+	svc := PrepareServiceAndDb("gladys.champl@edms.com", "")
 	output, err := svc.CheckToken(context.Background(), input)
 	assert.NoError(t, err)
-	//New access token expected
-	assert.True(t, input.AccessToken == output.AccessToken)
+	assert.Equal(t, input.AccessToken, output.AccessToken)
 }
 
-func TestCheckToken_ExpiredRefreshToken(t *testing.T) {
-	input, err := PrepareCheckTokenInput(RefreshExpired)
+func TestCheckToken_InvalidToken(t *testing.T) {
+	input, err := PrepareCheckTokenInput(InvalidToken)
 	assert.NoError(t, err)
-	svc := PrepareServiceAndDb("gladys.champl@edms.com", input.RefreshToken)
-	//Wait until the token expiration date
-	time.Sleep(1 * time.Second)
+	svc := PrepareServiceAndDb("gladys.champl@edms.com", "")
 	output, err := svc.CheckToken(context.Background(), input)
 	assert.Error(t, err)
-	//New access token expected
-	assert.True(t, "" == output.AccessToken)
+	assert.Equal(t, output.AccessToken, "")
 }
 
-func TestCheckToken_InvalidToken(t *testing.T) {
-	input, err := PrepareCheckTokenInput(InvalidToken)
+func TestLogin_ValidUser(t *testing.T) {
+	svc := PrepareServiceAndDb("user@email.com", "")
+	access, refresh, err := svc.Login(context.Background(), models.LoginData{UserName: "gladys.champl@edms.com", Password: "pass"})
 	assert.NoError(t, err)
-	svc := PrepareServiceAndDb("gladys.champl@edms.com", input.RefreshToken)
-	output, err := svc.CheckToken(context.Background(), input)
+	assert.NotEmpty(t, access.Token)
+	assert.NotEmpty(t, refresh.Token)
+}
+
+func TestLogin_UsersUnavailable(t *testing.T) {
+	users := newUsersStub()
+	users.err = errors.ErrUsersUnavailable
+	db, _ := db.Connection(config.GetLogger().Logger, "stub")
+	svc, err := Build(log.NewNopLogger(), db, []byte("secret"), []byte("mfa"), users)
+	assert.NoError(t, err)
+
+	access, _, err := svc.Login(context.Background(), models.LoginData{UserName: "gladys.champl@edms.com", Password: "pass"})
+	assert.Equal(t, errors.ErrUsersUnavailable, err)
+	assert.Empty(t, access.Token)
+}
+
+var errMFAStorage = stderrors.New("mfa storage is unavailable")
+
+//mfaFailingDb fails to read MFA settings, e.g. when the storage is down or a record cannot be decrypted
+type mfaFailingDb struct {
+	models.ISessionDatabase
+}
+
+func (db mfaFailingDb) GetMFA(userID string) (models.MFARecord, error) {
+	return models.MFARecord{}, errMFAStorage
+}
+
+func TestLogin_MFASettingsUnavailable(t *testing.T) {
+	sessionsDb, _ := db.Connection(config.GetLogger().Logger, "stub")
+	svc, err := Build(log.NewNopLogger(), mfaFailingDb{sessionsDb}, []byte("secret"), []byte("mfa"), newUsersStub())
+	assert.NoError(t, err)
+
+	//Second factor is not skipped when it cannot be checked
+	access, refresh, err := svc.Login(context.Background(), models.LoginData{UserName: "gladys.champl@edms.com", Password: "pass"})
+	assert.Equal(t, errMFAStorage, err)
+	assert.Empty(t, access.Token)
+	assert.Empty(t, refresh.Token)
+}
+
+func TestLoginMFA_Flow(t *testing.T) {
+	svc := PrepareServiceAndDb("user@email.com", "")
+	ctx := context.Background()
+	creds := models.LoginData{UserName: "gladys.champl@edms.com", Password: "pass"}
+
+	access, _, err := svc.Login(ctx, creds)
+	assert.NoError(t, err)
+
+	//Enroll and confirm TOTP
+	enrollment, err := svc.EnrollTOTP(ctx, models.EnrollTOTPData{AccessToken: access.Token})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, enrollment.Secret)
+	assert.Contains(t, enrollment.URI, enrollment.Secret)
+
+	assert.Equal(t, errors.ErrInvalidMFACode, svc.ConfirmTOTP(ctx, models.ConfirmTOTPData{AccessToken: access.Token, Code: "000000"}))
+	code, err := mfa.GenerateCode(enrollment.Secret, mfa.Step(time.Now()))
+	assert.NoError(t, err)
+	assert.NoError(t, svc.ConfirmTOTP(ctx, models.ConfirmTOTPData{AccessToken: access.Token, Code: code}))
+
+	_, err = svc.EnrollTOTP(ctx, models.EnrollTOTPData{AccessToken: access.Token})
+	assert.Equal(t, errors.ErrMFAAlreadyEnabled, err)
+
+	//The first step of login returns a challenge instead of tokens
+	challenge, refresh, err := svc.Login(ctx, creds)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.MFAChallengeType, challenge.Type)
+	assert.Empty(t, refresh.Token)
+
+	//Challenge is not an access token
+	_, err = svc.EnrollTOTP(ctx, models.EnrollTOTPData{AccessToken: challenge.Token})
 	assert.Error(t, err)
-	assert.Equal(t, output.AccessToken, "")
+
+	//Code of already used time step is rejected
+	_, _, _, err = svc.LoginMFA(ctx, models.LoginMFAData{ChallengeToken: challenge.Token, Code: code})
+	assert.Equal(t, errors.ErrInvalidMFACode, err)
+
+	code, err = mfa.GenerateCode(enrollment.Secret, mfa.Step(time.Now())+1)
+	assert.NoError(t, err)
+	access, refresh, _, err = svc.LoginMFA(ctx, models.LoginMFAData{ChallengeToken: challenge.Token, Code: code})
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer", access.Type)
+	assert.NotEmpty(t, refresh.Token)
 }
 
-func TestCheckToken_NoSuchTOkenInDb(t *testing.T) {
-	input, err := PrepareCheckTokenInput(AccessExpiredRefreshNotExpired)
+func TestLoginMFA_MixedCaseUserName(t *testing.T) {
+	svc := PrepareServiceAndDb("user@email.com", "")
+	ctx := context.Background()
+
+	access, _, err := svc.Login(ctx, models.LoginData{UserName: "gladys.champl@edms.com", Password: "pass"})
 	assert.NoError(t, err)
-	//Wait until the token expiration date
-	time.Sleep(2 * time.Second)
-	svc := PrepareServiceAndDb("user@email.com", input.RefreshToken)
-	output, err := svc.CheckToken(context.Background(), input)
+	enrollment, err := svc.EnrollTOTP(ctx, models.EnrollTOTPData{AccessToken: access.Token})
+	assert.NoError(t, err)
+	code, err := mfa.GenerateCode(enrollment.Secret, mfa.Step(time.Now()))
+	assert.NoError(t, err)
+	assert.NoError(t, svc.ConfirmTOTP(ctx, models.ConfirmTOTPData{AccessToken: access.Token, Code: code}))
+
+	//The name typed differently is the same user, so MFA is not skipped
+	challenge, refresh, err := svc.Login(ctx, models.LoginData{UserName: "Gladys.Champl@EDMS.com", Password: "pass"})
+	assert.NoError(t, err)
+	assert.Equal(t, constants.MFAChallengeType, challenge.Type)
+	assert.Empty(t, refresh.Token)
+
+	code, err = mfa.GenerateCode(enrollment.Secret, mfa.Step(time.Now())+1)
+	assert.NoError(t, err)
+	access, _, _, err = svc.LoginMFA(ctx, models.LoginMFAData{ChallengeToken: challenge.Token, Code: code})
+	assert.NoError(t, err)
+	output, err := svc.CheckToken(ctx, models.CheckTokenServiceInput{AccessToken: access.Token})
+	assert.NoError(t, err)
+	assert.Equal(t, "gladys.champl@edms.com", output.Subject)
+}
+
+func TestLoginMFA_InvalidChallenge(t *testing.T) {
+	svc := PrepareServiceAndDb("user@email.com", "")
+
+	accessToken, err := CreateAccessToken(constants.TokenIssuer, "gladys.champl@edms.com", 32767, false)
+	assert.NoError(t, err)
+
+	_, _, _, err = svc.LoginMFA(context.Background(), models.LoginMFAData{ChallengeToken: accessToken, Code: "000000"})
+	assert.Equal(t, errors.ErrInvalidTokenType, err)
+}
+
+//enableMFA enrolls a user into TOTP and returns the secret along with a valid access token
+func enableMFA(t *testing.T, svc models.ISessionService, creds models.LoginData) (secret, accessToken string) {
+	access, _, err := svc.Login(context.Background(), creds)
+	assert.NoError(t, err)
+
+	enrollment, err := svc.EnrollTOTP(context.Background(), models.EnrollTOTPData{AccessToken: access.Token})
+	assert.NoError(t, err)
+	code, err := mfa.GenerateCode(enrollment.Secret, mfa.Step(time.Now()))
+	assert.NoError(t, err)
+	assert.NoError(t, svc.ConfirmTOTP(context.Background(), models.ConfirmTOTPData{AccessToken: access.Token, Code: code}))
+
+	return enrollment.Secret, access.Token
+}
+
+func TestLoginMFA_RecoveryCodes(t *testing.T) {
+	svc := PrepareServiceAndDb("user@email.com", "")
+	ctx := context.Background()
+	creds := models.LoginData{UserName: "gladys.champl@edms.com", Password: "pass"}
+	_, accessToken := enableMFA(t, svc, creds)
+
+	codes, err := svc.RegenerateRecoveryCodes(ctx, models.RecoveryCodesData{AccessToken: accessToken})
+	assert.NoError(t, err)
+	assert.Len(t, codes.Codes, constants.RecoveryCodesCount)
+
+	challenge, _, err := svc.Login(ctx, creds)
+	assert.NoError(t, err)
+	access, _, _, err := svc.LoginMFA(ctx, models.LoginMFAData{ChallengeToken: challenge.Token, Code: codes.Codes[0]})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, access.Token)
+
+	//Recovery code is consumed
+	_, _, _, err = svc.LoginMFA(ctx, models.LoginMFAData{ChallengeToken: challenge.Token, Code: codes.Codes[0]})
+	assert.Equal(t, errors.ErrInvalidMFACode, err)
+}
+
+func TestLogin_RememberedDevice(t *testing.T) {
+	svc := PrepareServiceAndDb("user@email.com", "")
+	ctx := context.Background()
+	creds := models.LoginData{UserName: "gladys.champl@edms.com", Password: "pass"}
+	secret, accessToken := enableMFA(t, svc, creds)
+
+	challenge, _, err := svc.Login(ctx, creds)
+	assert.NoError(t, err)
+	code, err := mfa.GenerateCode(secret, mfa.Step(time.Now())+1)
+	assert.NoError(t, err)
+	_, _, device, err := svc.LoginMFA(ctx, models.LoginMFAData{ChallengeToken: challenge.Token, Code: code, RememberDevice: true})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, device.Token)
+
+	//Login from remembered device skips MFA
+	creds.DeviceToken = device.Token
+	access, refresh, err := svc.Login(ctx, creds)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer", access.Type)
+	assert.NotEmpty(t, refresh.Token)
+
+	//Admin resets remembered devices, MFA is required again
+	assert.NoError(t, svc.ResetMFA(ctx, models.ResetMFAData{AccessToken: accessToken, UserName: creds.UserName, Devices: true, RecoveryCodes: true}))
+	access, _, err = svc.Login(ctx, creds)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.MFAChallengeType, access.Type)
+}
+
+//slowMFADb widens the window between reading and saving MFA settings
+type slowMFADb struct {
+	models.ISessionDatabase
+}
+
+func (db slowMFADb) GetMFA(userID string) (models.MFARecord, error) {
+	record, err := db.ISessionDatabase.GetMFA(userID)
+	time.Sleep(time.Millisecond)
+	return record, err
+}
+
+func TestMFA_ConcurrentUpdates(t *testing.T) {
+	db, _ := db.Connection(config.GetLogger().Logger, "stub")
+	svc, err := NewSessionsService(slowMFADb{db}, []byte("secret"), []byte("mfa"), newUsersStub())
+	assert.NoError(t, err)
+	ctx := context.Background()
+	user := "gladys.champl@edms.com"
+	_, accessToken := enableMFA(t, svc, models.LoginData{UserName: user, Password: "pass"})
+	codes, err := svc.RegenerateRecoveryCodes(ctx, models.RecoveryCodesData{AccessToken: accessToken})
+	assert.NoError(t, err)
+
+	//Every code is consumed once and every device is remembered, none of the changes is lost
+	sessions := svc.(*SessionsService)
+	var wg sync.WaitGroup
+	for _, code := range codes.Codes {
+		wg.Add(2)
+		go func(code string) {
+			defer wg.Done()
+			assert.NoError(t, sessions.consumeRecoveryCode(user, code))
+		}(code)
+		go func() {
+			defer wg.Done()
+			_, err := sessions.rememberDevice(user)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	record, err := sessions.Db.GetMFA(user)
+	assert.NoError(t, err)
+	assert.Empty(t, record.RecoveryCodes)
+	assert.Len(t, record.TrustedDevices, len(codes.Codes))
+}
+
+func TestResetMFA_NoPermissions(t *testing.T) {
+	svc := PrepareServiceAndDb("user@email.com", "")
+
+	accessToken, err := CreateAccessToken(constants.TokenIssuer, "user@email.com", 2048, false)
+	assert.NoError(t, err)
+
+	err = svc.ResetMFA(context.Background(), models.ResetMFAData{AccessToken: accessToken, UserName: "gladys.champl@edms.com", Devices: true})
+	assert.Equal(t, errors.ErrNoPermissions, err)
+}
+
+func TestWebAuthn_PasskeyLogin(t *testing.T) {
+	svc := PrepareServiceAndDb("user@email.com", "")
+	ctx := context.Background()
+	authenticator := webauthntest.NewAuthenticator(constants.WebAuthnRPID, constants.WebAuthnOrigin)
+
+	access, _, err := svc.Login(ctx, models.LoginData{UserName: "gladys.champl@edms.com", Password: "pass"})
+	assert.NoError(t, err)
+
+	//Register a passkey
+	creation, err := svc.BeginWebAuthnRegistration(ctx, models.WebAuthnRegistrationData{AccessToken: access.Token})
+	assert.NoError(t, err)
+	assert.Equal(t, constants.WebAuthnRPID, creation.RP.ID)
+	assert.NotContains(t, creation.User.ID, "gladys")
+
+	credential, err := authenticator.Create(creation)
+	assert.NoError(t, err)
+	assert.NoError(t, svc.FinishWebAuthnRegistration(ctx, models.WebAuthnRegistrationFinishData{AccessToken: access.Token, Credential: credential}))
+
+	//Challenge is consumed by registration
+	err = svc.FinishWebAuthnRegistration(ctx, models.WebAuthnRegistrationFinishData{AccessToken: access.Token, Credential: credential})
+	assert.Equal(t, errors.ErrWebAuthnChallenge, err)
+
+	//Registered credential is excluded from new registrations and allowed for login
+	creation, err = svc.BeginWebAuthnRegistration(ctx, models.WebAuthnRegistrationData{AccessToken: access.Token})
+	assert.NoError(t, err)
+	assert.Len(t, creation.ExcludeCredentials, 1)
+
+	request, err := svc.BeginWebAuthnLogin(ctx, models.WebAuthnLoginData{UserName: "gladys.champl@edms.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, credential.ID, request.AllowCredentials[0].ID)
+
+	assertion, err := authenticator.Get(request)
+	assert.NoError(t, err)
+	access, refresh, err := svc.FinishWebAuthnLogin(ctx, models.WebAuthnLoginFinishData{Credential: assertion})
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer", access.Type)
+	assert.NotEmpty(t, refresh.Token)
+
+	//Replayed assertion is rejected
+	_, _, err = svc.FinishWebAuthnLogin(ctx, models.WebAuthnLoginFinishData{Credential: assertion})
+	assert.Equal(t, errors.ErrWebAuthnChallenge, err)
+
+	//Discoverable login from a cloned authenticator is detected by signature counter
+	request, err = svc.BeginWebAuthnLogin(ctx, models.WebAuthnLoginData{})
+	assert.NoError(t, err)
+	assert.Empty(t, request.AllowCredentials)
+	authenticator.Counter = 0
+	assertion, err = authenticator.Get(request)
+	assert.NoError(t, err)
+	_, _, err = svc.FinishWebAuthnLogin(ctx, models.WebAuthnLoginFinishData{Credential: assertion})
+	assert.Equal(t, errors.ErrSignCountRegression, err)
+}
+
+func TestLogin_Throttling(t *testing.T) {
+	svc := PrepareServiceAndDb("user@email.com", "")
+	ctx := context.Background()
+
+	//Failures of unknown users count as well, so that throttling does not reveal which accounts exist
+	for i := 0; i < 3; i++ {
+		_, _, err := svc.Login(ctx, models.LoginData{UserName: "Nobody@edms.com", Password: "guess", ClientIP: "192.0.2.10"})
+		assert.Equal(t, errors.ErrClientUnknown, err)
+	}
+
+	_, _, err := svc.Login(ctx, models.LoginData{UserName: "nobody@edms.com", Password: "guess", ClientIP: "192.0.2.10"})
+	assert.Equal(t, errors.ErrClientUnknown, err)
+
+	_, _, err = svc.Login(ctx, models.LoginData{UserName: "nobody@edms.com", Password: "guess", ClientIP: "192.0.2.10"})
+	retry, ok := err.(*errors.RetryError)
+	assert.True(t, ok)
+	assert.Equal(t, errors.ErrTooManyAttempts, retry.Err)
+	assert.True(t, retry.RetryAfter > 0)
+
+	//Only admin lifts throttling
+	userToken, err := CreateAccessToken(constants.TokenIssuer, "user@email.com", 2048, false)
+	assert.NoError(t, err)
+	assert.Equal(t, errors.ErrNoPermissions, svc.UnlockLogin(ctx, models.UnlockLoginData{AccessToken: userToken, UserName: "nobody@edms.com"}))
+
+	adminToken, err := CreateAccessToken(constants.TokenIssuer, "gladys.champl@edms.com", 32767, false)
+	assert.NoError(t, err)
+	assert.NoError(t, svc.UnlockLogin(ctx, models.UnlockLoginData{AccessToken: adminToken, UserName: "nobody@edms.com"}))
+
+	_, _, err = svc.Login(ctx, models.LoginData{UserName: "nobody@edms.com", Password: "guess", ClientIP: "192.0.2.10"})
+	assert.Equal(t, errors.ErrClientUnknown, err)
+}
+
+func TestLogin_SessionLimitReject(t *testing.T) {
+	db, _ := db.Connection(config.GetLogger().Logger, "stub")
+	svc, err := Build(log.NewNopLogger(), db, []byte("secret"), []byte("mfa"), newUsersStub(),
+		WithSessionLimits(SessionLimits{Max: 5, Roles: map[string]int{"Admin": 2}, Policy: RejectPolicy}))
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, _, err = svc.Login(ctx, models.LoginData{UserName: "gladys.champl@edms.com", Password: "pass"})
+		assert.NoError(t, err)
+	}
+
+	_, _, err = svc.Login(ctx, models.LoginData{UserName: "gladys.champl@edms.com", Password: "pass"})
+	assert.Equal(t, errors.ErrTooManySessions, err)
+
+	tokens, err := db.List("gladys.champl@edms.com")
+	assert.NoError(t, err)
+	assert.Len(t, tokens, 2)
+}
+
+func TestLogin_SessionLimitMixedCase(t *testing.T) {
+	db, _ := db.Connection(config.GetLogger().Logger, "stub")
+	svc, err := Build(log.NewNopLogger(), db, []byte("secret"), []byte("mfa"), newUsersStub(),
+		WithSessionLimits(SessionLimits{Max: 1, Policy: RejectPolicy}))
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	_, _, err = svc.Login(ctx, models.LoginData{UserName: "gladys.champl@edms.com", Password: "pass"})
+	assert.NoError(t, err)
+
+	//Names typed differently count against the same cap
+	for _, name := range []string{"Gladys.Champl@edms.com", "GLADYS.CHAMPL@EDMS.COM"} {
+		_, _, err = svc.Login(ctx, models.LoginData{UserName: name, Password: "pass"})
+		assert.Equal(t, errors.ErrTooManySessions, err, name)
+	}
+
+	tokens, err := db.List("gladys.champl@edms.com")
+	assert.NoError(t, err)
+	assert.Len(t, tokens, 1)
+}
+
+func TestLogin_SessionLimitConcurrent(t *testing.T) {
+	db, _ := db.Connection(config.GetLogger().Logger, "stub")
+	svc, err := Build(log.NewNopLogger(), db, []byte("secret"), []byte("mfa"), newUsersStub(),
+		WithSessionLimits(SessionLimits{Max: 2, Policy: RejectPolicy}))
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			svc.Login(ctx, models.LoginData{UserName: "gladys.champl@edms.com", Password: "pass"})
+		}()
+	}
+	wg.Wait()
+
+	//Concurrent logins do not exceed the cap
+	tokens, err := db.List("gladys.champl@edms.com")
+	assert.NoError(t, err)
+	assert.Len(t, tokens, 2)
+}
+
+var errSessionStorage = stderrors.New("session storage is unavailable")
+
+//saveFailingDb fails to save refresh tokens
+type saveFailingDb struct {
+	models.ISessionDatabase
+}
+
+func (db saveFailingDb) Save(userID, token string) error {
+	return errSessionStorage
+}
+
+func TestLogin_SessionNotSaved(t *testing.T) {
+	stub, _ := db.Connection(config.GetLogger().Logger, "stub")
+	svc, err := Build(log.NewNopLogger(), saveFailingDb{stub}, []byte("secret"), []byte("mfa"), newUsersStub())
+	assert.NoError(t, err)
+
+	access, refresh, err := svc.Login(context.Background(), models.LoginData{UserName: "gladys.champl@edms.com", Password: "pass"})
+	assert.Equal(t, errSessionStorage, err)
+	assert.Empty(t, access.Token)
+	assert.Empty(t, refresh.Token)
+}
+
+func TestLogin_SessionLimitEvictOldest(t *testing.T) {
+	db, _ := db.Connection(config.GetLogger().Logger, "stub")
+	svc, err := Build(log.NewNopLogger(), db, []byte("secret"), []byte("mfa"), newUsersStub(),
+		WithSessionLimits(SessionLimits{Max: 2, Policy: EvictOldestPolicy}))
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	_, oldest, err := svc.Login(ctx, models.LoginData{UserName: "gladys.champl@edms.com", Password: "pass"})
+	assert.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, _, err = svc.Login(ctx, models.LoginData{UserName: "gladys.champl@edms.com", Password: "pass"})
+		assert.NoError(t, err)
+	}
+
+	tokens, err := db.List("gladys.champl@edms.com")
+	assert.NoError(t, err)
+	assert.Len(t, tokens, 2)
+	assert.False(t, db.Exist("gladys.champl@edms.com", oldest.Token))
+}
+
+//notifierStub collects security events
+type notifierStub struct {
+	events chan models.SecurityEvent
+}
+
+func (n *notifierStub) Notify(ctx context.Context, event models.SecurityEvent) error {
+	n.events <- event
+	return nil
+}
+
+func TestLogin_NewDeviceNotification(t *testing.T) {
+	notifier := &notifierStub{events: make(chan models.SecurityEvent, 10)}
+	db, _ := db.Connection(config.GetLogger().Logger, "stub")
+	svc, err := Build(log.NewNopLogger(), db, []byte("secret"), []byte("mfa"), newUsersStub(), WithNotifier(notifier))
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	login := func(ip, userAgent string) {
+		_, _, err := svc.Login(ctx, models.LoginData{UserName: "gladys.champl@edms.com", Password: "pass", ClientIP: ip, UserAgent: userAgent})
+		assert.NoError(t, err)
+	}
+
+	//The first device of a user is not reported, neither are known ones
+	login("192.0.2.10", "Firefox")
+	login("192.0.2.10", "Firefox")
+
+	login("198.51.100.7", "Firefox")
+	select {
+	case event := <-notifier.events:
+		assert.Equal(t, constants.NewDeviceEvent, event.Type)
+		assert.Equal(t, "gladys.champl@edms.com", event.UserID)
+		assert.Equal(t, "198.51.100.7", event.IP)
+		assert.Equal(t, "Firefox", event.UserAgent)
+	case <-time.After(time.Second):
+		t.Fatal("new device is not reported")
+	}
+
+	login("198.51.100.7", "Firefox")
+	select {
+	case event := <-notifier.events:
+		t.Fatalf("known device is reported: %v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestLoginMFA_NewDeviceNotification(t *testing.T) {
+	notifier := &notifierStub{events: make(chan models.SecurityEvent, 10)}
+	db, _ := db.Connection(config.GetLogger().Logger, "stub")
+	svc, err := Build(log.NewNopLogger(), db, []byte("secret"), []byte("mfa"), newUsersStub(), WithNotifier(notifier))
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	access, _, err := svc.Login(ctx, models.LoginData{UserName: "gladys.champl@edms.com", Password: "pass", ClientIP: "192.0.2.10", UserAgent: "Firefox"})
+	assert.NoError(t, err)
+	enrollment, err := svc.EnrollTOTP(ctx, models.EnrollTOTPData{AccessToken: access.Token})
+	assert.NoError(t, err)
+	code, err := mfa.GenerateCode(enrollment.Secret, mfa.Step(time.Now()))
+	assert.NoError(t, err)
+	assert.NoError(t, svc.ConfirmTOTP(ctx, models.ConfirmTOTPData{AccessToken: access.Token, Code: code}))
+
+	challenge, _, err := svc.Login(ctx, models.LoginData{UserName: "gladys.champl@edms.com", Password: "pass", ClientIP: "198.51.100.7", UserAgent: "Safari"})
+	assert.NoError(t, err)
+	code, err = mfa.GenerateCode(enrollment.Secret, mfa.Step(time.Now())+1)
+	assert.NoError(t, err)
+	_, _, _, err = svc.LoginMFA(ctx, models.LoginMFAData{ChallengeToken: challenge.Token, Code: code, ClientIP: "198.51.100.7", UserAgent: "Safari"})
+	assert.NoError(t, err)
+
+	//Device is tracked once the second factor is passed
+	select {
+	case event := <-notifier.events:
+		assert.Equal(t, constants.NewDeviceEvent, event.Type)
+		assert.Equal(t, "198.51.100.7", event.IP)
+		assert.Equal(t, "Safari", event.UserAgent)
+	case <-time.After(time.Second):
+		t.Fatal("new device is not reported")
+	}
+}
+
+func TestEmailLogin_CodeAndMagicLink(t *testing.T) {
+	server, err := notifytest.NewSMTPServer()
+	assert.NoError(t, err)
+	defer server.Close()
+
+	db, _ := db.Connection(config.GetLogger().Logger, "stub")
+	svc, err := Build(log.NewNopLogger(), db, []byte("secret"), []byte("mfa"), newUsersStub(),
+		WithMailer(notify.NewMailer(notify.SMTPConfig{Addr: server.Addr, From: "noreply@edms.com"})))
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	//Unknown emails get the same answers, but no mail
+	assert.NoError(t, svc.RequestEmailLogin(ctx, models.EmailLoginData{Email: "nobody@edms.com"}))
+	assert.Len(t, server.Mails, 0)
+	err = svc.RequestEmailLogin(ctx, models.EmailLoginData{Email: "nobody@edms.com"})
+	retry, ok := err.(*errors.RetryError)
+	assert.True(t, ok)
+	assert.Equal(t, errors.ErrRateLimited, retry.Err)
+	_, _, err = svc.RedeemEmailLogin(ctx, models.EmailLoginRedeemData{Email: "nobody@edms.com", Code: ""})
+	assert.Equal(t, errors.ErrInvalidEmailCode, err)
+
+	assert.NoError(t, svc.RequestEmailLogin(ctx, models.EmailLoginData{Email: "Gladys.Champl@edms.com"}))
+	mail := <-server.Mails
+	assert.Equal(t, []string{"gladys.champl@edms.com"}, mail.To)
+	code := regexp.MustCompile(`login code is (\d{6})`).FindStringSubmatch(mail.Data)
+	assert.Len(t, code, 2)
+
+	//A new code cannot be requested right away
+	err = svc.RequestEmailLogin(ctx, models.EmailLoginData{Email: "gladys.champl@edms.com"})
+	retry, ok = err.(*errors.RetryError)
+	assert.True(t, ok)
+	assert.Equal(t, errors.ErrRateLimited, retry.Err)
+
+	_, _, err = svc.RedeemEmailLogin(ctx, models.EmailLoginRedeemData{Email: "gladys.champl@edms.com", Code: "not-a-code"})
+	assert.Equal(t, errors.ErrInvalidEmailCode, err)
+
+	access, refresh, err := svc.RedeemEmailLogin(ctx, models.EmailLoginRedeemData{Email: "gladys.champl@edms.com", Code: code[1]})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, access.Token)
+	assert.NotEmpty(t, refresh.Token)
+
+	//Codes are single-use
+	_, _, err = svc.RedeemEmailLogin(ctx, models.EmailLoginRedeemData{Email: "gladys.champl@edms.com", Code: code[1]})
+	assert.Equal(t, errors.ErrInvalidEmailCode, err)
+
+	//Magic link carries a token that works the same way as the code. Redeemed login does not hold back a new one
+	assert.NoError(t, svc.RequestEmailLogin(ctx, models.EmailLoginData{Email: "gladys.champl@edms.com"}))
+	mail = <-server.Mails
+	link := regexp.MustCompile(`(https://\S+)`).FindStringSubmatch(mail.Data)
+	assert.Len(t, link, 2)
+	parsed, err := url.Parse(link[1])
+	assert.NoError(t, err)
+
+	_, _, err = svc.RedeemEmailLogin(ctx, models.EmailLoginRedeemData{Email: parsed.Query().Get("email"), Code: parsed.Query().Get("code")})
+	assert.NoError(t, err)
+}
+
+func TestEmailLogin_MFAEnabled(t *testing.T) {
+	server, err := notifytest.NewSMTPServer()
+	assert.NoError(t, err)
+	defer server.Close()
+
+	db, _ := db.Connection(config.GetLogger().Logger, "stub")
+	svc, err := Build(log.NewNopLogger(), db, []byte("secret"), []byte("mfa"), newUsersStub(),
+		WithMailer(notify.NewMailer(notify.SMTPConfig{Addr: server.Addr, From: "noreply@edms.com"})))
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	access, _, err := svc.Login(ctx, models.LoginData{UserName: "gladys.champl@edms.com", Password: "pass"})
+	assert.NoError(t, err)
+	enrollment, err := svc.EnrollTOTP(ctx, models.EnrollTOTPData{AccessToken: access.Token})
+	assert.NoError(t, err)
+	totp, err := mfa.GenerateCode(enrollment.Secret, mfa.Step(time.Now()))
+	assert.NoError(t, err)
+	assert.NoError(t, svc.ConfirmTOTP(ctx, models.ConfirmTOTPData{AccessToken: access.Token, Code: totp}))
+
+	assert.NoError(t, svc.RequestEmailLogin(ctx, models.EmailLoginData{Email: "gladys.champl@edms.com"}))
+	mail := <-server.Mails
+	code := regexp.MustCompile(`login code is (\d{6})`).FindStringSubmatch(mail.Data)
+	assert.Len(t, code, 2)
+
+	//The code does not bypass MFA
+	challenge, refresh, err := svc.RedeemEmailLogin(ctx, models.EmailLoginRedeemData{Email: "gladys.champl@edms.com", Code: code[1]})
+	assert.NoError(t, err)
+	assert.Equal(t, constants.MFAChallengeType, challenge.Type)
+	assert.Empty(t, refresh.Token)
+}
+
+func TestEmailLogin_TooManyWrongCodes(t *testing.T) {
+	server, err := notifytest.NewSMTPServer()
+	assert.NoError(t, err)
+	defer server.Close()
+
+	db, _ := db.Connection(config.GetLogger().Logger, "stub")
+	svc, err := NewSessionsService(db, []byte("secret"), []byte("mfa"), newUsersStub(),
+		WithMailer(notify.NewMailer(notify.SMTPConfig{Addr: server.Addr, From: "noreply@edms.com"})))
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	assert.NoError(t, svc.RequestEmailLogin(ctx, models.EmailLoginData{Email: "gladys.champl@edms.com"}))
+	<-server.Mails
+
+	//Guessing is throttled as guessing passwords
+	var throttled bool
+	for i := 0; i < emailLoginAttempts && !throttled; i++ {
+		_, _, err = svc.RedeemEmailLogin(ctx, models.EmailLoginRedeemData{Email: "gladys.champl@edms.com", Code: "000000"})
+		_, throttled = err.(*errors.RetryError)
+	}
+	assert.True(t, throttled)
+
+	//Pending login is dropped after too many wrong codes regardless of throttling
+	for i := 0; i < emailLoginAttempts; i++ {
+		svc.(*SessionsService).consumeEmailCode("gladys.champl@edms.com", "000000")
+	}
+	_, err = db.GetEmailLogin("gladys.champl@edms.com")
 	assert.Error(t, err)
-	assert.Equal(t, output.AccessToken, "")
+}
+
+//blockingMailer holds every mail until the test tells how delivery ends
+type blockingMailer struct {
+	results chan error
+}
+
+func (m blockingMailer) Send(ctx context.Context, to, subject, body string) error {
+	return <-m.results
+}
+
+func TestEmailLogin_MailInBackground(t *testing.T) {
+	db, _ := db.Connection(config.GetLogger().Logger, "stub")
+	mailer := blockingMailer{results: make(chan error)}
+	svc, err := NewSessionsService(db, []byte("secret"), []byte("mfa"), newUsersStub(), WithMailer(mailer))
+	assert.NoError(t, err)
+
+	//The answer does not wait for the mail server, as it does not for unknown emails
+	assert.NoError(t, svc.RequestEmailLogin(context.Background(), models.EmailLoginData{Email: "gladys.champl@edms.com"}))
+	_, err = db.GetEmailLogin("gladys.champl@edms.com")
+	assert.NoError(t, err)
+
+	//Login that cannot be delivered is dropped
+	mailer.results <- errors.ErrMailUnavailable
+	deadline := time.Now().Add(time.Second)
+	for err == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		_, err = db.GetEmailLogin("gladys.champl@edms.com")
+	}
+	assert.Error(t, err)
+}
+
+func TestEmailLogin_ExpiredAreSwept(t *testing.T) {
+	db, _ := db.Connection(config.GetLogger().Logger, "stub")
+	mailer := blockingMailer{results: make(chan error, 1)}
+	mailer.results <- nil
+	svc, err := NewSessionsService(db, []byte("secret"), []byte("mfa"), newUsersStub(), WithMailer(mailer))
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	assert.NoError(t, svc.RequestEmailLogin(ctx, models.EmailLoginData{Email: "nobody@edms.com"}))
+	assert.NoError(t, svc.RequestEmailLogin(ctx, models.EmailLoginData{Email: "gladys.champl@edms.com"}))
+
+	//Placeholder of unknown email lives as long as it holds back the next request, a code lives until it expires
+	sessions := svc.(*SessionsService)
+	sessions.sweepEmailLogins(time.Now().Add(2 * emailLoginInterval))
+	_, err = db.GetEmailLogin("nobody@edms.com")
+	assert.Error(t, err)
+	_, err = db.GetEmailLogin("gladys.champl@edms.com")
+	assert.NoError(t, err)
+
+	sessions.sweepEmailLogins(time.Now().Add(emailLoginTTL + time.Second))
+	_, err = db.GetEmailLogin("gladys.champl@edms.com")
+	assert.Error(t, err)
+}
+
+func TestEmailLogin_NoMailer(t *testing.T) {
+	svc := PrepareServiceAndDb("user@email.com", "")
+	assert.Equal(t, errors.ErrMailUnavailable, svc.RequestEmailLogin(context.Background(), models.EmailLoginData{Email: "gladys.champl@edms.com"}))
+}
+
+func TestRefresh_RotatesRefreshToken(t *testing.T) {
+	svc := PrepareServiceAndDb("user@email.com", "")
+	ctx := context.Background()
+
+	access, refresh, err := svc.Login(ctx, models.LoginData{UserName: "gladys.champl@edms.com", Password: "pass"})
+	assert.NoError(t, err)
+
+	newAccess, rotated, err := svc.Refresh(ctx, models.RefreshData{RefreshToken: refresh.Token})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, newAccess.Token)
+	assert.NotEmpty(t, rotated.Token)
+	assert.NotEqual(t, refresh.Token, rotated.Token)
+
+	//The old refresh token is revoked by rotation
+	_, _, err = svc.Refresh(ctx, models.RefreshData{RefreshToken: refresh.Token})
+	assert.Equal(t, errors.ErrNonAuthorized, err)
+
+	//Access token cannot be used as refresh one
+	_, _, err = svc.Refresh(ctx, models.RefreshData{RefreshToken: access.Token})
+	assert.Equal(t, errors.ErrInvalidTokenType, err)
+
+	//Refresh token stops working on logout
+	assert.NoError(t, svc.Logout(ctx, models.LogoutData{Cookie: &http.Cookie{Name: "refresh_token", Value: rotated.Token}}))
+	_, _, err = svc.Refresh(ctx, models.RefreshData{RefreshToken: rotated.Token})
+	assert.Equal(t, errors.ErrNonAuthorized, err)
+}
+
+func TestRefresh_ReuseRevokesSession(t *testing.T) {
+	svc := PrepareServiceAndDb("user@email.com", "")
+	ctx := context.Background()
+
+	_, refresh, err := svc.Login(ctx, models.LoginData{UserName: "gladys.champl@edms.com", Password: "pass"})
+	assert.NoError(t, err)
+	_, other, err := svc.Login(ctx, models.LoginData{UserName: "gladys.champl@edms.com", Password: "pass"})
+	assert.NoError(t, err)
+
+	_, rotated, err := svc.Refresh(ctx, models.RefreshData{RefreshToken: refresh.Token})
+	assert.NoError(t, err)
+
+	//Replaying the rotated token ends its session, the other session is kept
+	_, _, err = svc.Refresh(ctx, models.RefreshData{RefreshToken: refresh.Token})
+	assert.Equal(t, errors.ErrNonAuthorized, err)
+	_, _, err = svc.Refresh(ctx, models.RefreshData{RefreshToken: rotated.Token})
+	assert.Equal(t, errors.ErrNonAuthorized, err)
+	_, _, err = svc.Refresh(ctx, models.RefreshData{RefreshToken: other.Token})
+	assert.NoError(t, err)
+}
+
+func TestRefresh_ConcurrentRotation(t *testing.T) {
+	svc := PrepareServiceAndDb("user@email.com", "")
+	ctx := context.Background()
+
+	_, refresh, err := svc.Login(ctx, models.LoginData{UserName: "gladys.champl@edms.com", Password: "pass"})
+	assert.NoError(t, err)
+
+	const callers = 8
+	var (
+		wg        sync.WaitGroup
+		succeeded int32
+	)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := svc.Refresh(ctx, models.RefreshData{RefreshToken: refresh.Token}); err == nil {
+				atomic.AddInt32(&succeeded, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	//Only one caller rotates the token, the others are taken for a replay
+	assert.Equal(t, int32(1), succeeded)
+}
+
+func TestCheckToken_ValidateOnly(t *testing.T) {
+	svc := PrepareServiceAndDb("user@email.com", "")
+
+	token, err := CreateAccessToken(constants.TokenIssuer, "user@email.com", 2048, false)
+	assert.NoError(t, err)
+	output, err := svc.CheckToken(context.Background(), models.CheckTokenServiceInput{AccessToken: token})
+	assert.NoError(t, err)
+	assert.Equal(t, token, output.AccessToken)
+
+	//Expired access token is not regenerated
+	expired, err := CreateAccessToken(constants.TokenIssuer, "user@email.com", 2048, true)
+	assert.NoError(t, err)
+	time.Sleep(time.Second)
+	_, err = svc.CheckToken(context.Background(), models.CheckTokenServiceInput{AccessToken: expired})
+	assert.Equal(t, errors.ErrExpiredAccessToken, err)
+}
+
+func TestCheckToken_DescribesToken(t *testing.T) {
+	svc := PrepareServiceAndDb("user@email.com", "")
+	ctx := context.Background()
+
+	access, refresh, err := svc.Login(ctx, models.LoginData{UserName: "gladys.champl@edms.com", Password: "pass"})
+	assert.NoError(t, err)
+
+	output, err := svc.CheckToken(ctx, models.CheckTokenServiceInput{AccessToken: access.Token})
+	assert.NoError(t, err)
+	assert.Equal(t, "gladys.champl@edms.com", output.Subject)
+	assert.Equal(t, int64(32767), output.Mask)
+	assert.Equal(t, []string{"admin"}, output.Permissions)
+	assert.Equal(t, access.ExpirationDate, output.ExpirationDate)
+	assert.NotEmpty(t, output.SessionID)
+
+	//Session ID survives rotation of tokens
+	newAccess, _, err := svc.Refresh(ctx, models.RefreshData{RefreshToken: refresh.Token})
+	assert.NoError(t, err)
+	rotated, err := svc.CheckToken(ctx, models.CheckTokenServiceInput{AccessToken: newAccess.Token})
+	assert.NoError(t, err)
+	assert.Equal(t, output.SessionID, rotated.SessionID)
+}
+
+func TestCheckTokens_Batch(t *testing.T) {
+	svc := PrepareServiceAndDb("user@email.com", "")
+	ctx := context.Background()
+
+	access, refresh, err := svc.Login(ctx, models.LoginData{UserName: "gladys.champl@edms.com", Password: "pass"})
+	assert.NoError(t, err)
+	expired, err := CreateAccessToken(constants.TokenIssuer, "user@email.com", 2048, true)
+	assert.NoError(t, err)
+	time.Sleep(time.Second)
+
+	verdicts, err := svc.CheckTokens(ctx, models.CheckTokensData{AccessTokens: []string{access.Token, expired, "invalid", refresh.Token}})
+	assert.NoError(t, err)
+	assert.Len(t, verdicts, 4)
+
+	assert.True(t, verdicts[0].Valid)
+	assert.Equal(t, "gladys.champl@edms.com", verdicts[0].Claims.Subject)
+	assert.Equal(t, []string{"admin"}, verdicts[0].Claims.Permissions)
+	assert.Equal(t, models.TokenVerdict{Err: errors.ErrExpiredAccessToken}, verdicts[1])
+	assert.Equal(t, models.TokenVerdict{Err: errors.ErrNonAuthorized}, verdicts[2])
+	assert.False(t, verdicts[3].Valid)
+	assert.Error(t, verdicts[3].Err)
+}
+
+func TestCheckTokens_BatchTooLarge(t *testing.T) {
+	svc := PrepareServiceAndDb("user@email.com", "")
+
+	tokens := make([]string, constants.MaxTokensPerBatch+1)
+	_, err := svc.CheckTokens(context.Background(), models.CheckTokensData{AccessTokens: tokens})
+	assert.Equal(t, errors.ErrBatchTooLarge, err)
+
+	//A full batch is evaluated token by token
+	verdicts, err := svc.CheckTokens(context.Background(), models.CheckTokensData{AccessTokens: tokens[1:]})
+	assert.NoError(t, err)
+	assert.Len(t, verdicts, constants.MaxTokensPerBatch)
+	for _, verdict := range verdicts {
+		assert.False(t, verdict.Valid)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	registry, err := permissions.ParseRegistry([]byte(`{"documents.read": 0, "documents.sign": 11, "admin": 14}`))
+	assert.NoError(t, err)
+	db, _ := db.Connection(config.GetLogger().Logger, "stub")
+	svc, err := NewSessionsService(db, []byte("secret"), []byte("mfa"), newUsersStub(), WithPermissions(registry))
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	token, err := CreateAccessToken(constants.TokenIssuer, "user@email.com", 1|1<<11, false)
+	assert.NoError(t, err)
+
+	output, err := svc.Authorize(ctx, models.AuthorizeData{AccessToken: token, Permissions: []string{"documents.read", "documents.sign"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "user@email.com", output.Subject)
+	assert.Equal(t, []string{"documents.read", "documents.sign"}, output.Permissions)
+
+	_, err = svc.Authorize(ctx, models.AuthorizeData{AccessToken: token, Permissions: []string{"documents.read", "admin"}, Mode: permissions.All})
+	assert.Equal(t, errors.ErrNoPermissions, err)
+	_, err = svc.Authorize(ctx, models.AuthorizeData{AccessToken: token, Permissions: []string{"documents.read", "admin"}, Mode: permissions.Any})
+	assert.NoError(t, err)
+	_, err = svc.Authorize(ctx, models.AuthorizeData{AccessToken: token, Permissions: []string{"documents.delete"}})
+	assert.Equal(t, errors.ErrUnknownPermission, err)
+	_, err = svc.Authorize(ctx, models.AuthorizeData{AccessToken: "invalid", Permissions: []string{"documents.read"}})
+	assert.Equal(t, errors.ErrNonAuthorized, err)
+}
+
+func TestUnlockLogin_ConfiguredAdminBit(t *testing.T) {
+	registry, err := permissions.ParseRegistry([]byte(`{"documents.read": 0, "admin": 3}`))
+	assert.NoError(t, err)
+	db, _ := db.Connection(config.GetLogger().Logger, "stub")
+	svc, err := NewSessionsService(db, []byte("secret"), []byte("mfa"), newUsersStub(), WithPermissions(registry))
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	//Admin is the bit of the registry, not the default one
+	token, err := CreateAccessToken(constants.TokenIssuer, "user@email.com", constants.AdminPermission, false)
+	assert.NoError(t, err)
+	assert.Equal(t, errors.ErrNoPermissions, svc.UnlockLogin(ctx, models.UnlockLoginData{AccessToken: token, UserName: "nobody@edms.com"}))
+
+	token, err = CreateAccessToken(constants.TokenIssuer, "user@email.com", 1<<3, false)
+	assert.NoError(t, err)
+	assert.NoError(t, svc.UnlockLogin(ctx, models.UnlockLoginData{AccessToken: token, UserName: "nobody@edms.com"}))
+	//Permission is granted, the user just has no MFA to reset
+	assert.Equal(t, errors.ErrMFANotEnrolled, svc.ResetMFA(ctx, models.ResetMFAData{AccessToken: token, UserName: "nobody@edms.com", Devices: true}))
+}
+
+func TestDecide(t *testing.T) {
+	engine := policy.NewEngine()
+	assert.NoError(t, engine.Load([]byte(`{"policies": [{"name": "admins from office", "effect": "allow", "actions": ["documents.approve"],
+		"when": {"all": [{"attr": "profile.role", "op": "eq", "value": "admin"}, {"attr": "request.ip", "op": "cidr", "value": ["10.0.0.0/8"]}]}}]}`)))
+	db, _ := db.Connection(config.GetLogger().Logger, "stub")
+	svc, err := NewSessionsService(db, []byte("secret"), []byte("mfa"), newUsersStub(), WithPolicies(engine))
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	access, _, err := svc.Login(ctx, models.LoginData{UserName: "gladys.champl@edms.com", Password: "pass"})
+	assert.NoError(t, err)
+
+	decision, err := svc.Decide(ctx, models.DecideData{AccessToken: access.Token, Action: "documents.approve", IP: "10.0.0.1"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.Decision{Allowed: true, Policy: "admins from office"}, decision)
+
+	decision, err = svc.Decide(ctx, models.DecideData{AccessToken: access.Token, Action: "documents.approve", IP: "192.0.2.1"})
+	assert.NoError(t, err)
+	assert.False(t, decision.Allowed)
+
+	_, err = svc.Decide(ctx, models.DecideData{AccessToken: "invalid", Action: "documents.approve"})
+	assert.Equal(t, errors.ErrNonAuthorized, err)
 }
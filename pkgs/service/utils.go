@@ -1,12 +1,10 @@
 package service
 
 import (
-	"crypto/tls"
-	"crypto/x509"
-	"encoding/json"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
-	"net/http"
-	"strings"
 	"time"
 
 	"encoding/base64"
@@ -18,97 +16,106 @@ import (
 	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
 )
 
-//EnsureUserCreds sends authentification request to Users service
-func (sStub *SessionsService) EnsureUserCreds(username, password string) (err error) {
-	req, err := http.NewRequest("GET", constants.URIOnAuthentification, nil)
-	req.SetBasicAuth(username, password)
+//GenerateToken generates and signs token according to toke type. Uses sgining method based on HS256
+func (sStub *SessionsService) GenerateToken(tokenType TokenType, id string, mask int64) (models.TokenData, error) {
+	return sStub.GenerateSessionToken(tokenType, id, mask, "")
+}
+
+//GenerateSessionToken generates token bound to a session: access and refresh tokens of one session share its ID in "sid" claim
+func (sStub *SessionsService) GenerateSessionToken(tokenType TokenType, id string, mask int64, sid string) (models.TokenData, error) {
+	var err error
 
+	claims, exp, err := CreatePayload(tokenType, id, constants.TokenIssuer, mask)
 	if err != nil {
-		return err
+		return models.TokenData{}, err
 	}
+	if len(sid) != 0 {
+		claims["sid"] = sid
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString([]byte(sStub.secret))
 
-	resp, err := sStub.client.Do(req)
 	if err != nil {
-		return err
+		return models.TokenData{}, err
 	}
 
-	switch resp.StatusCode {
-	case http.StatusOK:
-		break
-	case http.StatusUnauthorized:
-		return errors.ErrNonAuthorized
-	case http.StatusNotFound:
-		return errors.ErrClientUnknown
-	default:
-		return fmt.Errorf("Authentification request failed with code: %v", resp.StatusCode)
+	kind := "Bearer"
+	if tokenType == challenge {
+		kind = constants.MFAChallengeType
 	}
 
-	return nil
+	return models.TokenData{
+		Token:          tokenString,
+		Type:           kind,
+		ExpirationDate: exp,
+	}, err
 }
 
-//GetUserProfile sends a request to Users service to obtain user profile by his email
-func (sStub *SessionsService) GetUserProfile(email, token string) (profile models.UserProfile, err error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf(constants.URIOnGetProfile, email), nil)
+//NewServiceTokenSource returns a source of service tokens signed with PEM encoded EC private key. The key differs from
+//the secret of user sessions, so that Users service verifies service tokens with the public key and cannot mint tokens
+//of users
+func NewServiceTokenSource(pemKey []byte) (models.ServiceTokenSource, error) {
+	key, err := jwt.ParseECPrivateKeyFromPEM(pemKey)
 	if err != nil {
-		return models.UserProfile{}, err
+		return nil, errors.ErrInvalidServiceKey
 	}
 
-	req.Header.Add("Authorization", "Bearer "+token)
-	resp, err := sStub.client.Do(req)
-	if err != nil {
-		return models.UserProfile{}, err
-	}
-
-	switch resp.StatusCode {
-	case http.StatusOK:
-		break
-	case http.StatusBadRequest:
-		return models.UserProfile{}, errors.ErrRequestToUsersFailed
-	case http.StatusUnauthorized:
-		return models.UserProfile{}, errors.ErrNonAuthorized
-	case http.StatusNotFound:
-		return models.UserProfile{}, errors.ErrClientUnknown
-	default:
-		return models.UserProfile{}, fmt.Errorf("Get profile request failed with code: %v", resp.StatusCode)
-	}
+	return func() (models.TokenData, error) {
+		return GenerateServiceToken(key)
+	}, nil
+}
 
-	if !strings.Contains(resp.Header.Get("Content-Type"), "application/json") {
-		return models.UserProfile{}, fmt.Errorf("Get profile received content type: %v", resp.Header.Get("Content-Type"))
+//GenerateServiceToken mints a token that identifies Sessions service in outbound calls. It is signed with ES256 and
+//accepted by Users service only
+func GenerateServiceToken(key *ecdsa.PrivateKey) (models.TokenData, error) {
+	claims, exp, err := CreatePayload(service, constants.ServiceTokenSubject, constants.TokenIssuer, 0)
+	if err != nil {
+		return models.TokenData{}, err
 	}
 
-	defer resp.Body.Close()
-	err = json.NewDecoder(resp.Body).Decode(&profile)
-
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(key)
 	if err != nil {
-		return models.UserProfile{}, fmt.Errorf("Profile decoding failed")
+		return models.TokenData{}, err
 	}
 
-	return profile, nil
+	return models.TokenData{
+		Token:          tokenString,
+		Type:           "Bearer",
+		ExpirationDate: exp,
+	}, nil
 }
 
-//GenerateToken generates and signs token according to toke type. Uses sgining method based on HS256
-func (sStub *SessionsService) GenerateToken(tokenType TokenType, id string, mask int64) (models.TokenData, error) {
-	var err error
-
-	claims, exp, err := CreatePayload(tokenType, id, constants.TokenIssuer, mask)
+//GenerateDeviceToken mints a token that identifies a remembered device of a user
+func (sStub *SessionsService) GenerateDeviceToken(sub, deviceID string) (models.TokenData, error) {
+	claims, exp, err := CreatePayload(device, sub, constants.TokenIssuer, 0)
 	if err != nil {
 		return models.TokenData{}, err
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(sStub.secret))
+	claims["did"] = deviceID //device ID
 
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(sStub.secret))
 	if err != nil {
 		return models.TokenData{}, err
 	}
 
 	return models.TokenData{
 		Token:          tokenString,
-		Type:           "Bearer",
+		Type:           constants.DeviceTokenType,
 		ExpirationDate: exp,
-	}, err
+	}, nil
+}
+
+//GenerateID returns a random identifier
+func GenerateID() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(raw), nil
 }
 
-//CreatePayload returns claims for JWT. If token type is "access" it returns claims for access token, or for refresh token otherwise
+//CreatePayload returns claims for JWT according to token type: access, refresh, service, MFA challenge or device token
 func CreatePayload(tokenType TokenType, cid, iss string, mask int64) (jwt.MapClaims, int64, error) {
 	var (
 		exp    int64
@@ -131,6 +138,12 @@ func CreatePayload(tokenType TokenType, cid, iss string, mask int64) (jwt.MapCla
 			"aud":  []string{cid, iss}, //audience claim. See: https://tools.ietf.org/html/rfc7519#
 		}
 	case refresh:
+		//A user may have several sessions, so refresh tokens issued within the same second must differ
+		jti, err := GenerateID()
+		if err != nil {
+			return jwt.MapClaims{}, 0, err
+		}
+
 		exp = time.Now().Add(time.Duration(720) * time.Hour).Unix()
 		claims = jwt.MapClaims{
 			"iss": iss,                //token issue
@@ -139,6 +152,41 @@ func CreatePayload(tokenType TokenType, cid, iss string, mask int64) (jwt.MapCla
 			"nbf": iat,                //issued not before
 			"exp": exp,                //expiration time
 			"aud": []string{cid, iss}, //audience claim. See: https://tools.ietf.org/html/rfc7519#
+			"jti": jti,                //unique token ID
+		}
+	case service:
+		exp = time.Now().Add(time.Duration(5) * time.Minute).Unix()
+		claims = jwt.MapClaims{
+			"iss": iss,                        //token issue
+			"sub": cid,                        //calling service
+			"iat": iat,                        //issued at
+			"nbf": iat,                        //issued not before
+			"exp": exp,                        //expiration time
+			"aud": constants.UsersAudience,    //token is valid for Users service only
+			"typ": constants.ServiceTokenType, //marks token as a service one
+		}
+	case challenge:
+		exp = time.Now().Add(time.Duration(5) * time.Minute).Unix()
+		claims = jwt.MapClaims{
+			"iss":  iss,                          //token issue
+			"sub":  cid,                          //user email
+			"iat":  iat,                          //issued at
+			"nbf":  iat,                          //issued not before
+			"exp":  exp,                          //expiration time
+			"mask": mask,                         //user mask, carried to the second step of login
+			"aud":  iss,                          //token is accepted by Sessions service only
+			"typ":  constants.ChallengeTokenType, //marks token as MFA challenge
+		}
+	case device:
+		exp = time.Now().Add(time.Duration(24*constants.TrustedDeviceDays) * time.Hour).Unix()
+		claims = jwt.MapClaims{
+			"iss": iss,                       //token issue
+			"sub": cid,                       //user email
+			"iat": iat,                       //issued at
+			"nbf": iat,                       //issued not before
+			"exp": exp,                       //expiration time
+			"aud": iss,                       //token is accepted by Sessions service only
+			"typ": constants.DeviceTokenType, //marks token as a remembered device one
 		}
 	default:
 		return jwt.MapClaims{}, 0, errors.ErrInvalidTokenType
@@ -163,8 +211,19 @@ func (sStub *SessionsService) CheckTokenValidness(tokenString string) (jwt.MapCl
 		return jwt.MapClaims{}, errors.ErrNonAuthorized
 	}
 
-	//Check token validness
+	//Expired tokens are still parsed since refresh relies on them. Any other validation error (e.g. invalid signature) means the token is forged
+	if err != nil {
+		if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors&^jwt.ValidationErrorExpired != 0 {
+			sStub.Logger.Log("method", "GetRawToken", "err", err)
+			return jwt.MapClaims{}, errors.ErrNonAuthorized
+		}
+	}
+
+	//Check token validness. Tokens for Users service are never accepted by Sessions service, whatever they are signed with
 	if claims, ok := token.Claims.(jwt.MapClaims); ok {
+		if hasAudience(claims, constants.UsersAudience) {
+			return jwt.MapClaims{}, errors.ErrInvalidTokenType
+		}
 		return claims, nil
 	}
 
@@ -173,6 +232,173 @@ func (sStub *SessionsService) CheckTokenValidness(tokenString string) (jwt.MapCl
 	return jwt.MapClaims{}, errors.ErrNonAuthorized
 }
 
+//Authenticate checks that access token is valid and not expired, and returns its subject and mask
+func (sStub *SessionsService) Authenticate(accessToken string) (sub string, mask int64, err error) {
+	claims, err := sStub.AccessTokenClaims(accessToken)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return subjectAndMask(claims)
+}
+
+//AccessTokenClaims checks that access token is valid and not expired, and returns its claims
+func (sStub *SessionsService) AccessTokenClaims(accessToken string) (jwt.MapClaims, error) {
+	claims, err := sStub.CheckTokenValidness(accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = EnsureSessionToken(claims); err != nil {
+		return nil, err
+	}
+
+	expired, err := IsExpired(claims)
+	if err != nil {
+		return nil, err
+	}
+	if expired {
+		return nil, errors.ErrExpiredAccessToken
+	}
+
+	return claims, nil
+}
+
+//ParseChallenge checks that MFA challenge token is valid and not expired, and returns its subject and mask
+func (sStub *SessionsService) ParseChallenge(challengeToken string) (sub string, mask int64, err error) {
+	claims, err := sStub.CheckTokenValidness(challengeToken)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if typ, _ := claims["typ"].(string); typ != constants.ChallengeTokenType {
+		return "", 0, errors.ErrInvalidTokenType
+	}
+	if len(claims) != constants.ClaimsPerChallenge {
+		return "", 0, errors.ErrInvalidClaimInToken
+	}
+
+	expired, err := IsExpired(claims)
+	if err != nil {
+		return "", 0, err
+	}
+	if expired {
+		return "", 0, errors.ErrNonAuthorized
+	}
+
+	return subjectAndMask(claims)
+}
+
+//ParseDeviceToken checks that device token is valid and not expired, and returns its subject and device ID
+func (sStub *SessionsService) ParseDeviceToken(deviceToken string) (sub, deviceID string, err error) {
+	claims, err := sStub.CheckTokenValidness(deviceToken)
+	if err != nil {
+		return "", "", err
+	}
+
+	if typ, _ := claims["typ"].(string); typ != constants.DeviceTokenType {
+		return "", "", errors.ErrInvalidTokenType
+	}
+	if len(claims) != constants.ClaimsPerDeviceToken {
+		return "", "", errors.ErrInvalidClaimInToken
+	}
+
+	expired, err := IsExpired(claims)
+	if err != nil {
+		return "", "", err
+	}
+	if expired {
+		return "", "", errors.ErrNonAuthorized
+	}
+
+	var ok bool
+	if sub, ok = claims["sub"].(string); !ok {
+		return "", "", errors.ErrInvalidClaimInToken
+	}
+	if deviceID, ok = claims["did"].(string); !ok {
+		return "", "", errors.ErrInvalidClaimInToken
+	}
+
+	return sub, deviceID, nil
+}
+
+//ParseRefreshToken checks that refresh token is valid and not expired, and returns its subject and session ID.
+//Access tokens carry a mask, so they are told apart by its absence. Tokens issued before sessions got IDs have none
+func (sStub *SessionsService) ParseRefreshToken(refreshToken string) (sub, sid string, err error) {
+	claims, err := sStub.CheckTokenValidness(refreshToken)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err = EnsureSessionToken(claims); err != nil {
+		return "", "", err
+	}
+	if _, ok := claims["mask"]; ok {
+		return "", "", errors.ErrInvalidTokenType
+	}
+
+	expired, err := IsExpired(claims)
+	if err != nil {
+		return "", "", err
+	}
+	if expired {
+		return "", "", errors.ErrNonAuthorized
+	}
+
+	var ok bool
+	if sub, ok = claims["sub"].(string); !ok {
+		return "", "", errors.ErrInvalidClaimInToken
+	}
+	sid, _ = claims["sid"].(string)
+
+	return sub, sid, nil
+}
+
+func subjectAndMask(claims jwt.MapClaims) (string, int64, error) {
+	sub, ok := claims["sub"].(string)
+	if !ok {
+		return "", 0, errors.ErrInvalidClaimInToken
+	}
+
+	mask, ok := claims["mask"].(float64)
+	if !ok {
+		return "", 0, errors.ErrInvalidClaimInToken
+	}
+
+	return sub, int64(mask), nil
+}
+
+//hasAudience tells whether "aud" claim, either a string or a list of strings, names the audience
+func hasAudience(claims jwt.MapClaims, audience string) bool {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, a := range aud {
+			if a == audience {
+				return true
+			}
+		}
+	case []string:
+		for _, a := range aud {
+			if a == audience {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+//EnsureSessionToken returns an error if claims belong to a token that was not issued for a user session
+func EnsureSessionToken(claims jwt.MapClaims) error {
+	if _, ok := claims["typ"]; ok {
+		return errors.ErrInvalidTokenType
+	}
+
+	return nil
+}
+
 //IsExpired checks whether a token is expired
 func IsExpired(claims jwt.MapClaims) (bool, error) {
 	if claims == nil {
@@ -198,23 +424,3 @@ func IsExpired(claims jwt.MapClaims) (bool, error) {
 func EncodeSessionSecret(s string) (encoded string) {
 	return base64.StdEncoding.EncodeToString([]byte(s))
 }
-
-func MakeHTTPClient(pKey []byte) (*http.Client, error) {
-	if len(pKey) == 0 {
-		return nil, errors.ErrPublicKeyIsMissing
-	}
-
-	caCertPool := x509.NewCertPool()
-	caCertPool.AppendCertsFromPEM(pKey)
-
-	client := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				RootCAs:            caCertPool,
-				InsecureSkipVerify: true,
-			},
-		},
-	}
-
-	return client, nil
-}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"strings"
+	"time"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/webauthn"
+)
+
+const publicKeyType = "public-key"
+
+//BeginWebAuthnRegistration starts registration of a passkey for an authorized user. Already registered credentials are
+//excluded, so that an authenticator is not registered twice
+func (svc *SessionsService) BeginWebAuthnRegistration(ctx context.Context, rd models.WebAuthnRegistrationData) (res models.WebAuthnCreationOptions, err error) {
+	sub, _, err := svc.Authenticate(rd.AccessToken)
+	if err != nil {
+		return res, err
+	}
+
+	profile, err := svc.Users.GetUserProfile(ctx, sub)
+	if err != nil {
+		svc.Logger.Log("method", "GetUserProfile", "action", "retrieving user profile", "error", err)
+		return res, err
+	}
+
+	credentials, err := svc.Db.ListCredentials(sub)
+	if err != nil {
+		return res, err
+	}
+
+	challenge, err := svc.newCeremony(webauthn.CeremonyCreate, sub)
+	if err != nil {
+		return res, err
+	}
+
+	//User handle must not contain personal data, so a hash of the user name is used
+	handle := sha256.Sum256([]byte(sub))
+
+	res = models.WebAuthnCreationOptions{
+		Challenge: challenge,
+		RP:        models.WebAuthnEntity{ID: svc.rp.ID, Name: svc.rp.Name},
+		User: models.WebAuthnUser{
+			ID:          webauthn.Encoding.EncodeToString(handle[:]),
+			Name:        sub,
+			DisplayName: strings.TrimSpace(profile.First_name + " " + profile.Last_name),
+		},
+		PubKeyCredParams: []models.WebAuthnCredentialParameter{
+			{Type: publicKeyType, Alg: webauthn.AlgES256},
+			{Type: publicKeyType, Alg: webauthn.AlgEdDSA},
+		},
+		Timeout:            int64(webauthn.Timeout / time.Millisecond),
+		Attestation:        "none",
+		ExcludeCredentials: descriptors(credentials),
+		AuthenticatorSelection: models.WebAuthnAuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: "required",
+		},
+	}
+
+	return res, nil
+}
+
+//FinishWebAuthnRegistration verifies the new credential and stores it for the user
+func (svc *SessionsService) FinishWebAuthnRegistration(ctx context.Context, rd models.WebAuthnRegistrationFinishData) error {
+	sub, _, err := svc.Authenticate(rd.AccessToken)
+	if err != nil {
+		return err
+	}
+
+	clientData, err := webauthn.DecodeBase64(rd.Credential.Response.ClientDataJSON)
+	if err != nil {
+		return err
+	}
+	attestation, err := webauthn.DecodeBase64(rd.Credential.Response.AttestationObject)
+	if err != nil {
+		return err
+	}
+
+	challenge, err := svc.consumeCeremony(webauthn.CeremonyCreate, clientData)
+	if err != nil {
+		return err
+	}
+	if challenge.UserID != sub {
+		return errors.ErrWebAuthnChallenge
+	}
+
+	credential, err := svc.rp.VerifyRegistration(challenge.Challenge, clientData, attestation)
+	if err != nil {
+		svc.Logger.Log("method", "FinishWebAuthnRegistration", "action", "verifying attestation", "error", err)
+		return err
+	}
+
+	id := webauthn.Encoding.EncodeToString(credential.ID)
+	if _, err = svc.Db.GetCredential(id); err == nil {
+		return errors.ErrCredentialAlreadyExists
+	}
+
+	return svc.Db.SaveCredential(models.WebAuthnCredential{
+		ID:        id,
+		UserID:    sub,
+		PublicKey: credential.PublicKey,
+		SignCount: credential.SignCount,
+		CreatedAt: time.Now().Unix(),
+	})
+}
+
+//BeginWebAuthnLogin starts passwordless login. If user name is given, only credentials of that user are allowed
+func (svc *SessionsService) BeginWebAuthnLogin(ctx context.Context, ld models.WebAuthnLoginData) (res models.WebAuthnRequestOptions, err error) {
+	var credentials []models.WebAuthnCredential
+	if len(ld.UserName) != 0 {
+		if credentials, err = svc.Db.ListCredentials(ld.UserName); err != nil {
+			return res, err
+		}
+	}
+
+	challenge, err := svc.newCeremony(webauthn.CeremonyGet, ld.UserName)
+	if err != nil {
+		return res, err
+	}
+
+	res = models.WebAuthnRequestOptions{
+		Challenge:        challenge,
+		Timeout:          int64(webauthn.Timeout / time.Millisecond),
+		RPID:             svc.rp.ID,
+		AllowCredentials: descriptors(credentials),
+		UserVerification: "required",
+	}
+
+	return res, nil
+}
+
+//FinishWebAuthnLogin verifies the assertion, updates signature counter of the credential and issues a pair of tokens.
+//Passkeys verify the user on the authenticator, so MFA challenge is not required
+func (svc *SessionsService) FinishWebAuthnLogin(ctx context.Context, ld models.WebAuthnLoginFinishData) (resAccess, resRefresh models.TokenData, err error) {
+	response := ld.Credential.Response
+
+	clientData, err := webauthn.DecodeBase64(response.ClientDataJSON)
+	if err != nil {
+		return resAccess, resRefresh, err
+	}
+	authData, err := webauthn.DecodeBase64(response.AuthenticatorData)
+	if err != nil {
+		return resAccess, resRefresh, err
+	}
+	signature, err := webauthn.DecodeBase64(response.Signature)
+	if err != nil {
+		return resAccess, resRefresh, err
+	}
+
+	challenge, err := svc.consumeCeremony(webauthn.CeremonyGet, clientData)
+	if err != nil {
+		return resAccess, resRefresh, err
+	}
+
+	stored, err := svc.Db.GetCredential(strings.TrimRight(ld.Credential.ID, "="))
+	if err != nil {
+		return resAccess, resRefresh, err
+	}
+	if len(challenge.UserID) != 0 && challenge.UserID != stored.UserID {
+		return resAccess, resRefresh, errors.ErrWebAuthnVerification
+	}
+
+	count, err := svc.rp.VerifyAssertion(challenge.Challenge, webauthn.Credential{
+		PublicKey: stored.PublicKey,
+		SignCount: stored.SignCount,
+	}, clientData, authData, signature)
+	if err != nil {
+		svc.Logger.Log("method", "FinishWebAuthnLogin", "action", "verifying assertion", "credential", stored.ID, "error", err)
+		return resAccess, resRefresh, err
+	}
+
+	stored.SignCount = count
+	if err = svc.Db.SaveCredential(stored); err != nil {
+		return resAccess, resRefresh, err
+	}
+
+	profile, err := svc.Users.GetUserProfile(ctx, stored.UserID)
+	if err != nil {
+		svc.Logger.Log("method", "GetUserProfile", "action", "retrieving user profile", "error", err)
+		return resAccess, resRefresh, err
+	}
+
+	return svc.issueTokens(stored.UserID, profile.Role, ld.ClientIP, ld.UserAgent)
+}
+
+//newCeremony creates and saves a challenge of a ceremony
+func (svc *SessionsService) newCeremony(ceremony, sub string) (string, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return "", err
+	}
+
+	err = svc.Db.SaveChallenge(models.WebAuthnChallenge{
+		Challenge:      challenge,
+		Ceremony:       ceremony,
+		UserID:         sub,
+		ExpirationDate: time.Now().Add(webauthn.Timeout).Unix(),
+	})
+
+	return challenge, err
+}
+
+//consumeCeremony finds the challenge client data was created for and removes it, so that a response cannot be replayed
+func (svc *SessionsService) consumeCeremony(ceremony string, clientData []byte) (models.WebAuthnChallenge, error) {
+	challenge, err := webauthn.ChallengeOf(clientData)
+	if err != nil {
+		return models.WebAuthnChallenge{}, err
+	}
+
+	record, err := svc.Db.ConsumeChallenge(challenge)
+	if err != nil {
+		return models.WebAuthnChallenge{}, err
+	}
+
+	if record.Ceremony != ceremony || record.ExpirationDate <= time.Now().Unix() {
+		return models.WebAuthnChallenge{}, errors.ErrWebAuthnChallenge
+	}
+
+	return record, nil
+}
+
+func descriptors(credentials []models.WebAuthnCredential) []models.WebAuthnCredentialDescriptor {
+	res := make([]models.WebAuthnCredentialDescriptor, 0, len(credentials))
+	for _, credential := range credentials {
+		res = append(res, models.WebAuthnCredentialDescriptor{Type: publicKeyType, ID: credential.ID})
+	}
+
+	return res
+}
@@ -0,0 +1,218 @@
+package users
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+)
+
+//CacheConfig describes profile cache bounds
+type CacheConfig struct {
+	TTL         time.Duration //how long a profile is served from cache
+	NegativeTTL time.Duration //how long an unknown user is remembered as unknown
+	Size        int           //maximum number of cached entries, least recently used are evicted first
+}
+
+//DefaultCacheConfig returns profile cache settings used by the service unless overridden
+func DefaultCacheConfig() CacheConfig {
+	return CacheConfig{
+		TTL:         5 * time.Minute,
+		NegativeTTL: time.Minute,
+		Size:        10000,
+	}
+}
+
+//CacheMetrics collects cache efficiency
+type CacheMetrics struct {
+	Hits    metrics.Counter
+	Misses  metrics.Counter
+	HitRate metrics.Gauge
+}
+
+type cacheEntry struct {
+	email   string
+	profile models.UserProfile
+	unknown bool
+	expires time.Time
+}
+
+//CachingClient decorates Users client with a TTL and size bounded profile cache keyed by email
+type CachingClient struct {
+	next    models.IUsersClient
+	cfg     CacheConfig
+	metrics CacheMetrics
+	now     func() time.Time
+
+	mtx     sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	hits    uint64
+	misses  uint64
+}
+
+//NewCachingClient wraps next with a profile cache
+func NewCachingClient(next models.IUsersClient, cfg CacheConfig, m CacheMetrics) *CachingClient {
+	return &CachingClient{
+		next:    next,
+		cfg:     cfg,
+		metrics: m,
+		now:     time.Now,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+//EnsureUserCreds is never cached since it verifies a password. Users known to be unknown are rejected without a round-trip
+func (c *CachingClient) EnsureUserCreds(ctx context.Context, username, password string) error {
+	if entry, ok := c.lookup(username); ok && entry.unknown {
+		return errors.ErrClientUnknown
+	}
+
+	err := c.next.EnsureUserCreds(ctx, username, password)
+	if err == errors.ErrClientUnknown {
+		c.store(cacheEntry{email: username, unknown: true})
+	}
+
+	return err
+}
+
+//GetUserProfile returns cached profile or requests it from Users service
+func (c *CachingClient) GetUserProfile(ctx context.Context, email string) (models.UserProfile, error) {
+	if entry, ok := c.lookup(email); ok {
+		c.record(true)
+		if entry.unknown {
+			return models.UserProfile{}, errors.ErrClientUnknown
+		}
+		return entry.profile, nil
+	}
+	c.record(false)
+
+	profile, err := c.next.GetUserProfile(ctx, email)
+	switch err {
+	case nil:
+		c.store(cacheEntry{email: email, profile: profile})
+	case errors.ErrClientUnknown:
+		c.store(cacheEntry{email: email, unknown: true})
+	}
+
+	return profile, err
+}
+
+//Invalidate drops cached profile of a user, e.g. when the profile was changed or the user was removed
+func (c *CachingClient) Invalidate(email string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if elem, ok := c.entries[normalize(email)]; ok {
+		c.remove(elem)
+	}
+}
+
+//Purge drops all cached profiles
+func (c *CachingClient) Purge() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+}
+
+//HitRate returns a share of profile lookups served from cache
+func (c *CachingClient) HitRate() float64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.hitRate()
+}
+
+func (c *CachingClient) lookup(email string) (cacheEntry, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	elem, ok := c.entries[normalize(email)]
+	if !ok {
+		return cacheEntry{}, false
+	}
+
+	entry := elem.Value.(cacheEntry)
+	if c.now().After(entry.expires) {
+		c.remove(elem)
+		return cacheEntry{}, false
+	}
+
+	c.lru.MoveToFront(elem)
+	return entry, true
+}
+
+func (c *CachingClient) store(entry cacheEntry) {
+	if c.cfg.Size <= 0 {
+		return
+	}
+
+	ttl := c.cfg.TTL
+	if entry.unknown {
+		ttl = c.cfg.NegativeTTL
+	}
+	entry.expires = c.now().Add(ttl)
+	entry.email = normalize(entry.email)
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if elem, ok := c.entries[entry.email]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[entry.email] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.cfg.Size {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *CachingClient) remove(elem *list.Element) {
+	delete(c.entries, elem.Value.(cacheEntry).email)
+	c.lru.Remove(elem)
+}
+
+func (c *CachingClient) record(hit bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if hit {
+		c.hits++
+		if c.metrics.Hits != nil {
+			c.metrics.Hits.Add(1)
+		}
+	} else {
+		c.misses++
+		if c.metrics.Misses != nil {
+			c.metrics.Misses.Add(1)
+		}
+	}
+
+	if c.metrics.HitRate != nil {
+		c.metrics.HitRate.Set(c.hitRate())
+	}
+}
+
+func (c *CachingClient) hitRate() float64 {
+	total := c.hits + c.misses
+	if total == 0 {
+		return 0
+	}
+	return float64(c.hits) / float64(total)
+}
+
+//normalize makes cache keys case-insensitive since emails are
+func normalize(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
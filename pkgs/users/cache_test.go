@@ -0,0 +1,113 @@
+package users
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/metrics/discard"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+)
+
+type countingClient struct {
+	profiles map[string]models.UserProfile
+	calls    int
+}
+
+func (c *countingClient) EnsureUserCreds(ctx context.Context, username, password string) error {
+	c.calls++
+	if _, ok := c.profiles[username]; !ok {
+		return errors.ErrClientUnknown
+	}
+	return nil
+}
+
+func (c *countingClient) GetUserProfile(ctx context.Context, email string) (models.UserProfile, error) {
+	c.calls++
+	profile, ok := c.profiles[email]
+	if !ok {
+		return models.UserProfile{}, errors.ErrClientUnknown
+	}
+	return profile, nil
+}
+
+func prepareCache(size int) (*CachingClient, *countingClient) {
+	next := &countingClient{profiles: map[string]models.UserProfile{
+		"gladys.champl@edms.com": {Email: "gladys.champl@edms.com"},
+		"admin@edms.com":         {Email: "admin@edms.com"},
+	}}
+	cfg := DefaultCacheConfig()
+	cfg.Size = size
+
+	return NewCachingClient(next, cfg, CacheMetrics{
+		Hits:    discard.NewCounter(),
+		Misses:  discard.NewCounter(),
+		HitRate: discard.NewGauge(),
+	}), next
+}
+
+func TestCache_ServesRepeatedLookups(t *testing.T) {
+	cache, next := prepareCache(10)
+
+	//Cache keys are case-insensitive as emails are
+	for _, email := range []string{"gladys.champl@edms.com", "Gladys.Champl@edms.com", "GLADYS.CHAMPL@EDMS.COM"} {
+		profile, err := cache.GetUserProfile(context.Background(), email)
+		assert.NoError(t, err)
+		assert.Equal(t, "gladys.champl@edms.com", profile.Email)
+	}
+
+	assert.Equal(t, 1, next.calls)
+	assert.InDelta(t, 2.0/3.0, cache.HitRate(), 0.001)
+}
+
+func TestCache_Expiration(t *testing.T) {
+	cache, next := prepareCache(10)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	cache.GetUserProfile(context.Background(), "admin@edms.com")
+	now = now.Add(DefaultCacheConfig().TTL + time.Second)
+	cache.GetUserProfile(context.Background(), "admin@edms.com")
+
+	assert.Equal(t, 2, next.calls)
+}
+
+func TestCache_NegativeCaching(t *testing.T) {
+	cache, next := prepareCache(10)
+
+	_, err := cache.GetUserProfile(context.Background(), "user@email.com")
+	assert.Equal(t, errors.ErrClientUnknown, err)
+
+	//Neither profile nor credentials of an unknown user are requested again
+	_, err = cache.GetUserProfile(context.Background(), "user@email.com")
+	assert.Equal(t, errors.ErrClientUnknown, err)
+	assert.Equal(t, errors.ErrClientUnknown, cache.EnsureUserCreds(context.Background(), "user@email.com", "pass"))
+	assert.Equal(t, 1, next.calls)
+}
+
+func TestCache_SizeBound(t *testing.T) {
+	cache, next := prepareCache(1)
+
+	cache.GetUserProfile(context.Background(), "admin@edms.com")
+	cache.GetUserProfile(context.Background(), "gladys.champl@edms.com")
+	cache.GetUserProfile(context.Background(), "admin@edms.com")
+
+	//The first profile was evicted by the second one
+	assert.Equal(t, 3, next.calls)
+}
+
+func TestCache_Invalidate(t *testing.T) {
+	cache, next := prepareCache(10)
+
+	cache.GetUserProfile(context.Background(), "admin@edms.com")
+	cache.Invalidate("admin@edms.com")
+	cache.GetUserProfile(context.Background(), "admin@edms.com")
+	assert.Equal(t, 2, next.calls)
+
+	cache.Purge()
+	cache.GetUserProfile(context.Background(), "admin@edms.com")
+	assert.Equal(t, 3, next.calls)
+}
@@ -0,0 +1,198 @@
+package users
+
+//  client.go
+//  https://github.com/apriorit/Soroka-EDMS/svc/sessions/pkgs/users
+//
+//  Describes a client of Users service. Each call is a go-kit client endpoint wrapped with
+//  per-attempt timeout, bounded retries with backoff and a circuit breaker. Calls are
+//  load-balanced across Users instances reported by an instancer (Consul catalog in production)
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/sd"
+	consulsd "github.com/go-kit/kit/sd/consul"
+	"github.com/go-kit/kit/sd/lb"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/hashicorp/consul/api"
+	"github.com/sony/gobreaker"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/constants"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+)
+
+//Config describes timeouts, retries and circuit breaker settings of Users client
+type Config struct {
+	Timeout         time.Duration //deadline of a single attempt
+	RetryMax        int           //maximum number of attempts per call
+	RetryTimeout    time.Duration //deadline of a call including all attempts
+	RetryBackoff    time.Duration //delay before the second attempt, doubled for each next one
+	BreakerFailures uint32        //consecutive failures that open the circuit
+	BreakerTimeout  time.Duration //how long the circuit stays open before probing Users again
+}
+
+//DefaultConfig returns settings used by the service unless overridden
+func DefaultConfig() Config {
+	return Config{
+		Timeout:         2 * time.Second,
+		RetryMax:        3,
+		RetryTimeout:    5 * time.Second,
+		RetryBackoff:    100 * time.Millisecond,
+		BreakerFailures: 5,
+		BreakerTimeout:  30 * time.Second,
+	}
+}
+
+//Client implements models.IUsersClient
+type Client struct {
+	endpoint endpoint.Endpoint
+	breaker  *gobreaker.CircuitBreaker
+	logger   log.Logger
+}
+
+//NewInstancer watches healthy instances of Users service in Consul catalog. Instance list is refreshed with blocking queries
+func NewInstancer(client *api.Client, service string, tags []string, logger log.Logger) *consulsd.Instancer {
+	return consulsd.NewInstancer(consulsd.NewClient(client), logger, service, tags, true)
+}
+
+//NewClient creates Users client balanced over instances. Outbound requests are sent with httpClient and authorized with tokens from source.
+//
+//A single endpointer serves all calls: go-kit endpointers subscribed to one instancer share the list of instances and
+//sort it concurrently
+func NewClient(instancer sd.Instancer, httpClient *http.Client, source models.ServiceTokenSource, cfg Config, logger log.Logger) (*Client, error) {
+	factory := makeFactory(cfg.Timeout, func(base url.URL) endpoint.Endpoint {
+		checkAuthURL, profileURL := base, base
+		checkAuthURL.Path = constants.UsersCheckAuthPath
+		profileURL.Path = constants.UsersProfilePath
+
+		checkAuth := httptransport.NewClient(
+			"GET",
+			&checkAuthURL,
+			encodeCheckAuthRequest,
+			decodeCheckAuthResponse,
+			httptransport.SetClient(httpClient),
+		).Endpoint()
+		getProfile := httptransport.NewClient(
+			"GET",
+			&profileURL,
+			makeEncodeProfileRequest(source),
+			decodeProfileResponse,
+			httptransport.SetClient(httpClient),
+		).Endpoint()
+
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			if _, ok := request.(checkAuthRequest); ok {
+				return checkAuth(ctx, request)
+			}
+			return getProfile(ctx, request)
+		}
+	})
+
+	return &Client{
+		endpoint: makeBalancedEndpoint(sd.NewEndpointer(instancer, factory, logger), cfg),
+		breaker: gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "users",
+			Timeout: cfg.BreakerTimeout,
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				return counts.ConsecutiveFailures >= cfg.BreakerFailures
+			},
+			IsSuccessful: func(err error) bool {
+				return err == nil || !isRetryable(err)
+			},
+			OnStateChange: func(name string, from, to gobreaker.State) {
+				logger.Log("breaker", name, "from", from, "to", to)
+			},
+		}),
+		logger: logger,
+	}, nil
+}
+
+//EnsureUserCreds sends authentification request to Users service
+func (c *Client) EnsureUserCreds(ctx context.Context, username, password string) error {
+	_, err := c.call(ctx, checkAuthRequest{UserName: username, Password: password})
+	return err
+}
+
+//GetUserProfile sends a request to Users service to obtain user profile by his email
+func (c *Client) GetUserProfile(ctx context.Context, email string) (models.UserProfile, error) {
+	resp, err := c.call(ctx, profileRequest{Email: email})
+	if err != nil {
+		return models.UserProfile{}, err
+	}
+
+	return resp.(models.UserProfile), nil
+}
+
+//call runs the request through circuit breaker. Users outage is reported as ErrUsersUnavailable, business errors are returned as is
+func (c *Client) call(ctx context.Context, request interface{}) (interface{}, error) {
+	resp, err := c.breaker.Execute(func() (interface{}, error) {
+		resp, err := c.endpoint(ctx, request)
+		if retryErr, ok := err.(lb.RetryError); ok {
+			err = retryErr.Final
+		}
+		return resp, err
+	})
+
+	if err != nil && (isRetryable(err) || err == gobreaker.ErrOpenState || err == gobreaker.ErrTooManyRequests) {
+		c.logger.Log("method", "call", "action", "request to Users service", "err", err)
+		return nil, errors.ErrUsersUnavailable
+	}
+
+	return resp, err
+}
+
+//makeFactory creates endpoint of a Users instance. Instances reported by Consul are "host:port" pairs and reached over HTTPS,
+//each attempt is limited with a timeout
+func makeFactory(timeout time.Duration, build func(base url.URL) endpoint.Endpoint) sd.Factory {
+	return func(instance string) (endpoint.Endpoint, io.Closer, error) {
+		if !strings.HasPrefix(instance, "http") {
+			instance = "https://" + instance
+		}
+		tgt, err := url.Parse(instance)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		e := build(*tgt)
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			return e(ctx, request)
+		}, nil, nil
+	}
+}
+
+//makeBalancedEndpoint spreads attempts over instances in round-robin manner and retries failed attempts with exponential backoff.
+//Waiting for the next attempt stops as soon as the call is canceled or runs out of time
+func makeBalancedEndpoint(endpointer sd.Endpointer, cfg Config) endpoint.Endpoint {
+	balancer := lb.NewRoundRobin(endpointer)
+
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		ctx, cancel := context.WithTimeout(ctx, cfg.RetryTimeout)
+		defer cancel()
+
+		retry := lb.RetryWithCallback(cfg.RetryTimeout, balancer, func(n int, received error) (bool, error) {
+			if n >= cfg.RetryMax || !isRetryable(received) {
+				return false, nil
+			}
+
+			backoff := time.NewTimer(cfg.RetryBackoff << uint(n-1))
+			defer backoff.Stop()
+			select {
+			case <-backoff.C:
+				return true, nil
+			case <-ctx.Done():
+				return false, nil
+			}
+		})
+
+		return retry(ctx, request)
+	}
+}
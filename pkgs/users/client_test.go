@@ -0,0 +1,186 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/sd"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/constants"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+)
+
+func testTokenSource() (models.TokenData, error) {
+	return models.TokenData{Token: "service-token", Type: "Bearer"}, nil
+}
+
+func testConfig() Config {
+	cfg := DefaultConfig()
+	cfg.Timeout = 100 * time.Millisecond
+	cfg.RetryTimeout = time.Second
+	cfg.RetryBackoff = time.Millisecond
+	cfg.BreakerFailures = 2
+	return cfg
+}
+
+func TestGetUserProfile_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, constants.UsersProfilePath, r.URL.Path)
+		assert.Equal(t, "gladys.champl@edms.com", r.URL.Query().Get("email"))
+		assert.Equal(t, "Bearer service-token", r.Header.Get("Authorization"))
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(models.UserProfile{Email: "gladys.champl@edms.com", Role: models.UserRole{Mask: 2048}})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(sd.FixedInstancer{server.URL}, server.Client(), testTokenSource, testConfig(), log.NewNopLogger())
+	assert.NoError(t, err)
+
+	profile, err := client.GetUserProfile(context.Background(), "gladys.champl@edms.com")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2048), profile.Role.Mask)
+}
+
+func TestEnsureUserCreds_Unauthorized(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(sd.FixedInstancer{server.URL}, server.Client(), testTokenSource, testConfig(), log.NewNopLogger())
+	assert.NoError(t, err)
+
+	err = client.EnsureUserCreds(context.Background(), "admin", "wrong")
+	assert.Equal(t, errors.ErrNonAuthorized, err)
+	//Business errors are not retried
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestEnsureUserCreds_RetriesServerErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(sd.FixedInstancer{server.URL}, server.Client(), testTokenSource, testConfig(), log.NewNopLogger())
+	assert.NoError(t, err)
+
+	assert.NoError(t, client.EnsureUserCreds(context.Background(), "admin", "a@m1n"))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestEnsureUserCreds_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(sd.FixedInstancer{server.URL}, server.Client(), testTokenSource, testConfig(), log.NewNopLogger())
+	assert.NoError(t, err)
+
+	err = client.EnsureUserCreds(context.Background(), "admin", "a@m1n")
+	assert.Equal(t, errors.ErrUsersUnavailable, err)
+}
+
+func TestCircuitBreaker_FailsFast(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(sd.FixedInstancer{server.URL}, server.Client(), testTokenSource, testConfig(), log.NewNopLogger())
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, errors.ErrUsersUnavailable, client.EnsureUserCreds(context.Background(), "admin", "a@m1n"))
+	}
+
+	//Circuit is open now, Users service is not called anymore
+	before := atomic.LoadInt32(&calls)
+	assert.Equal(t, errors.ErrUsersUnavailable, client.EnsureUserCreds(context.Background(), "admin", "a@m1n"))
+	assert.Equal(t, before, atomic.LoadInt32(&calls))
+}
+
+func TestClient_BalancesAcrossInstances(t *testing.T) {
+	var first, second int32
+	handler := func(counter *int32) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(counter, 1)
+			w.WriteHeader(http.StatusOK)
+		})
+	}
+	serverA := httptest.NewServer(handler(&first))
+	defer serverA.Close()
+	serverB := httptest.NewServer(handler(&second))
+	defer serverB.Close()
+
+	client, err := NewClient(sd.FixedInstancer{serverA.URL, serverB.URL}, http.DefaultClient, testTokenSource, testConfig(), log.NewNopLogger())
+	assert.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, client.EnsureUserCreds(context.Background(), "admin", "a@m1n"))
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&first))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&second))
+}
+
+func TestClient_SkipsUnreachableInstance(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	//Closed listener: the first attempt fails and is retried on the healthy instance
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+
+	client, err := NewClient(sd.FixedInstancer{dead.URL, server.URL}, http.DefaultClient, testTokenSource, testConfig(), log.NewNopLogger())
+	assert.NoError(t, err)
+
+	assert.NoError(t, client.EnsureUserCreds(context.Background(), "admin", "a@m1n"))
+}
+
+func TestClient_NoInstances(t *testing.T) {
+	client, err := NewClient(sd.FixedInstancer{}, http.DefaultClient, testTokenSource, testConfig(), log.NewNopLogger())
+	assert.NoError(t, err)
+
+	assert.Equal(t, errors.ErrUsersUnavailable, client.EnsureUserCreds(context.Background(), "admin", "a@m1n"))
+}
+
+func TestEnsureUserCreds_BackoffStopsOnCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	cfg := testConfig()
+	cfg.RetryTimeout = time.Minute
+	cfg.RetryBackoff = time.Minute
+	client, err := NewClient(sd.FixedInstancer{server.URL}, server.Client(), testTokenSource, cfg, log.NewNopLogger())
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	assert.Equal(t, errors.ErrUsersUnavailable, client.EnsureUserCreds(ctx, "admin", "a@m1n"))
+	assert.True(t, time.Since(start) < time.Second)
+}
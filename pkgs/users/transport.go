@@ -0,0 +1,109 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-kit/kit/sd/lb"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+)
+
+type checkAuthRequest struct {
+	UserName string
+	Password string
+}
+
+type profileRequest struct {
+	Email string
+}
+
+//statusError is returned when Users service responds with unexpected status code
+type statusError int
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("Users service responded with code: %v", int(e))
+}
+
+//isRetryable reports whether a call failed because Users service is unreachable or overloaded, so the call may be repeated
+func isRetryable(err error) bool {
+	if err == lb.ErrNoEndpoints {
+		return true
+	}
+
+	switch e := err.(type) {
+	case net.Error:
+		return true
+	case statusError:
+		return int(e) >= http.StatusInternalServerError
+	}
+
+	return false
+}
+
+func encodeCheckAuthRequest(_ context.Context, r *http.Request, request interface{}) error {
+	req := request.(checkAuthRequest)
+	r.SetBasicAuth(req.UserName, req.Password)
+	return nil
+}
+
+func decodeCheckAuthResponse(_ context.Context, resp *http.Response) (interface{}, error) {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil, nil
+	case http.StatusUnauthorized:
+		return nil, errors.ErrNonAuthorized
+	case http.StatusNotFound:
+		return nil, errors.ErrClientUnknown
+	default:
+		return nil, statusError(resp.StatusCode)
+	}
+}
+
+//makeEncodeProfileRequest returns encoder that authorizes each attempt with a fresh service token
+func makeEncodeProfileRequest(source models.ServiceTokenSource) func(context.Context, *http.Request, interface{}) error {
+	return func(_ context.Context, r *http.Request, request interface{}) error {
+		req := request.(profileRequest)
+		r.URL.RawQuery = url.Values{"email": []string{req.Email}}.Encode()
+
+		serviceToken, err := source()
+		if err != nil {
+			return err
+		}
+
+		r.Header.Set("Authorization", serviceToken.Type+" "+serviceToken.Token)
+		return nil
+	}
+}
+
+func decodeProfileResponse(_ context.Context, resp *http.Response) (interface{}, error) {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		break
+	case http.StatusBadRequest:
+		return nil, errors.ErrRequestToUsersFailed
+	case http.StatusUnauthorized:
+		return nil, errors.ErrNonAuthorized
+	case http.StatusNotFound:
+		return nil, errors.ErrClientUnknown
+	default:
+		return nil, statusError(resp.StatusCode)
+	}
+
+	if !strings.Contains(resp.Header.Get("Content-Type"), "application/json") {
+		return nil, fmt.Errorf("Get profile received content type: %v", resp.Header.Get("Content-Type"))
+	}
+
+	var profile models.UserProfile
+	if err := json.NewDecoder(resp.Body).Decode(&profile); err != nil {
+		return nil, fmt.Errorf("Profile decoding failed")
+	}
+
+	return profile, nil
+}
@@ -0,0 +1,43 @@
+package users
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+)
+
+//MakeHTTPClient creates a client that verifies Users service certificate against CA bundle (or system roots if bundle is empty)
+//and presents a client certificate when one is configured
+func MakeHTTPClient(cfg models.UsersTLSConfig) (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		ServerName: cfg.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if len(cfg.CA) > 0 {
+		caCertPool := x509.NewCertPool()
+		if ok := caCertPool.AppendCertsFromPEM(cfg.CA); !ok {
+			return nil, errors.ErrInvalidCABundle
+		}
+		tlsConfig.RootCAs = caCertPool
+	}
+
+	if len(cfg.Cert) > 0 || len(cfg.Key) > 0 {
+		cert, err := tls.X509KeyPair(cfg.Cert, cfg.Key)
+		if err != nil {
+			return nil, errors.ErrInvalidClientCert
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsConfig,
+		},
+	}
+
+	return client, nil
+}
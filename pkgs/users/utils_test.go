@@ -0,0 +1,76 @@
+package users
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+)
+
+func generateTestCertificate(t *testing.T) (certPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sessions"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	return certPEM, keyPEM
+}
+
+func TestMakeHTTPClient_SystemRoots(t *testing.T) {
+	client, err := MakeHTTPClient(models.UsersTLSConfig{})
+	assert.NoError(t, err)
+
+	tlsConfig := client.Transport.(*http.Transport).TLSClientConfig
+	assert.False(t, tlsConfig.InsecureSkipVerify)
+	assert.Nil(t, tlsConfig.RootCAs)
+}
+
+func TestMakeHTTPClient_MutualTLS(t *testing.T) {
+	certPEM, keyPEM := generateTestCertificate(t)
+
+	client, err := MakeHTTPClient(models.UsersTLSConfig{
+		CA:         certPEM,
+		Cert:       certPEM,
+		Key:        keyPEM,
+		ServerName: "users.service.consul",
+	})
+	assert.NoError(t, err)
+
+	tlsConfig := client.Transport.(*http.Transport).TLSClientConfig
+	assert.False(t, tlsConfig.InsecureSkipVerify)
+	assert.NotNil(t, tlsConfig.RootCAs)
+	assert.Len(t, tlsConfig.Certificates, 1)
+	assert.Equal(t, "users.service.consul", tlsConfig.ServerName)
+}
+
+func TestMakeHTTPClient_InvalidMaterial(t *testing.T) {
+	certPEM, _ := generateTestCertificate(t)
+
+	_, err := MakeHTTPClient(models.UsersTLSConfig{CA: []byte("not a certificate")})
+	assert.Equal(t, errors.ErrInvalidCABundle, err)
+
+	_, err = MakeHTTPClient(models.UsersTLSConfig{Cert: certPEM})
+	assert.Equal(t, errors.ErrInvalidClientCert, err)
+}
@@ -0,0 +1,131 @@
+package webauthn
+
+import (
+	"encoding/binary"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+)
+
+//maxCBORDepth limits nesting of arrays, maps and tags. Attestation objects and COSE keys are nested a few levels deep,
+//deeper input is rejected before it exhausts the stack
+const maxCBORDepth = 16
+
+//decodeCBOR decodes a single CBOR item (https://tools.ietf.org/html/rfc7049) and returns it along with the rest of data.
+//Only definite-length items that authenticators use are supported. Items are decoded to
+//int64, []byte, string, []interface{}, map[interface{}]interface{}, bool or nil
+func decodeCBOR(data []byte) (interface{}, []byte, error) {
+	return decodeCBORItem(data, 0)
+}
+
+//decodeCBORItem decodes an item nested depth levels deep. Counts of items are checked against the rest of data before
+//anything is allocated, since every item takes at least one byte
+func decodeCBORItem(data []byte, depth int) (interface{}, []byte, error) {
+	if len(data) == 0 || depth > maxCBORDepth {
+		return nil, nil, errors.ErrMalformedAuthenticatorData
+	}
+
+	major, info := data[0]>>5, data[0]&0x1f
+	data = data[1:]
+
+	if major == 7 {
+		switch info {
+		case 20:
+			return false, data, nil
+		case 21:
+			return true, data, nil
+		case 22, 23:
+			return nil, data, nil
+		default:
+			return nil, nil, errors.ErrMalformedAuthenticatorData
+		}
+	}
+
+	var arg uint64
+	switch {
+	case info < 24:
+		arg = uint64(info)
+	case info <= 27:
+		size := 1 << (info - 24)
+		if len(data) < size {
+			return nil, nil, errors.ErrMalformedAuthenticatorData
+		}
+		for _, b := range data[:size] {
+			arg = arg<<8 | uint64(b)
+		}
+		data = data[size:]
+	default:
+		return nil, nil, errors.ErrMalformedAuthenticatorData
+	}
+
+	switch major {
+	case 0:
+		if arg > 1<<63-1 {
+			return nil, nil, errors.ErrMalformedAuthenticatorData
+		}
+		return int64(arg), data, nil
+	case 1:
+		if arg > 1<<63-1 {
+			return nil, nil, errors.ErrMalformedAuthenticatorData
+		}
+		return -1 - int64(arg), data, nil
+	case 2, 3:
+		if arg > uint64(len(data)) {
+			return nil, nil, errors.ErrMalformedAuthenticatorData
+		}
+		value := data[:arg]
+		if major == 3 {
+			return string(value), data[arg:], nil
+		}
+		return append([]byte(nil), value...), data[arg:], nil
+	case 4:
+		if arg > uint64(len(data)) {
+			return nil, nil, errors.ErrMalformedAuthenticatorData
+		}
+		items := make([]interface{}, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			var (
+				item interface{}
+				err  error
+			)
+			if item, data, err = decodeCBORItem(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, data, nil
+	case 5:
+		if arg > uint64(len(data))/2 {
+			return nil, nil, errors.ErrMalformedAuthenticatorData
+		}
+		items := make(map[interface{}]interface{}, arg)
+		for i := uint64(0); i < arg; i++ {
+			var (
+				key, value interface{}
+				err        error
+			)
+			if key, data, err = decodeCBORItem(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, errors.ErrMalformedAuthenticatorData
+			}
+			if value, data, err = decodeCBORItem(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			items[key] = value
+		}
+		return items, data, nil
+	case 6:
+		//Semantic tags carry no meaning for WebAuthn structures, the tagged item is returned as is
+		return decodeCBORItem(data, depth+1)
+	}
+
+	return nil, nil, errors.ErrMalformedAuthenticatorData
+}
+
+//uint16At reads big-endian uint16 from data
+func uint16At(data []byte) uint16 {
+	return binary.BigEndian.Uint16(data)
+}
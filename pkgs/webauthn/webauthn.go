@@ -0,0 +1,349 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+)
+
+const (
+	//Timeout is how long a ceremony challenge stays valid
+	Timeout = 5 * time.Minute
+	//AlgES256 and AlgEdDSA are COSE identifiers of supported signature algorithms. See: https://www.iana.org/assignments/cose/cose.xhtml
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	//CeremonyCreate and CeremonyGet are client data types of registration and authentication
+	CeremonyCreate = "webauthn.create"
+	CeremonyGet    = "webauthn.get"
+	//challengeSize is a number of random bytes in a challenge as recommended in https://www.w3.org/TR/webauthn-2/#sctn-cryptographic-challenges
+	challengeSize = 32
+)
+
+//Authenticator data flags. See: https://www.w3.org/TR/webauthn-2/#sctn-authenticator-data
+const (
+	flagUserPresent  byte = 0x01
+	flagUserVerified byte = 0x04
+	flagAttestedData byte = 0x40
+	flagExtensions   byte = 0x80
+)
+
+//COSE key parameters. See: https://tools.ietf.org/html/rfc8152#section-13
+const (
+	coseKty     int64 = 1
+	coseAlg     int64 = 3
+	coseCrv     int64 = -1
+	coseX       int64 = -2
+	coseY       int64 = -3
+	ktyOKP      int64 = 1
+	ktyEC2      int64 = 2
+	crvP256     int64 = 1
+	crvEd25519  int64 = 6
+	rpIDHashLen       = 32
+	authDataLen       = rpIDHashLen + 1 + 4
+)
+
+//Encoding is used for all binary values exchanged with browsers
+var Encoding = base64.RawURLEncoding
+
+//RelyingParty verifies ceremonies performed by authenticators for the service identified by ID
+type RelyingParty struct {
+	ID                      string
+	Name                    string
+	Origins                 []string
+	RequireUserVerification bool
+}
+
+//Credential is a public key credential produced by registration
+type Credential struct {
+	ID        []byte
+	PublicKey []byte
+	SignCount uint32
+}
+
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+type authenticatorData struct {
+	rpIDHash     []byte
+	flags        byte
+	signCount    uint32
+	credentialID []byte
+	publicKey    map[interface{}]interface{}
+}
+
+//NewRelyingParty creates relying party that requires user verification, as passwordless login relies on it
+func NewRelyingParty(id, name string, origins ...string) *RelyingParty {
+	return &RelyingParty{
+		ID:                      id,
+		Name:                    name,
+		Origins:                 origins,
+		RequireUserVerification: true,
+	}
+}
+
+//NewChallenge creates a random challenge for a ceremony
+func NewChallenge() (string, error) {
+	raw := make([]byte, challengeSize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	return Encoding.EncodeToString(raw), nil
+}
+
+//DecodeBase64 decodes base64url value with or without padding
+func DecodeBase64(value string) ([]byte, error) {
+	raw, err := Encoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, errors.ErrMalformedAuthenticatorData
+	}
+
+	return raw, nil
+}
+
+//VerifyRegistration checks response of navigator.credentials.create() to the challenge and returns the new credential.
+//Only "none" attestation is accepted. See: https://www.w3.org/TR/webauthn-2/#sctn-registering-a-new-credential
+func (rp *RelyingParty) VerifyRegistration(challenge string, clientDataJSON, attestationObject []byte) (Credential, error) {
+	if err := rp.verifyClientData(CeremonyCreate, challenge, clientDataJSON); err != nil {
+		return Credential{}, err
+	}
+
+	item, rest, err := decodeCBOR(attestationObject)
+	if err != nil {
+		return Credential{}, err
+	}
+	attestation, ok := item.(map[interface{}]interface{})
+	if !ok || len(rest) != 0 {
+		return Credential{}, errors.ErrMalformedAuthenticatorData
+	}
+
+	format, _ := attestation["fmt"].(string)
+	statement, _ := attestation["attStmt"].(map[interface{}]interface{})
+	if format != "none" || len(statement) != 0 {
+		return Credential{}, errors.ErrWebAuthnVerification
+	}
+
+	raw, ok := attestation["authData"].([]byte)
+	if !ok {
+		return Credential{}, errors.ErrMalformedAuthenticatorData
+	}
+
+	data, err := parseAuthenticatorData(raw)
+	if err != nil {
+		return Credential{}, err
+	}
+	if err = rp.verifyAuthenticatorData(data); err != nil {
+		return Credential{}, err
+	}
+	if data.flags&flagAttestedData == 0 {
+		return Credential{}, errors.ErrMalformedAuthenticatorData
+	}
+
+	publicKey, err := parsePublicKey(data.publicKey)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	return Credential{
+		ID:        data.credentialID,
+		PublicKey: der,
+		SignCount: data.signCount,
+	}, nil
+}
+
+//VerifyAssertion checks response of navigator.credentials.get() to the challenge with the credential and returns the new
+//signature counter. A counter that did not increase signals a cloned authenticator.
+//See: https://www.w3.org/TR/webauthn-2/#sctn-verifying-assertion
+func (rp *RelyingParty) VerifyAssertion(challenge string, credential Credential, clientDataJSON, authData, signature []byte) (uint32, error) {
+	if err := rp.verifyClientData(CeremonyGet, challenge, clientDataJSON); err != nil {
+		return 0, err
+	}
+
+	data, err := parseAuthenticatorData(authData)
+	if err != nil {
+		return 0, err
+	}
+	if err = rp.verifyAuthenticatorData(data); err != nil {
+		return 0, err
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(credential.PublicKey)
+	if err != nil {
+		return 0, errors.ErrWebAuthnVerification
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte(nil), authData...), clientDataHash[:]...)
+
+	var valid bool
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(signed)
+		valid = ecdsa.VerifyASN1(key, digest[:], signature)
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, signed, signature)
+	}
+	if !valid {
+		return 0, errors.ErrWebAuthnVerification
+	}
+
+	//Authenticators that do not implement the counter always report zero
+	if (data.signCount != 0 || credential.SignCount != 0) && data.signCount <= credential.SignCount {
+		return 0, errors.ErrSignCountRegression
+	}
+
+	return data.signCount, nil
+}
+
+func (rp *RelyingParty) verifyClientData(ceremony, challenge string, raw []byte) error {
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return errors.ErrMalformedAuthenticatorData
+	}
+
+	if data.Type != ceremony || data.CrossOrigin {
+		return errors.ErrWebAuthnVerification
+	}
+
+	expected := strings.TrimRight(challenge, "=")
+	if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(strings.TrimRight(data.Challenge, "="))) != 1 {
+		return errors.ErrWebAuthnChallenge
+	}
+
+	for _, origin := range rp.Origins {
+		if data.Origin == origin {
+			return nil
+		}
+	}
+
+	return errors.ErrWebAuthnVerification
+}
+
+func (rp *RelyingParty) verifyAuthenticatorData(data authenticatorData) error {
+	expected := sha256.Sum256([]byte(rp.ID))
+	if subtle.ConstantTimeCompare(expected[:], data.rpIDHash) != 1 {
+		return errors.ErrWebAuthnVerification
+	}
+
+	if data.flags&flagUserPresent == 0 {
+		return errors.ErrWebAuthnVerification
+	}
+
+	if rp.RequireUserVerification && data.flags&flagUserVerified == 0 {
+		return errors.ErrWebAuthnVerification
+	}
+
+	return nil
+}
+
+func parseAuthenticatorData(raw []byte) (authenticatorData, error) {
+	if len(raw) < authDataLen {
+		return authenticatorData{}, errors.ErrMalformedAuthenticatorData
+	}
+
+	data := authenticatorData{
+		rpIDHash:  raw[:rpIDHashLen],
+		flags:     raw[rpIDHashLen],
+		signCount: binary.BigEndian.Uint32(raw[rpIDHashLen+1 : authDataLen]),
+	}
+	rest := raw[authDataLen:]
+
+	if data.flags&flagAttestedData != 0 {
+		//AAGUID (16 bytes) and length of credential ID (2 bytes) precede credential ID
+		if len(rest) < 18 {
+			return authenticatorData{}, errors.ErrMalformedAuthenticatorData
+		}
+		size := int(uint16At(rest[16:18]))
+		rest = rest[18:]
+		if size == 0 || len(rest) < size {
+			return authenticatorData{}, errors.ErrMalformedAuthenticatorData
+		}
+		data.credentialID = append([]byte(nil), rest[:size]...)
+
+		item, tail, err := decodeCBOR(rest[size:])
+		if err != nil {
+			return authenticatorData{}, err
+		}
+		key, ok := item.(map[interface{}]interface{})
+		if !ok {
+			return authenticatorData{}, errors.ErrMalformedAuthenticatorData
+		}
+		data.publicKey = key
+		rest = tail
+	}
+
+	if data.flags&flagExtensions != 0 {
+		_, tail, err := decodeCBOR(rest)
+		if err != nil {
+			return authenticatorData{}, err
+		}
+		rest = tail
+	}
+
+	if len(rest) != 0 {
+		return authenticatorData{}, errors.ErrMalformedAuthenticatorData
+	}
+
+	return data, nil
+}
+
+//parsePublicKey converts COSE key of a supported algorithm to crypto public key
+func parsePublicKey(key map[interface{}]interface{}) (interface{}, error) {
+	x, _ := key[coseX].([]byte)
+
+	switch {
+	case key[coseKty] == ktyEC2 && key[coseAlg] == AlgES256 && key[coseCrv] == crvP256:
+		y, _ := key[coseY].([]byte)
+		if len(x) != 32 || len(y) != 32 {
+			return nil, errors.ErrMalformedAuthenticatorData
+		}
+
+		publicKey := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !publicKey.Curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return nil, errors.ErrMalformedAuthenticatorData
+		}
+		return publicKey, nil
+	case key[coseKty] == ktyOKP && key[coseAlg] == AlgEdDSA && key[coseCrv] == crvEd25519:
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.ErrMalformedAuthenticatorData
+		}
+		return ed25519.PublicKey(append([]byte(nil), x...)), nil
+	}
+
+	return nil, errors.ErrWebAuthnVerification
+}
+
+//ChallengeOf returns challenge that client data was created for, so that the matching ceremony can be looked up
+func ChallengeOf(clientDataJSON []byte) (string, error) {
+	var data clientData
+	if err := json.Unmarshal(clientDataJSON, &data); err != nil || data.Challenge == "" {
+		return "", errors.ErrMalformedAuthenticatorData
+	}
+
+	return strings.TrimRight(data.Challenge, "="), nil
+}
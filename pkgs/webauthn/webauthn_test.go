@@ -0,0 +1,170 @@
+package webauthn
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/errors"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/webauthn/webauthntest"
+)
+
+const (
+	testRPID   = "edms.com"
+	testOrigin = "https://edms.com"
+)
+
+func register(t *testing.T, rp *RelyingParty, authenticator *webauthntest.Authenticator) (models.WebAuthnCredentialData, Credential, error) {
+	challenge, err := NewChallenge()
+	assert.NoError(t, err)
+
+	response, err := authenticator.Create(models.WebAuthnCreationOptions{Challenge: challenge, User: models.WebAuthnUser{ID: "dXNlcg"}})
+	assert.NoError(t, err)
+
+	clientData, err := DecodeBase64(response.Response.ClientDataJSON)
+	assert.NoError(t, err)
+	attestation, err := DecodeBase64(response.Response.AttestationObject)
+	assert.NoError(t, err)
+
+	credential, err := rp.VerifyRegistration(challenge, clientData, attestation)
+	return response, credential, err
+}
+
+func assertWith(t *testing.T, rp *RelyingParty, authenticator *webauthntest.Authenticator, credential Credential) (uint32, error) {
+	challenge, err := NewChallenge()
+	assert.NoError(t, err)
+
+	response, err := authenticator.Get(models.WebAuthnRequestOptions{Challenge: challenge})
+	assert.NoError(t, err)
+
+	clientData, err := DecodeBase64(response.Response.ClientDataJSON)
+	assert.NoError(t, err)
+	authData, err := DecodeBase64(response.Response.AuthenticatorData)
+	assert.NoError(t, err)
+	signature, err := DecodeBase64(response.Response.Signature)
+	assert.NoError(t, err)
+
+	return rp.VerifyAssertion(challenge, credential, clientData, authData, signature)
+}
+
+func TestRegistrationAndAssertion(t *testing.T) {
+	rp := NewRelyingParty(testRPID, "Soroka EDMS", testOrigin)
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+
+	response, credential, err := register(t, rp, authenticator)
+	assert.NoError(t, err)
+	assert.Equal(t, response.ID, Encoding.EncodeToString(credential.ID))
+	assert.NotEmpty(t, credential.PublicKey)
+
+	count, err := assertWith(t, rp, authenticator, credential)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), count)
+
+	//Authenticator cloned before the last assertion reports a counter that did not increase
+	credential.SignCount = count
+	authenticator.Counter = 0
+	_, err = assertWith(t, rp, authenticator, credential)
+	assert.Equal(t, errors.ErrSignCountRegression, err)
+}
+
+func TestRegistration_Rejected(t *testing.T) {
+	rp := NewRelyingParty(testRPID, "Soroka EDMS", testOrigin)
+
+	var testData = []struct {
+		name          string
+		authenticator *webauthntest.Authenticator
+	}{
+		{"foreign origin", webauthntest.NewAuthenticator(testRPID, "https://edms.com.evil.org")},
+		{"foreign RP ID", webauthntest.NewAuthenticator("evil.org", testOrigin)},
+		{"no user verification", &webauthntest.Authenticator{RPID: testRPID, Origin: testOrigin, SkipVerified: true}},
+	}
+
+	for _, td := range testData {
+		_, _, err := register(t, rp, td.authenticator)
+		assert.Equal(t, errors.ErrWebAuthnVerification, err, td.name)
+	}
+}
+
+func TestAssertion_WrongChallenge(t *testing.T) {
+	rp := NewRelyingParty(testRPID, "Soroka EDMS", testOrigin)
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+
+	_, credential, err := register(t, rp, authenticator)
+	assert.NoError(t, err)
+
+	response, err := authenticator.Get(models.WebAuthnRequestOptions{Challenge: "b2xk"})
+	assert.NoError(t, err)
+
+	clientData, _ := DecodeBase64(response.Response.ClientDataJSON)
+	authData, _ := DecodeBase64(response.Response.AuthenticatorData)
+	signature, _ := DecodeBase64(response.Response.Signature)
+
+	_, err = rp.VerifyAssertion("bmV3", credential, clientData, authData, signature)
+	assert.Equal(t, errors.ErrWebAuthnChallenge, err)
+
+	//Signature does not cover altered authenticator data
+	authData[len(authData)-1]++
+	_, err = rp.VerifyAssertion("b2xk", credential, clientData, authData, signature)
+	assert.Equal(t, errors.ErrWebAuthnVerification, err)
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	//{1: -7, "a": [h'0102', true]}
+	item, rest, err := decodeCBOR([]byte{0xa2, 0x01, 0x26, 0x61, 'a', 0x82, 0x42, 0x01, 0x02, 0xf5, 0xff})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0xff}, rest)
+	assert.Equal(t, map[interface{}]interface{}{
+		int64(1): int64(-7),
+		"a":      []interface{}{[]byte{1, 2}, true},
+	}, item)
+
+	//Truncated byte string
+	_, _, err = decodeCBOR([]byte{0x45, 0x01})
+	assert.Error(t, err)
+}
+
+func TestDecodeCBOR_Hostile(t *testing.T) {
+	nested := func(head byte, n int) []byte {
+		return append(bytes.Repeat([]byte{head}, n), 0x00)
+	}
+
+	//Nesting within the limit is decoded
+	_, _, err := decodeCBOR(nested(0x81, maxCBORDepth))
+	assert.NoError(t, err)
+
+	//Deeply nested arrays, maps and tags are rejected without exhausting the stack
+	for _, data := range [][]byte{
+		nested(0x81, 1<<20),
+		nested(0xc0, 1<<20),
+		append(bytes.Repeat([]byte{0xa1, 0x00}, 1<<20), 0x00),
+	} {
+		_, _, err = decodeCBOR(data)
+		assert.Equal(t, errors.ErrMalformedAuthenticatorData, err)
+	}
+
+	//Counts larger than the input are rejected before allocation
+	for _, data := range [][]byte{
+		{0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		{0xbb, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		{0xa2, 0x01, 0x02},
+		{0x5b, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+	} {
+		_, _, err = decodeCBOR(data)
+		assert.Equal(t, errors.ErrMalformedAuthenticatorData, err)
+	}
+}
+
+func FuzzDecodeCBOR(f *testing.F) {
+	f.Add([]byte{0xa2, 0x01, 0x26, 0x61, 'a', 0x82, 0x42, 0x01, 0x02, 0xf5})
+	f.Add([]byte{0x81, 0x81, 0x81, 0x00})
+	f.Add([]byte{0xc0, 0xa1, 0x00, 0x9b, 0xff})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, rest, err := decodeCBOR(data)
+		if err == nil && len(rest) > len(data) {
+			t.Fatal("rest is longer than input")
+		}
+	})
+}
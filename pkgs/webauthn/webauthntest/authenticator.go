@@ -0,0 +1,227 @@
+//Package webauthntest provides a software authenticator that performs WebAuthn ceremonies the way a browser
+//with a platform authenticator does. It is intended for tests only
+package webauthntest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+
+	"github.com/Soroka-EDMS/svc/sessions/pkgs/models"
+)
+
+//Authenticator data flags. See: https://www.w3.org/TR/webauthn-2/#sctn-authenticator-data
+const (
+	flagUserPresent  byte = 0x01
+	flagUserVerified byte = 0x04
+	flagAttestedData byte = 0x40
+)
+
+var encoding = base64.RawURLEncoding
+
+//ErrNoCredential is returned by Get if authenticator does not hold any of the allowed credentials
+var ErrNoCredential = errors.New("webauthntest: no matching credential")
+
+type credential struct {
+	id         string
+	key        *ecdsa.PrivateKey
+	userHandle string
+}
+
+//Authenticator holds ES256 credentials in memory. RPID and Origin are those the browser would report, so setting them
+//to foreign values emulates phishing. Counter is a signature counter shared by all credentials, it is incremented
+//before every assertion; rewinding it emulates a cloned authenticator. SkipVerified emulates authenticator without user verification
+type Authenticator struct {
+	RPID         string
+	Origin       string
+	Counter      uint32
+	SkipVerified bool
+	credentials  []credential
+}
+
+//NewAuthenticator creates authenticator bound to the relying party
+func NewAuthenticator(rpID, origin string) *Authenticator {
+	return &Authenticator{
+		RPID:   rpID,
+		Origin: origin,
+	}
+}
+
+//Create performs navigator.credentials.create() with "none" attestation
+func (a *Authenticator) Create(options models.WebAuthnCreationOptions) (models.WebAuthnCredentialData, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return models.WebAuthnCredentialData{}, err
+	}
+
+	id := make([]byte, 16)
+	if _, err = rand.Read(id); err != nil {
+		return models.WebAuthnCredentialData{}, err
+	}
+
+	clientData, err := a.clientData("webauthn.create", options.Challenge)
+	if err != nil {
+		return models.WebAuthnCredentialData{}, err
+	}
+
+	//Attested credential data: zero AAGUID, length of credential ID, credential ID and COSE key
+	attested := make([]byte, 16, 16+2+len(id))
+	attested = append(attested, byte(len(id)>>8), byte(len(id)))
+	attested = append(attested, id...)
+
+	var coseKey cbor
+	coseKey.mapHeader(5)
+	coseKey.int(1).int(2)
+	coseKey.int(3).int(-7)
+	coseKey.int(-1).int(1)
+	coseKey.int(-2).bytes(pad32(key.X.Bytes()))
+	coseKey.int(-3).bytes(pad32(key.Y.Bytes()))
+	attested = append(attested, coseKey...)
+
+	authData := a.authenticatorData(flagAttestedData, 0, attested)
+
+	var attestation cbor
+	attestation.mapHeader(3)
+	attestation.text("fmt").text("none")
+	attestation.text("attStmt").mapHeader(0)
+	attestation.text("authData").bytes(authData)
+
+	a.credentials = append(a.credentials, credential{
+		id:         encoding.EncodeToString(id),
+		key:        key,
+		userHandle: options.User.ID,
+	})
+
+	return models.WebAuthnCredentialData{
+		ID:   encoding.EncodeToString(id),
+		Type: "public-key",
+		Response: models.WebAuthnResponseData{
+			ClientDataJSON:    encoding.EncodeToString(clientData),
+			AttestationObject: encoding.EncodeToString(attestation),
+		},
+	}, nil
+}
+
+//Get performs navigator.credentials.get(). If options do not list allowed credentials, the first discoverable one is used
+func (a *Authenticator) Get(options models.WebAuthnRequestOptions) (models.WebAuthnCredentialData, error) {
+	selected, ok := a.find(options.AllowCredentials)
+	if !ok {
+		return models.WebAuthnCredentialData{}, ErrNoCredential
+	}
+
+	clientData, err := a.clientData("webauthn.get", options.Challenge)
+	if err != nil {
+		return models.WebAuthnCredentialData{}, err
+	}
+
+	a.Counter++
+	authData := a.authenticatorData(0, a.Counter, nil)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, selected.key, digest[:])
+	if err != nil {
+		return models.WebAuthnCredentialData{}, err
+	}
+
+	return models.WebAuthnCredentialData{
+		ID:   selected.id,
+		Type: "public-key",
+		Response: models.WebAuthnResponseData{
+			ClientDataJSON:    encoding.EncodeToString(clientData),
+			AuthenticatorData: encoding.EncodeToString(authData),
+			Signature:         encoding.EncodeToString(signature),
+			UserHandle:        selected.userHandle,
+		},
+	}, nil
+}
+
+func (a *Authenticator) find(allowed []models.WebAuthnCredentialDescriptor) (credential, bool) {
+	for _, c := range a.credentials {
+		if len(allowed) == 0 {
+			return c, true
+		}
+		for _, descriptor := range allowed {
+			if descriptor.ID == c.id {
+				return c, true
+			}
+		}
+	}
+
+	return credential{}, false
+}
+
+func (a *Authenticator) clientData(ceremony, challenge string) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"type":        ceremony,
+		"challenge":   challenge,
+		"origin":      a.Origin,
+		"crossOrigin": false,
+	})
+}
+
+func (a *Authenticator) authenticatorData(flags byte, counter uint32, attested []byte) []byte {
+	flags |= flagUserPresent
+	if !a.SkipVerified {
+		flags |= flagUserVerified
+	}
+
+	rpIDHash := sha256.Sum256([]byte(a.RPID))
+	data := append(rpIDHash[:], flags, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(data[len(data)-4:], counter)
+
+	return append(data, attested...)
+}
+
+func pad32(value []byte) []byte {
+	return append(make([]byte, 32-len(value)), value...)
+}
+
+//cbor is a minimal CBOR encoder for structures that authenticators produce
+type cbor []byte
+
+func (c *cbor) head(major byte, arg uint64) *cbor {
+	switch {
+	case arg < 24:
+		*c = append(*c, major<<5|byte(arg))
+	case arg <= 0xff:
+		*c = append(*c, major<<5|24, byte(arg))
+	case arg <= 0xffff:
+		*c = append(*c, major<<5|25, byte(arg>>8), byte(arg))
+	default:
+		*c = append(*c, major<<5|26, byte(arg>>24), byte(arg>>16), byte(arg>>8), byte(arg))
+	}
+
+	return c
+}
+
+func (c *cbor) int(value int64) *cbor {
+	if value < 0 {
+		return c.head(1, uint64(-1-value))
+	}
+
+	return c.head(0, uint64(value))
+}
+
+func (c *cbor) bytes(value []byte) *cbor {
+	c.head(2, uint64(len(value)))
+	*c = append(*c, value...)
+
+	return c
+}
+
+func (c *cbor) text(value string) *cbor {
+	c.head(3, uint64(len(value)))
+	*c = append(*c, value...)
+
+	return c
+}
+
+func (c *cbor) mapHeader(size int) *cbor {
+	return c.head(5, uint64(size))
+}